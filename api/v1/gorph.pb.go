@@ -304,6 +304,77 @@ func (OutputFormat) EnumDescriptor() ([]byte, []int) {
 	return file_gorph_proto_rawDescGZIP(), []int{3}
 }
 
+// Kinds of change reported by WatchInfrastructure
+type ChangeType int32
+
+const (
+	ChangeType_CHANGE_TYPE_UNSPECIFIED            ChangeType = 0
+	ChangeType_CHANGE_TYPE_SNAPSHOT               ChangeType = 1 // Full current state
+	ChangeType_CHANGE_TYPE_ENTITY_CREATED         ChangeType = 2
+	ChangeType_CHANGE_TYPE_ENTITY_UPDATED         ChangeType = 3
+	ChangeType_CHANGE_TYPE_ENTITY_DELETED         ChangeType = 4
+	ChangeType_CHANGE_TYPE_CONNECTION_CREATED     ChangeType = 5
+	ChangeType_CHANGE_TYPE_CONNECTION_UPDATED     ChangeType = 6
+	ChangeType_CHANGE_TYPE_CONNECTION_DELETED     ChangeType = 7
+	ChangeType_CHANGE_TYPE_INFRASTRUCTURE_UPDATED ChangeType = 8 // Name, description or admins
+	ChangeType_CHANGE_TYPE_INFRASTRUCTURE_DELETED ChangeType = 9 // Last event of the stream
+)
+
+// Enum value maps for ChangeType.
+var (
+	ChangeType_name = map[int32]string{
+		0: "CHANGE_TYPE_UNSPECIFIED",
+		1: "CHANGE_TYPE_SNAPSHOT",
+		2: "CHANGE_TYPE_ENTITY_CREATED",
+		3: "CHANGE_TYPE_ENTITY_UPDATED",
+		4: "CHANGE_TYPE_ENTITY_DELETED",
+		5: "CHANGE_TYPE_CONNECTION_CREATED",
+		6: "CHANGE_TYPE_CONNECTION_UPDATED",
+		7: "CHANGE_TYPE_CONNECTION_DELETED",
+		8: "CHANGE_TYPE_INFRASTRUCTURE_UPDATED",
+		9: "CHANGE_TYPE_INFRASTRUCTURE_DELETED",
+	}
+	ChangeType_value = map[string]int32{
+		"CHANGE_TYPE_UNSPECIFIED":            0,
+		"CHANGE_TYPE_SNAPSHOT":               1,
+		"CHANGE_TYPE_ENTITY_CREATED":         2,
+		"CHANGE_TYPE_ENTITY_UPDATED":         3,
+		"CHANGE_TYPE_ENTITY_DELETED":         4,
+		"CHANGE_TYPE_CONNECTION_CREATED":     5,
+		"CHANGE_TYPE_CONNECTION_UPDATED":     6,
+		"CHANGE_TYPE_CONNECTION_DELETED":     7,
+		"CHANGE_TYPE_INFRASTRUCTURE_UPDATED": 8,
+		"CHANGE_TYPE_INFRASTRUCTURE_DELETED": 9,
+	}
+)
+
+func (x ChangeType) Enum() *ChangeType {
+	p := new(ChangeType)
+	*p = x
+	return p
+}
+
+func (x ChangeType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChangeType) Descriptor() protoreflect.EnumDescriptor {
+	return file_gorph_proto_enumTypes[4].Descriptor()
+}
+
+func (ChangeType) Type() protoreflect.EnumType {
+	return &file_gorph_proto_enumTypes[4]
+}
+
+func (x ChangeType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChangeType.Descriptor instead.
+func (ChangeType) EnumDescriptor() ([]byte, []int) {
+	return file_gorph_proto_rawDescGZIP(), []int{4}
+}
+
 // Entity represents an infrastructure component
 type Entity struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -315,7 +386,8 @@ type Entity struct {
 	Description string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
 	// Current operational status
 	Status Status `protobuf:"varint,4,opt,name=status,proto3,enum=gorph.v1.Status" json:"status,omitempty"`
-	// Team or person responsible
+	// Team or person responsible; API callers are matched against teams,
+	// or against their subject for owners written as "user:<subject>"
 	Owner string `protobuf:"bytes,5,opt,name=owner,proto3" json:"owner,omitempty"`
 	// Deployment environment
 	Environment string `protobuf:"bytes,6,opt,name=environment,proto3" json:"environment,omitempty"`
@@ -330,8 +402,14 @@ type Entity struct {
 	// Icon identifier
 	Icon string `protobuf:"bytes,11,opt,name=icon,proto3" json:"icon,omitempty"`
 	// Metadata
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// YAML spelling of the category, kept when it has no enum value (category
+	// is then CATEGORY_UNSPECIFIED) or is not the canonical one. Ignored
+	// unless it spells the value of category.
+	CategoryName string `protobuf:"bytes,14,opt,name=category_name,json=categoryName,proto3" json:"category_name,omitempty"`
+	// YAML spelling of the status, kept like category_name
+	StatusName    string `protobuf:"bytes,15,opt,name=status_name,json=statusName,proto3" json:"status_name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -457,6 +535,20 @@ func (x *Entity) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Entity) GetCategoryName() string {
+	if x != nil {
+		return x.CategoryName
+	}
+	return ""
+}
+
+func (x *Entity) GetStatusName() string {
+	if x != nil {
+		return x.StatusName
+	}
+	return ""
+}
+
 // Connection represents a relationship between entities
 type Connection struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -469,8 +561,10 @@ type Connection struct {
 	// Optional connection attributes
 	Attributes map[string]string `protobuf:"bytes,4,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	// Metadata
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// YAML spelling of the type, kept like Entity.category_name
+	TypeName      string `protobuf:"bytes,7,opt,name=type_name,json=typeName,proto3" json:"type_name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -547,6 +641,13 @@ func (x *Connection) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Connection) GetTypeName() string {
+	if x != nil {
+		return x.TypeName
+	}
+	return ""
+}
+
 // Infrastructure represents a complete system architecture
 type Infrastructure struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -563,8 +664,11 @@ type Infrastructure struct {
 	// Version for optimistic locking
 	Version int64 `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
 	// Metadata
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Teams, or users written as "user:<subject>", allowed to change its
+	// name, description and admins, edit any entity, and delete it
+	Admins        []string `protobuf:"bytes,9,rep,name=admins,proto3" json:"admins,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -655,13 +759,23 @@ func (x *Infrastructure) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Infrastructure) GetAdmins() []string {
+	if x != nil {
+		return x.Admins
+	}
+	return nil
+}
+
 // Entity operations
 type CreateEntityRequest struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	InfrastructureId string                 `protobuf:"bytes,1,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"`
 	Entity           *Entity                `protobuf:"bytes,2,opt,name=entity,proto3" json:"entity,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	// Reject the change unless the infrastructure is at this version;
+	// 0 applies it unconditionally
+	ExpectedVersion int64 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *CreateEntityRequest) Reset() {
@@ -708,9 +822,18 @@ func (x *CreateEntityRequest) GetEntity() *Entity {
 	return nil
 }
 
+func (x *CreateEntityRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 type CreateEntityResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Entity        *Entity                `protobuf:"bytes,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Entity *Entity                `protobuf:"bytes,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	// Version of the infrastructure after the change
+	Version       int64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -752,6 +875,13 @@ func (x *CreateEntityResponse) GetEntity() *Entity {
 	return nil
 }
 
+func (x *CreateEntityResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type GetEntityRequest struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	InfrastructureId string                 `protobuf:"bytes,1,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"`
@@ -853,9 +983,10 @@ type UpdateEntityRequest struct {
 	InfrastructureId string                 `protobuf:"bytes,1,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"`
 	Entity           *Entity                `protobuf:"bytes,2,opt,name=entity,proto3" json:"entity,omitempty"`
 	// Field mask for partial updates
-	UpdateMask    []string `protobuf:"bytes,3,rep,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	UpdateMask      []string `protobuf:"bytes,3,rep,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	ExpectedVersion int64    `protobuf:"varint,4,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *UpdateEntityRequest) Reset() {
@@ -909,9 +1040,17 @@ func (x *UpdateEntityRequest) GetUpdateMask() []string {
 	return nil
 }
 
+func (x *UpdateEntityRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 type UpdateEntityResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Entity        *Entity                `protobuf:"bytes,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -953,10 +1092,18 @@ func (x *UpdateEntityResponse) GetEntity() *Entity {
 	return nil
 }
 
+func (x *UpdateEntityResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type DeleteEntityRequest struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	InfrastructureId string                 `protobuf:"bytes,1,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"`
 	EntityId         string                 `protobuf:"bytes,2,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	ExpectedVersion  int64                  `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
@@ -1005,8 +1152,16 @@ func (x *DeleteEntityRequest) GetEntityId() string {
 	return ""
 }
 
+func (x *DeleteEntityRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 type DeleteEntityResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int64                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1041,6 +1196,13 @@ func (*DeleteEntityResponse) Descriptor() ([]byte, []int) {
 	return file_gorph_proto_rawDescGZIP(), []int{10}
 }
 
+func (x *DeleteEntityResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type ListEntitiesRequest struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	InfrastructureId string                 `protobuf:"bytes,1,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"`
@@ -1050,8 +1212,15 @@ type ListEntitiesRequest struct {
 	Owner    string   `protobuf:"bytes,4,opt,name=owner,proto3" json:"owner,omitempty"`
 	Tags     []string `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
 	// Pagination
-	PageSize      int32  `protobuf:"varint,6,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
-	PageToken     string `protobuf:"bytes,7,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	PageSize  int32  `protobuf:"varint,6,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken string `protobuf:"bytes,7,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Match entities with any of tags instead of all of them
+	MatchAnyTag bool `protobuf:"varint,8,opt,name=match_any_tag,json=matchAnyTag,proto3" json:"match_any_tag,omitempty"`
+	// Optional filters on the YAML spelling of the category and status,
+	// ignoring case, which also select values without an enum value such as
+	// a custom "Queue" category
+	CategoryName  string `protobuf:"bytes,9,opt,name=category_name,json=categoryName,proto3" json:"category_name,omitempty"`
+	StatusName    string `protobuf:"bytes,10,opt,name=status_name,json=statusName,proto3" json:"status_name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1135,6 +1304,27 @@ func (x *ListEntitiesRequest) GetPageToken() string {
 	return ""
 }
 
+func (x *ListEntitiesRequest) GetMatchAnyTag() bool {
+	if x != nil {
+		return x.MatchAnyTag
+	}
+	return false
+}
+
+func (x *ListEntitiesRequest) GetCategoryName() string {
+	if x != nil {
+		return x.CategoryName
+	}
+	return ""
+}
+
+func (x *ListEntitiesRequest) GetStatusName() string {
+	if x != nil {
+		return x.StatusName
+	}
+	return ""
+}
+
 type ListEntitiesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Entities      []*Entity              `protobuf:"bytes,1,rep,name=entities,proto3" json:"entities,omitempty"`
@@ -1200,6 +1390,7 @@ type CreateConnectionRequest struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	InfrastructureId string                 `protobuf:"bytes,1,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"`
 	Connection       *Connection            `protobuf:"bytes,2,opt,name=connection,proto3" json:"connection,omitempty"`
+	ExpectedVersion  int64                  `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
@@ -1248,9 +1439,17 @@ func (x *CreateConnectionRequest) GetConnection() *Connection {
 	return nil
 }
 
+func (x *CreateConnectionRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 type CreateConnectionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Connection    *Connection            `protobuf:"bytes,1,opt,name=connection,proto3" json:"connection,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1292,13 +1491,24 @@ func (x *CreateConnectionResponse) GetConnection() *Connection {
 	return nil
 }
 
+func (x *CreateConnectionResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type GetConnectionRequest struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	InfrastructureId string                 `protobuf:"bytes,1,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"`
 	From             string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
 	To               string                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	// Type of the connection, needed only when several connections join the
+	// same entities; type_name also names types without an enum value
+	Type          ConnectionType `protobuf:"varint,4,opt,name=type,proto3,enum=gorph.v1.ConnectionType" json:"type,omitempty"`
+	TypeName      string         `protobuf:"bytes,5,opt,name=type_name,json=typeName,proto3" json:"type_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetConnectionRequest) Reset() {
@@ -1352,6 +1562,20 @@ func (x *GetConnectionRequest) GetTo() string {
 	return ""
 }
 
+func (x *GetConnectionRequest) GetType() ConnectionType {
+	if x != nil {
+		return x.Type
+	}
+	return ConnectionType_CONNECTION_TYPE_UNSPECIFIED
+}
+
+func (x *GetConnectionRequest) GetTypeName() string {
+	if x != nil {
+		return x.TypeName
+	}
+	return ""
+}
+
 type GetConnectionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Connection    *Connection            `protobuf:"bytes,1,opt,name=connection,proto3" json:"connection,omitempty"`
@@ -1401,6 +1625,7 @@ type UpdateConnectionRequest struct {
 	InfrastructureId string                 `protobuf:"bytes,1,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"`
 	Connection       *Connection            `protobuf:"bytes,2,opt,name=connection,proto3" json:"connection,omitempty"`
 	UpdateMask       []string               `protobuf:"bytes,3,rep,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	ExpectedVersion  int64                  `protobuf:"varint,4,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
@@ -1456,9 +1681,17 @@ func (x *UpdateConnectionRequest) GetUpdateMask() []string {
 	return nil
 }
 
+func (x *UpdateConnectionRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 type UpdateConnectionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Connection    *Connection            `protobuf:"bytes,1,opt,name=connection,proto3" json:"connection,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1500,13 +1733,24 @@ func (x *UpdateConnectionResponse) GetConnection() *Connection {
 	return nil
 }
 
+func (x *UpdateConnectionResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type DeleteConnectionRequest struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	InfrastructureId string                 `protobuf:"bytes,1,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"`
 	From             string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
 	To               string                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	// Type of the connection, as in GetConnectionRequest
+	Type            ConnectionType `protobuf:"varint,4,opt,name=type,proto3,enum=gorph.v1.ConnectionType" json:"type,omitempty"`
+	ExpectedVersion int64          `protobuf:"varint,5,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	TypeName        string         `protobuf:"bytes,6,opt,name=type_name,json=typeName,proto3" json:"type_name,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *DeleteConnectionRequest) Reset() {
@@ -1560,8 +1804,30 @@ func (x *DeleteConnectionRequest) GetTo() string {
 	return ""
 }
 
+func (x *DeleteConnectionRequest) GetType() ConnectionType {
+	if x != nil {
+		return x.Type
+	}
+	return ConnectionType_CONNECTION_TYPE_UNSPECIFIED
+}
+
+func (x *DeleteConnectionRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *DeleteConnectionRequest) GetTypeName() string {
+	if x != nil {
+		return x.TypeName
+	}
+	return ""
+}
+
 type DeleteConnectionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       int64                  `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1596,6 +1862,13 @@ func (*DeleteConnectionResponse) Descriptor() ([]byte, []int) {
 	return file_gorph_proto_rawDescGZIP(), []int{20}
 }
 
+func (x *DeleteConnectionResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type ListConnectionsRequest struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	InfrastructureId string                 `protobuf:"bytes,1,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"`
@@ -1604,8 +1877,11 @@ type ListConnectionsRequest struct {
 	To   string         `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
 	Type ConnectionType `protobuf:"varint,4,opt,name=type,proto3,enum=gorph.v1.ConnectionType" json:"type,omitempty"`
 	// Pagination
-	PageSize      int32  `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
-	PageToken     string `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	PageSize  int32  `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken string `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Optional filter on the YAML spelling of the type, ignoring case, which
+	// also selects types without an enum value such as "Publishes"
+	TypeName      string `protobuf:"bytes,7,opt,name=type_name,json=typeName,proto3" json:"type_name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1682,6 +1958,13 @@ func (x *ListConnectionsRequest) GetPageToken() string {
 	return ""
 }
 
+func (x *ListConnectionsRequest) GetTypeName() string {
+	if x != nil {
+		return x.TypeName
+	}
+	return ""
+}
+
 type ListConnectionsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Connections   []*Connection          `protobuf:"bytes,1,rep,name=connections,proto3" json:"connections,omitempty"`
@@ -1920,11 +2203,12 @@ func (x *GetInfrastructureResponse) GetInfrastructure() *Infrastructure {
 }
 
 type UpdateInfrastructureRequest struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	Infrastructure *Infrastructure        `protobuf:"bytes,1,opt,name=infrastructure,proto3" json:"infrastructure,omitempty"`
-	UpdateMask     []string               `protobuf:"bytes,2,rep,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Infrastructure  *Infrastructure        `protobuf:"bytes,1,opt,name=infrastructure,proto3" json:"infrastructure,omitempty"`
+	UpdateMask      []string               `protobuf:"bytes,2,rep,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	ExpectedVersion int64                  `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *UpdateInfrastructureRequest) Reset() {
@@ -1971,6 +2255,13 @@ func (x *UpdateInfrastructureRequest) GetUpdateMask() []string {
 	return nil
 }
 
+func (x *UpdateInfrastructureRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 type UpdateInfrastructureResponse struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	Infrastructure *Infrastructure        `protobuf:"bytes,1,opt,name=infrastructure,proto3" json:"infrastructure,omitempty"`
@@ -2018,6 +2309,7 @@ func (x *UpdateInfrastructureResponse) GetInfrastructure() *Infrastructure {
 type DeleteInfrastructureRequest struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	InfrastructureId string                 `protobuf:"bytes,1,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"`
+	ExpectedVersion  int64                  `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
@@ -2059,6 +2351,13 @@ func (x *DeleteInfrastructureRequest) GetInfrastructureId() string {
 	return ""
 }
 
+func (x *DeleteInfrastructureRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 type DeleteInfrastructureResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -2334,6 +2633,7 @@ type ImportYAMLRequest struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	YamlContent      string                 `protobuf:"bytes,1,opt,name=yaml_content,json=yamlContent,proto3" json:"yaml_content,omitempty"`
 	InfrastructureId string                 `protobuf:"bytes,2,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"` // Optional, creates new if empty
+	ExpectedVersion  int64                  `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
@@ -2382,6 +2682,13 @@ func (x *ImportYAMLRequest) GetInfrastructureId() string {
 	return ""
 }
 
+func (x *ImportYAMLRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 type ImportYAMLResponse struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	Infrastructure *Infrastructure        `protobuf:"bytes,1,opt,name=infrastructure,proto3" json:"infrastructure,omitempty"`
@@ -2672,11 +2979,178 @@ func (x *ValidationError) GetEntityId() string {
 	return ""
 }
 
+// Change notifications
+type WatchInfrastructureRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	InfrastructureId string                 `protobuf:"bytes,1,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"`
+	// Resume after this version; 0 starts with a snapshot
+	StartVersion  int64 `protobuf:"varint,2,opt,name=start_version,json=startVersion,proto3" json:"start_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchInfrastructureRequest) Reset() {
+	*x = WatchInfrastructureRequest{}
+	mi := &file_gorph_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchInfrastructureRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchInfrastructureRequest) ProtoMessage() {}
+
+func (x *WatchInfrastructureRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gorph_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchInfrastructureRequest.ProtoReflect.Descriptor instead.
+func (*WatchInfrastructureRequest) Descriptor() ([]byte, []int) {
+	return file_gorph_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *WatchInfrastructureRequest) GetInfrastructureId() string {
+	if x != nil {
+		return x.InfrastructureId
+	}
+	return ""
+}
+
+func (x *WatchInfrastructureRequest) GetStartVersion() int64 {
+	if x != nil {
+		return x.StartVersion
+	}
+	return 0
+}
+
+type ChangeEvent struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Type             ChangeType             `protobuf:"varint,1,opt,name=type,proto3,enum=gorph.v1.ChangeType" json:"type,omitempty"`
+	InfrastructureId string                 `protobuf:"bytes,2,opt,name=infrastructure_id,json=infrastructureId,proto3" json:"infrastructure_id,omitempty"`
+	// Version of the infrastructure after the change
+	Version int64                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	Time    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=time,proto3" json:"time,omitempty"`
+	// Values before and after the change; old is unset for creations and
+	// new is unset for deletions
+	OldEntity     *Entity     `protobuf:"bytes,5,opt,name=old_entity,json=oldEntity,proto3" json:"old_entity,omitempty"`
+	NewEntity     *Entity     `protobuf:"bytes,6,opt,name=new_entity,json=newEntity,proto3" json:"new_entity,omitempty"`
+	OldConnection *Connection `protobuf:"bytes,7,opt,name=old_connection,json=oldConnection,proto3" json:"old_connection,omitempty"`
+	NewConnection *Connection `protobuf:"bytes,8,opt,name=new_connection,json=newConnection,proto3" json:"new_connection,omitempty"`
+	// The whole infrastructure for snapshots, or its name and description
+	// for infrastructure updates
+	Infrastructure *Infrastructure `protobuf:"bytes,9,opt,name=infrastructure,proto3" json:"infrastructure,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ChangeEvent) Reset() {
+	*x = ChangeEvent{}
+	mi := &file_gorph_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeEvent) ProtoMessage() {}
+
+func (x *ChangeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_gorph_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeEvent.ProtoReflect.Descriptor instead.
+func (*ChangeEvent) Descriptor() ([]byte, []int) {
+	return file_gorph_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *ChangeEvent) GetType() ChangeType {
+	if x != nil {
+		return x.Type
+	}
+	return ChangeType_CHANGE_TYPE_UNSPECIFIED
+}
+
+func (x *ChangeEvent) GetInfrastructureId() string {
+	if x != nil {
+		return x.InfrastructureId
+	}
+	return ""
+}
+
+func (x *ChangeEvent) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ChangeEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *ChangeEvent) GetOldEntity() *Entity {
+	if x != nil {
+		return x.OldEntity
+	}
+	return nil
+}
+
+func (x *ChangeEvent) GetNewEntity() *Entity {
+	if x != nil {
+		return x.NewEntity
+	}
+	return nil
+}
+
+func (x *ChangeEvent) GetOldConnection() *Connection {
+	if x != nil {
+		return x.OldConnection
+	}
+	return nil
+}
+
+func (x *ChangeEvent) GetNewConnection() *Connection {
+	if x != nil {
+		return x.NewConnection
+	}
+	return nil
+}
+
+func (x *ChangeEvent) GetInfrastructure() *Infrastructure {
+	if x != nil {
+		return x.Infrastructure
+	}
+	return nil
+}
+
 var File_gorph_proto protoreflect.FileDescriptor
 
 const file_gorph_proto_rawDesc = "" +
 	"\n" +
-	"\vgorph.proto\x12\bgorph.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc7\x04\n" +
+	"\vgorph.proto\x12\bgorph.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x8d\x05\n" +
 	"\x06Entity\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12.\n" +
 	"\bcategory\x18\x02 \x01(\x0e2\x12.gorph.v1.CategoryR\bcategory\x12 \n" +
@@ -2695,10 +3169,13 @@ const file_gorph_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x1a=\n" +
+	"updated_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12#\n" +
+	"\rcategory_name\x18\x0e \x01(\tR\fcategoryName\x12\x1f\n" +
+	"\vstatus_name\x18\x0f \x01(\tR\n" +
+	"statusName\x1a=\n" +
 	"\x0fAttributesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xd9\x02\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xf6\x02\n" +
 	"\n" +
 	"Connection\x12\x12\n" +
 	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
@@ -2710,10 +3187,11 @@ const file_gorph_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x1a=\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1b\n" +
+	"\ttype_name\x18\a \x01(\tR\btypeName\x1a=\n" +
 	"\x0fAttributesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xcc\x02\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xe4\x02\n" +
 	"\x0eInfrastructure\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
@@ -2724,28 +3202,35 @@ const file_gorph_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"l\n" +
+	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x16\n" +
+	"\x06admins\x18\t \x03(\tR\x06admins\"\x97\x01\n" +
 	"\x13CreateEntityRequest\x12+\n" +
 	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\x12(\n" +
-	"\x06entity\x18\x02 \x01(\v2\x10.gorph.v1.EntityR\x06entity\"@\n" +
+	"\x06entity\x18\x02 \x01(\v2\x10.gorph.v1.EntityR\x06entity\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x03R\x0fexpectedVersion\"Z\n" +
 	"\x14CreateEntityResponse\x12(\n" +
-	"\x06entity\x18\x01 \x01(\v2\x10.gorph.v1.EntityR\x06entity\"\\\n" +
+	"\x06entity\x18\x01 \x01(\v2\x10.gorph.v1.EntityR\x06entity\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"\\\n" +
 	"\x10GetEntityRequest\x12+\n" +
 	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\x12\x1b\n" +
 	"\tentity_id\x18\x02 \x01(\tR\bentityId\"=\n" +
 	"\x11GetEntityResponse\x12(\n" +
-	"\x06entity\x18\x01 \x01(\v2\x10.gorph.v1.EntityR\x06entity\"\x8d\x01\n" +
+	"\x06entity\x18\x01 \x01(\v2\x10.gorph.v1.EntityR\x06entity\"\xb8\x01\n" +
 	"\x13UpdateEntityRequest\x12+\n" +
 	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\x12(\n" +
 	"\x06entity\x18\x02 \x01(\v2\x10.gorph.v1.EntityR\x06entity\x12\x1f\n" +
 	"\vupdate_mask\x18\x03 \x03(\tR\n" +
-	"updateMask\"@\n" +
+	"updateMask\x12)\n" +
+	"\x10expected_version\x18\x04 \x01(\x03R\x0fexpectedVersion\"Z\n" +
 	"\x14UpdateEntityResponse\x12(\n" +
-	"\x06entity\x18\x01 \x01(\v2\x10.gorph.v1.EntityR\x06entity\"_\n" +
+	"\x06entity\x18\x01 \x01(\v2\x10.gorph.v1.EntityR\x06entity\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"\x8a\x01\n" +
 	"\x13DeleteEntityRequest\x12+\n" +
 	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\x12\x1b\n" +
-	"\tentity_id\x18\x02 \x01(\tR\bentityId\"\x16\n" +
-	"\x14DeleteEntityResponse\"\x82\x02\n" +
+	"\tentity_id\x18\x02 \x01(\tR\bentityId\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x03R\x0fexpectedVersion\"0\n" +
+	"\x14DeleteEntityResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x03R\aversion\"\xec\x02\n" +
 	"\x13ListEntitiesRequest\x12+\n" +
 	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\x12.\n" +
 	"\bcategory\x18\x02 \x01(\x0e2\x12.gorph.v1.CategoryR\bcategory\x12(\n" +
@@ -2754,45 +3239,60 @@ const file_gorph_proto_rawDesc = "" +
 	"\x04tags\x18\x05 \x03(\tR\x04tags\x12\x1b\n" +
 	"\tpage_size\x18\x06 \x01(\x05R\bpageSize\x12\x1d\n" +
 	"\n" +
-	"page_token\x18\a \x01(\tR\tpageToken\"\x8d\x01\n" +
+	"page_token\x18\a \x01(\tR\tpageToken\x12\"\n" +
+	"\rmatch_any_tag\x18\b \x01(\bR\vmatchAnyTag\x12#\n" +
+	"\rcategory_name\x18\t \x01(\tR\fcategoryName\x12\x1f\n" +
+	"\vstatus_name\x18\n" +
+	" \x01(\tR\n" +
+	"statusName\"\x8d\x01\n" +
 	"\x14ListEntitiesResponse\x12,\n" +
 	"\bentities\x18\x01 \x03(\v2\x10.gorph.v1.EntityR\bentities\x12&\n" +
 	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1f\n" +
 	"\vtotal_count\x18\x03 \x01(\x05R\n" +
-	"totalCount\"|\n" +
+	"totalCount\"\xa7\x01\n" +
 	"\x17CreateConnectionRequest\x12+\n" +
 	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\x124\n" +
 	"\n" +
 	"connection\x18\x02 \x01(\v2\x14.gorph.v1.ConnectionR\n" +
-	"connection\"P\n" +
+	"connection\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x03R\x0fexpectedVersion\"j\n" +
 	"\x18CreateConnectionResponse\x124\n" +
 	"\n" +
 	"connection\x18\x01 \x01(\v2\x14.gorph.v1.ConnectionR\n" +
-	"connection\"g\n" +
+	"connection\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"\xb2\x01\n" +
 	"\x14GetConnectionRequest\x12+\n" +
 	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\x12\x12\n" +
 	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
-	"\x02to\x18\x03 \x01(\tR\x02to\"M\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\x12,\n" +
+	"\x04type\x18\x04 \x01(\x0e2\x18.gorph.v1.ConnectionTypeR\x04type\x12\x1b\n" +
+	"\ttype_name\x18\x05 \x01(\tR\btypeName\"M\n" +
 	"\x15GetConnectionResponse\x124\n" +
 	"\n" +
 	"connection\x18\x01 \x01(\v2\x14.gorph.v1.ConnectionR\n" +
-	"connection\"\x9d\x01\n" +
+	"connection\"\xc8\x01\n" +
 	"\x17UpdateConnectionRequest\x12+\n" +
 	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\x124\n" +
 	"\n" +
 	"connection\x18\x02 \x01(\v2\x14.gorph.v1.ConnectionR\n" +
 	"connection\x12\x1f\n" +
 	"\vupdate_mask\x18\x03 \x03(\tR\n" +
-	"updateMask\"P\n" +
+	"updateMask\x12)\n" +
+	"\x10expected_version\x18\x04 \x01(\x03R\x0fexpectedVersion\"j\n" +
 	"\x18UpdateConnectionResponse\x124\n" +
 	"\n" +
 	"connection\x18\x01 \x01(\v2\x14.gorph.v1.ConnectionR\n" +
-	"connection\"j\n" +
+	"connection\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"\xe0\x01\n" +
 	"\x17DeleteConnectionRequest\x12+\n" +
 	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\x12\x12\n" +
 	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
-	"\x02to\x18\x03 \x01(\tR\x02to\"\x1a\n" +
-	"\x18DeleteConnectionResponse\"\xd3\x01\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\x12,\n" +
+	"\x04type\x18\x04 \x01(\x0e2\x18.gorph.v1.ConnectionTypeR\x04type\x12)\n" +
+	"\x10expected_version\x18\x05 \x01(\x03R\x0fexpectedVersion\x12\x1b\n" +
+	"\ttype_name\x18\x06 \x01(\tR\btypeName\"4\n" +
+	"\x18DeleteConnectionResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x03R\aversion\"\xf0\x01\n" +
 	"\x16ListConnectionsRequest\x12+\n" +
 	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\x12\x12\n" +
 	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
@@ -2800,7 +3300,8 @@ const file_gorph_proto_rawDesc = "" +
 	"\x04type\x18\x04 \x01(\x0e2\x18.gorph.v1.ConnectionTypeR\x04type\x12\x1b\n" +
 	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\x12\x1d\n" +
 	"\n" +
-	"page_token\x18\x06 \x01(\tR\tpageToken\"\x9a\x01\n" +
+	"page_token\x18\x06 \x01(\tR\tpageToken\x12\x1b\n" +
+	"\ttype_name\x18\a \x01(\tR\btypeName\"\x9a\x01\n" +
 	"\x17ListConnectionsResponse\x126\n" +
 	"\vconnections\x18\x01 \x03(\v2\x14.gorph.v1.ConnectionR\vconnections\x12&\n" +
 	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1f\n" +
@@ -2813,15 +3314,17 @@ const file_gorph_proto_rawDesc = "" +
 	"\x18GetInfrastructureRequest\x12+\n" +
 	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\"]\n" +
 	"\x19GetInfrastructureResponse\x12@\n" +
-	"\x0einfrastructure\x18\x01 \x01(\v2\x18.gorph.v1.InfrastructureR\x0einfrastructure\"\x80\x01\n" +
+	"\x0einfrastructure\x18\x01 \x01(\v2\x18.gorph.v1.InfrastructureR\x0einfrastructure\"\xab\x01\n" +
 	"\x1bUpdateInfrastructureRequest\x12@\n" +
 	"\x0einfrastructure\x18\x01 \x01(\v2\x18.gorph.v1.InfrastructureR\x0einfrastructure\x12\x1f\n" +
 	"\vupdate_mask\x18\x02 \x03(\tR\n" +
-	"updateMask\"`\n" +
+	"updateMask\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x03R\x0fexpectedVersion\"`\n" +
 	"\x1cUpdateInfrastructureResponse\x12@\n" +
-	"\x0einfrastructure\x18\x01 \x01(\v2\x18.gorph.v1.InfrastructureR\x0einfrastructure\"J\n" +
+	"\x0einfrastructure\x18\x01 \x01(\v2\x18.gorph.v1.InfrastructureR\x0einfrastructure\"u\n" +
 	"\x1bDeleteInfrastructureRequest\x12+\n" +
-	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\"\x1e\n" +
+	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\x12)\n" +
+	"\x10expected_version\x18\x02 \x01(\x03R\x0fexpectedVersion\"\x1e\n" +
 	"\x1cDeleteInfrastructureResponse\"X\n" +
 	"\x1aListInfrastructuresRequest\x12\x1b\n" +
 	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
@@ -2840,10 +3343,11 @@ const file_gorph_proto_rawDesc = "" +
 	"\acontent\x18\x01 \x01(\fR\acontent\x12!\n" +
 	"\fcontent_type\x18\x02 \x01(\tR\vcontentType\x12\x1d\n" +
 	"\n" +
-	"size_bytes\x18\x03 \x01(\x03R\tsizeBytes\"c\n" +
+	"size_bytes\x18\x03 \x01(\x03R\tsizeBytes\"\x8e\x01\n" +
 	"\x11ImportYAMLRequest\x12!\n" +
 	"\fyaml_content\x18\x01 \x01(\tR\vyamlContent\x12+\n" +
-	"\x11infrastructure_id\x18\x02 \x01(\tR\x10infrastructureId\"V\n" +
+	"\x11infrastructure_id\x18\x02 \x01(\tR\x10infrastructureId\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x03R\x0fexpectedVersion\"V\n" +
 	"\x12ImportYAMLResponse\x12@\n" +
 	"\x0einfrastructure\x18\x01 \x01(\v2\x18.gorph.v1.InfrastructureR\x0einfrastructure\"@\n" +
 	"\x11ExportYAMLRequest\x12+\n" +
@@ -2858,7 +3362,22 @@ const file_gorph_proto_rawDesc = "" +
 	"\x0fValidationError\x12\x14\n" +
 	"\x05field\x18\x01 \x01(\tR\x05field\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1b\n" +
-	"\tentity_id\x18\x03 \x01(\tR\bentityId*\xe0\x02\n" +
+	"\tentity_id\x18\x03 \x01(\tR\bentityId\"n\n" +
+	"\x1aWatchInfrastructureRequest\x12+\n" +
+	"\x11infrastructure_id\x18\x01 \x01(\tR\x10infrastructureId\x12#\n" +
+	"\rstart_version\x18\x02 \x01(\x03R\fstartVersion\"\xcc\x03\n" +
+	"\vChangeEvent\x12(\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x14.gorph.v1.ChangeTypeR\x04type\x12+\n" +
+	"\x11infrastructure_id\x18\x02 \x01(\tR\x10infrastructureId\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\x12.\n" +
+	"\x04time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12/\n" +
+	"\n" +
+	"old_entity\x18\x05 \x01(\v2\x10.gorph.v1.EntityR\toldEntity\x12/\n" +
+	"\n" +
+	"new_entity\x18\x06 \x01(\v2\x10.gorph.v1.EntityR\tnewEntity\x12;\n" +
+	"\x0eold_connection\x18\a \x01(\v2\x14.gorph.v1.ConnectionR\roldConnection\x12;\n" +
+	"\x0enew_connection\x18\b \x01(\v2\x14.gorph.v1.ConnectionR\rnewConnection\x12@\n" +
+	"\x0einfrastructure\x18\t \x01(\v2\x18.gorph.v1.InfrastructureR\x0einfrastructure*\xe0\x02\n" +
 	"\bCategory\x12\x18\n" +
 	"\x14CATEGORY_UNSPECIFIED\x10\x00\x12\x18\n" +
 	"\x14CATEGORY_USER_FACING\x10\x01\x12\x15\n" +
@@ -2903,7 +3422,19 @@ const file_gorph_proto_rawDesc = "" +
 	"\x11OUTPUT_FORMAT_DOT\x10\x01\x12\x15\n" +
 	"\x11OUTPUT_FORMAT_PNG\x10\x02\x12\x15\n" +
 	"\x11OUTPUT_FORMAT_SVG\x10\x03\x12\x15\n" +
-	"\x11OUTPUT_FORMAT_PDF\x10\x042\x99\r\n" +
+	"\x11OUTPUT_FORMAT_PDF\x10\x04*\xdf\x02\n" +
+	"\n" +
+	"ChangeType\x12\x1b\n" +
+	"\x17CHANGE_TYPE_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14CHANGE_TYPE_SNAPSHOT\x10\x01\x12\x1e\n" +
+	"\x1aCHANGE_TYPE_ENTITY_CREATED\x10\x02\x12\x1e\n" +
+	"\x1aCHANGE_TYPE_ENTITY_UPDATED\x10\x03\x12\x1e\n" +
+	"\x1aCHANGE_TYPE_ENTITY_DELETED\x10\x04\x12\"\n" +
+	"\x1eCHANGE_TYPE_CONNECTION_CREATED\x10\x05\x12\"\n" +
+	"\x1eCHANGE_TYPE_CONNECTION_UPDATED\x10\x06\x12\"\n" +
+	"\x1eCHANGE_TYPE_CONNECTION_DELETED\x10\a\x12&\n" +
+	"\"CHANGE_TYPE_INFRASTRUCTURE_UPDATED\x10\b\x12&\n" +
+	"\"CHANGE_TYPE_INFRASTRUCTURE_DELETED\x10\t2\xef\r\n" +
 	"\fGorphService\x12M\n" +
 	"\fCreateEntity\x12\x1d.gorph.v1.CreateEntityRequest\x1a\x1e.gorph.v1.CreateEntityResponse\x12D\n" +
 	"\tGetEntity\x12\x1a.gorph.v1.GetEntityRequest\x1a\x1b.gorph.v1.GetEntityResponse\x12M\n" +
@@ -2919,7 +3450,8 @@ const file_gorph_proto_rawDesc = "" +
 	"\x11GetInfrastructure\x12\".gorph.v1.GetInfrastructureRequest\x1a#.gorph.v1.GetInfrastructureResponse\x12e\n" +
 	"\x14UpdateInfrastructure\x12%.gorph.v1.UpdateInfrastructureRequest\x1a&.gorph.v1.UpdateInfrastructureResponse\x12e\n" +
 	"\x14DeleteInfrastructure\x12%.gorph.v1.DeleteInfrastructureRequest\x1a&.gorph.v1.DeleteInfrastructureResponse\x12b\n" +
-	"\x13ListInfrastructures\x12$.gorph.v1.ListInfrastructuresRequest\x1a%.gorph.v1.ListInfrastructuresResponse\x12V\n" +
+	"\x13ListInfrastructures\x12$.gorph.v1.ListInfrastructuresRequest\x1a%.gorph.v1.ListInfrastructuresResponse\x12T\n" +
+	"\x13WatchInfrastructure\x12$.gorph.v1.WatchInfrastructureRequest\x1a\x15.gorph.v1.ChangeEvent0\x01\x12V\n" +
 	"\x0fGenerateDiagram\x12 .gorph.v1.GenerateDiagramRequest\x1a!.gorph.v1.GenerateDiagramResponse\x12G\n" +
 	"\n" +
 	"ImportYAML\x12\x1b.gorph.v1.ImportYAMLRequest\x1a\x1c.gorph.v1.ImportYAMLResponse\x12G\n" +
@@ -2939,143 +3471,157 @@ func file_gorph_proto_rawDescGZIP() []byte {
 	return file_gorph_proto_rawDescData
 }
 
-var file_gorph_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
-var file_gorph_proto_msgTypes = make([]protoimpl.MessageInfo, 44)
+var file_gorph_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_gorph_proto_msgTypes = make([]protoimpl.MessageInfo, 46)
 var file_gorph_proto_goTypes = []any{
 	(Category)(0),                          // 0: gorph.v1.Category
 	(Status)(0),                            // 1: gorph.v1.Status
 	(ConnectionType)(0),                    // 2: gorph.v1.ConnectionType
 	(OutputFormat)(0),                      // 3: gorph.v1.OutputFormat
-	(*Entity)(nil),                         // 4: gorph.v1.Entity
-	(*Connection)(nil),                     // 5: gorph.v1.Connection
-	(*Infrastructure)(nil),                 // 6: gorph.v1.Infrastructure
-	(*CreateEntityRequest)(nil),            // 7: gorph.v1.CreateEntityRequest
-	(*CreateEntityResponse)(nil),           // 8: gorph.v1.CreateEntityResponse
-	(*GetEntityRequest)(nil),               // 9: gorph.v1.GetEntityRequest
-	(*GetEntityResponse)(nil),              // 10: gorph.v1.GetEntityResponse
-	(*UpdateEntityRequest)(nil),            // 11: gorph.v1.UpdateEntityRequest
-	(*UpdateEntityResponse)(nil),           // 12: gorph.v1.UpdateEntityResponse
-	(*DeleteEntityRequest)(nil),            // 13: gorph.v1.DeleteEntityRequest
-	(*DeleteEntityResponse)(nil),           // 14: gorph.v1.DeleteEntityResponse
-	(*ListEntitiesRequest)(nil),            // 15: gorph.v1.ListEntitiesRequest
-	(*ListEntitiesResponse)(nil),           // 16: gorph.v1.ListEntitiesResponse
-	(*CreateConnectionRequest)(nil),        // 17: gorph.v1.CreateConnectionRequest
-	(*CreateConnectionResponse)(nil),       // 18: gorph.v1.CreateConnectionResponse
-	(*GetConnectionRequest)(nil),           // 19: gorph.v1.GetConnectionRequest
-	(*GetConnectionResponse)(nil),          // 20: gorph.v1.GetConnectionResponse
-	(*UpdateConnectionRequest)(nil),        // 21: gorph.v1.UpdateConnectionRequest
-	(*UpdateConnectionResponse)(nil),       // 22: gorph.v1.UpdateConnectionResponse
-	(*DeleteConnectionRequest)(nil),        // 23: gorph.v1.DeleteConnectionRequest
-	(*DeleteConnectionResponse)(nil),       // 24: gorph.v1.DeleteConnectionResponse
-	(*ListConnectionsRequest)(nil),         // 25: gorph.v1.ListConnectionsRequest
-	(*ListConnectionsResponse)(nil),        // 26: gorph.v1.ListConnectionsResponse
-	(*CreateInfrastructureRequest)(nil),    // 27: gorph.v1.CreateInfrastructureRequest
-	(*CreateInfrastructureResponse)(nil),   // 28: gorph.v1.CreateInfrastructureResponse
-	(*GetInfrastructureRequest)(nil),       // 29: gorph.v1.GetInfrastructureRequest
-	(*GetInfrastructureResponse)(nil),      // 30: gorph.v1.GetInfrastructureResponse
-	(*UpdateInfrastructureRequest)(nil),    // 31: gorph.v1.UpdateInfrastructureRequest
-	(*UpdateInfrastructureResponse)(nil),   // 32: gorph.v1.UpdateInfrastructureResponse
-	(*DeleteInfrastructureRequest)(nil),    // 33: gorph.v1.DeleteInfrastructureRequest
-	(*DeleteInfrastructureResponse)(nil),   // 34: gorph.v1.DeleteInfrastructureResponse
-	(*ListInfrastructuresRequest)(nil),     // 35: gorph.v1.ListInfrastructuresRequest
-	(*ListInfrastructuresResponse)(nil),    // 36: gorph.v1.ListInfrastructuresResponse
-	(*GenerateDiagramRequest)(nil),         // 37: gorph.v1.GenerateDiagramRequest
-	(*GenerateDiagramResponse)(nil),        // 38: gorph.v1.GenerateDiagramResponse
-	(*ImportYAMLRequest)(nil),              // 39: gorph.v1.ImportYAMLRequest
-	(*ImportYAMLResponse)(nil),             // 40: gorph.v1.ImportYAMLResponse
-	(*ExportYAMLRequest)(nil),              // 41: gorph.v1.ExportYAMLRequest
-	(*ExportYAMLResponse)(nil),             // 42: gorph.v1.ExportYAMLResponse
-	(*ValidateInfrastructureRequest)(nil),  // 43: gorph.v1.ValidateInfrastructureRequest
-	(*ValidateInfrastructureResponse)(nil), // 44: gorph.v1.ValidateInfrastructureResponse
-	(*ValidationError)(nil),                // 45: gorph.v1.ValidationError
-	nil,                                    // 46: gorph.v1.Entity.AttributesEntry
-	nil,                                    // 47: gorph.v1.Connection.AttributesEntry
-	(*structpb.Struct)(nil),                // 48: google.protobuf.Struct
-	(*timestamppb.Timestamp)(nil),          // 49: google.protobuf.Timestamp
+	(ChangeType)(0),                        // 4: gorph.v1.ChangeType
+	(*Entity)(nil),                         // 5: gorph.v1.Entity
+	(*Connection)(nil),                     // 6: gorph.v1.Connection
+	(*Infrastructure)(nil),                 // 7: gorph.v1.Infrastructure
+	(*CreateEntityRequest)(nil),            // 8: gorph.v1.CreateEntityRequest
+	(*CreateEntityResponse)(nil),           // 9: gorph.v1.CreateEntityResponse
+	(*GetEntityRequest)(nil),               // 10: gorph.v1.GetEntityRequest
+	(*GetEntityResponse)(nil),              // 11: gorph.v1.GetEntityResponse
+	(*UpdateEntityRequest)(nil),            // 12: gorph.v1.UpdateEntityRequest
+	(*UpdateEntityResponse)(nil),           // 13: gorph.v1.UpdateEntityResponse
+	(*DeleteEntityRequest)(nil),            // 14: gorph.v1.DeleteEntityRequest
+	(*DeleteEntityResponse)(nil),           // 15: gorph.v1.DeleteEntityResponse
+	(*ListEntitiesRequest)(nil),            // 16: gorph.v1.ListEntitiesRequest
+	(*ListEntitiesResponse)(nil),           // 17: gorph.v1.ListEntitiesResponse
+	(*CreateConnectionRequest)(nil),        // 18: gorph.v1.CreateConnectionRequest
+	(*CreateConnectionResponse)(nil),       // 19: gorph.v1.CreateConnectionResponse
+	(*GetConnectionRequest)(nil),           // 20: gorph.v1.GetConnectionRequest
+	(*GetConnectionResponse)(nil),          // 21: gorph.v1.GetConnectionResponse
+	(*UpdateConnectionRequest)(nil),        // 22: gorph.v1.UpdateConnectionRequest
+	(*UpdateConnectionResponse)(nil),       // 23: gorph.v1.UpdateConnectionResponse
+	(*DeleteConnectionRequest)(nil),        // 24: gorph.v1.DeleteConnectionRequest
+	(*DeleteConnectionResponse)(nil),       // 25: gorph.v1.DeleteConnectionResponse
+	(*ListConnectionsRequest)(nil),         // 26: gorph.v1.ListConnectionsRequest
+	(*ListConnectionsResponse)(nil),        // 27: gorph.v1.ListConnectionsResponse
+	(*CreateInfrastructureRequest)(nil),    // 28: gorph.v1.CreateInfrastructureRequest
+	(*CreateInfrastructureResponse)(nil),   // 29: gorph.v1.CreateInfrastructureResponse
+	(*GetInfrastructureRequest)(nil),       // 30: gorph.v1.GetInfrastructureRequest
+	(*GetInfrastructureResponse)(nil),      // 31: gorph.v1.GetInfrastructureResponse
+	(*UpdateInfrastructureRequest)(nil),    // 32: gorph.v1.UpdateInfrastructureRequest
+	(*UpdateInfrastructureResponse)(nil),   // 33: gorph.v1.UpdateInfrastructureResponse
+	(*DeleteInfrastructureRequest)(nil),    // 34: gorph.v1.DeleteInfrastructureRequest
+	(*DeleteInfrastructureResponse)(nil),   // 35: gorph.v1.DeleteInfrastructureResponse
+	(*ListInfrastructuresRequest)(nil),     // 36: gorph.v1.ListInfrastructuresRequest
+	(*ListInfrastructuresResponse)(nil),    // 37: gorph.v1.ListInfrastructuresResponse
+	(*GenerateDiagramRequest)(nil),         // 38: gorph.v1.GenerateDiagramRequest
+	(*GenerateDiagramResponse)(nil),        // 39: gorph.v1.GenerateDiagramResponse
+	(*ImportYAMLRequest)(nil),              // 40: gorph.v1.ImportYAMLRequest
+	(*ImportYAMLResponse)(nil),             // 41: gorph.v1.ImportYAMLResponse
+	(*ExportYAMLRequest)(nil),              // 42: gorph.v1.ExportYAMLRequest
+	(*ExportYAMLResponse)(nil),             // 43: gorph.v1.ExportYAMLResponse
+	(*ValidateInfrastructureRequest)(nil),  // 44: gorph.v1.ValidateInfrastructureRequest
+	(*ValidateInfrastructureResponse)(nil), // 45: gorph.v1.ValidateInfrastructureResponse
+	(*ValidationError)(nil),                // 46: gorph.v1.ValidationError
+	(*WatchInfrastructureRequest)(nil),     // 47: gorph.v1.WatchInfrastructureRequest
+	(*ChangeEvent)(nil),                    // 48: gorph.v1.ChangeEvent
+	nil,                                    // 49: gorph.v1.Entity.AttributesEntry
+	nil,                                    // 50: gorph.v1.Connection.AttributesEntry
+	(*structpb.Struct)(nil),                // 51: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil),          // 52: google.protobuf.Timestamp
 }
 var file_gorph_proto_depIdxs = []int32{
 	0,  // 0: gorph.v1.Entity.category:type_name -> gorph.v1.Category
 	1,  // 1: gorph.v1.Entity.status:type_name -> gorph.v1.Status
-	46, // 2: gorph.v1.Entity.attributes:type_name -> gorph.v1.Entity.AttributesEntry
-	48, // 3: gorph.v1.Entity.deployment_config:type_name -> google.protobuf.Struct
-	49, // 4: gorph.v1.Entity.created_at:type_name -> google.protobuf.Timestamp
-	49, // 5: gorph.v1.Entity.updated_at:type_name -> google.protobuf.Timestamp
+	49, // 2: gorph.v1.Entity.attributes:type_name -> gorph.v1.Entity.AttributesEntry
+	51, // 3: gorph.v1.Entity.deployment_config:type_name -> google.protobuf.Struct
+	52, // 4: gorph.v1.Entity.created_at:type_name -> google.protobuf.Timestamp
+	52, // 5: gorph.v1.Entity.updated_at:type_name -> google.protobuf.Timestamp
 	2,  // 6: gorph.v1.Connection.type:type_name -> gorph.v1.ConnectionType
-	47, // 7: gorph.v1.Connection.attributes:type_name -> gorph.v1.Connection.AttributesEntry
-	49, // 8: gorph.v1.Connection.created_at:type_name -> google.protobuf.Timestamp
-	49, // 9: gorph.v1.Connection.updated_at:type_name -> google.protobuf.Timestamp
-	4,  // 10: gorph.v1.Infrastructure.entities:type_name -> gorph.v1.Entity
-	5,  // 11: gorph.v1.Infrastructure.connections:type_name -> gorph.v1.Connection
-	49, // 12: gorph.v1.Infrastructure.created_at:type_name -> google.protobuf.Timestamp
-	49, // 13: gorph.v1.Infrastructure.updated_at:type_name -> google.protobuf.Timestamp
-	4,  // 14: gorph.v1.CreateEntityRequest.entity:type_name -> gorph.v1.Entity
-	4,  // 15: gorph.v1.CreateEntityResponse.entity:type_name -> gorph.v1.Entity
-	4,  // 16: gorph.v1.GetEntityResponse.entity:type_name -> gorph.v1.Entity
-	4,  // 17: gorph.v1.UpdateEntityRequest.entity:type_name -> gorph.v1.Entity
-	4,  // 18: gorph.v1.UpdateEntityResponse.entity:type_name -> gorph.v1.Entity
+	50, // 7: gorph.v1.Connection.attributes:type_name -> gorph.v1.Connection.AttributesEntry
+	52, // 8: gorph.v1.Connection.created_at:type_name -> google.protobuf.Timestamp
+	52, // 9: gorph.v1.Connection.updated_at:type_name -> google.protobuf.Timestamp
+	5,  // 10: gorph.v1.Infrastructure.entities:type_name -> gorph.v1.Entity
+	6,  // 11: gorph.v1.Infrastructure.connections:type_name -> gorph.v1.Connection
+	52, // 12: gorph.v1.Infrastructure.created_at:type_name -> google.protobuf.Timestamp
+	52, // 13: gorph.v1.Infrastructure.updated_at:type_name -> google.protobuf.Timestamp
+	5,  // 14: gorph.v1.CreateEntityRequest.entity:type_name -> gorph.v1.Entity
+	5,  // 15: gorph.v1.CreateEntityResponse.entity:type_name -> gorph.v1.Entity
+	5,  // 16: gorph.v1.GetEntityResponse.entity:type_name -> gorph.v1.Entity
+	5,  // 17: gorph.v1.UpdateEntityRequest.entity:type_name -> gorph.v1.Entity
+	5,  // 18: gorph.v1.UpdateEntityResponse.entity:type_name -> gorph.v1.Entity
 	0,  // 19: gorph.v1.ListEntitiesRequest.category:type_name -> gorph.v1.Category
 	1,  // 20: gorph.v1.ListEntitiesRequest.status:type_name -> gorph.v1.Status
-	4,  // 21: gorph.v1.ListEntitiesResponse.entities:type_name -> gorph.v1.Entity
-	5,  // 22: gorph.v1.CreateConnectionRequest.connection:type_name -> gorph.v1.Connection
-	5,  // 23: gorph.v1.CreateConnectionResponse.connection:type_name -> gorph.v1.Connection
-	5,  // 24: gorph.v1.GetConnectionResponse.connection:type_name -> gorph.v1.Connection
-	5,  // 25: gorph.v1.UpdateConnectionRequest.connection:type_name -> gorph.v1.Connection
-	5,  // 26: gorph.v1.UpdateConnectionResponse.connection:type_name -> gorph.v1.Connection
-	2,  // 27: gorph.v1.ListConnectionsRequest.type:type_name -> gorph.v1.ConnectionType
-	5,  // 28: gorph.v1.ListConnectionsResponse.connections:type_name -> gorph.v1.Connection
-	6,  // 29: gorph.v1.CreateInfrastructureRequest.infrastructure:type_name -> gorph.v1.Infrastructure
-	6,  // 30: gorph.v1.CreateInfrastructureResponse.infrastructure:type_name -> gorph.v1.Infrastructure
-	6,  // 31: gorph.v1.GetInfrastructureResponse.infrastructure:type_name -> gorph.v1.Infrastructure
-	6,  // 32: gorph.v1.UpdateInfrastructureRequest.infrastructure:type_name -> gorph.v1.Infrastructure
-	6,  // 33: gorph.v1.UpdateInfrastructureResponse.infrastructure:type_name -> gorph.v1.Infrastructure
-	6,  // 34: gorph.v1.ListInfrastructuresResponse.infrastructures:type_name -> gorph.v1.Infrastructure
-	3,  // 35: gorph.v1.GenerateDiagramRequest.format:type_name -> gorph.v1.OutputFormat
-	6,  // 36: gorph.v1.ImportYAMLResponse.infrastructure:type_name -> gorph.v1.Infrastructure
-	6,  // 37: gorph.v1.ValidateInfrastructureRequest.infrastructure:type_name -> gorph.v1.Infrastructure
-	45, // 38: gorph.v1.ValidateInfrastructureResponse.errors:type_name -> gorph.v1.ValidationError
-	7,  // 39: gorph.v1.GorphService.CreateEntity:input_type -> gorph.v1.CreateEntityRequest
-	9,  // 40: gorph.v1.GorphService.GetEntity:input_type -> gorph.v1.GetEntityRequest
-	11, // 41: gorph.v1.GorphService.UpdateEntity:input_type -> gorph.v1.UpdateEntityRequest
-	13, // 42: gorph.v1.GorphService.DeleteEntity:input_type -> gorph.v1.DeleteEntityRequest
-	15, // 43: gorph.v1.GorphService.ListEntities:input_type -> gorph.v1.ListEntitiesRequest
-	17, // 44: gorph.v1.GorphService.CreateConnection:input_type -> gorph.v1.CreateConnectionRequest
-	19, // 45: gorph.v1.GorphService.GetConnection:input_type -> gorph.v1.GetConnectionRequest
-	21, // 46: gorph.v1.GorphService.UpdateConnection:input_type -> gorph.v1.UpdateConnectionRequest
-	23, // 47: gorph.v1.GorphService.DeleteConnection:input_type -> gorph.v1.DeleteConnectionRequest
-	25, // 48: gorph.v1.GorphService.ListConnections:input_type -> gorph.v1.ListConnectionsRequest
-	27, // 49: gorph.v1.GorphService.CreateInfrastructure:input_type -> gorph.v1.CreateInfrastructureRequest
-	29, // 50: gorph.v1.GorphService.GetInfrastructure:input_type -> gorph.v1.GetInfrastructureRequest
-	31, // 51: gorph.v1.GorphService.UpdateInfrastructure:input_type -> gorph.v1.UpdateInfrastructureRequest
-	33, // 52: gorph.v1.GorphService.DeleteInfrastructure:input_type -> gorph.v1.DeleteInfrastructureRequest
-	35, // 53: gorph.v1.GorphService.ListInfrastructures:input_type -> gorph.v1.ListInfrastructuresRequest
-	37, // 54: gorph.v1.GorphService.GenerateDiagram:input_type -> gorph.v1.GenerateDiagramRequest
-	39, // 55: gorph.v1.GorphService.ImportYAML:input_type -> gorph.v1.ImportYAMLRequest
-	41, // 56: gorph.v1.GorphService.ExportYAML:input_type -> gorph.v1.ExportYAMLRequest
-	43, // 57: gorph.v1.GorphService.ValidateInfrastructure:input_type -> gorph.v1.ValidateInfrastructureRequest
-	8,  // 58: gorph.v1.GorphService.CreateEntity:output_type -> gorph.v1.CreateEntityResponse
-	10, // 59: gorph.v1.GorphService.GetEntity:output_type -> gorph.v1.GetEntityResponse
-	12, // 60: gorph.v1.GorphService.UpdateEntity:output_type -> gorph.v1.UpdateEntityResponse
-	14, // 61: gorph.v1.GorphService.DeleteEntity:output_type -> gorph.v1.DeleteEntityResponse
-	16, // 62: gorph.v1.GorphService.ListEntities:output_type -> gorph.v1.ListEntitiesResponse
-	18, // 63: gorph.v1.GorphService.CreateConnection:output_type -> gorph.v1.CreateConnectionResponse
-	20, // 64: gorph.v1.GorphService.GetConnection:output_type -> gorph.v1.GetConnectionResponse
-	22, // 65: gorph.v1.GorphService.UpdateConnection:output_type -> gorph.v1.UpdateConnectionResponse
-	24, // 66: gorph.v1.GorphService.DeleteConnection:output_type -> gorph.v1.DeleteConnectionResponse
-	26, // 67: gorph.v1.GorphService.ListConnections:output_type -> gorph.v1.ListConnectionsResponse
-	28, // 68: gorph.v1.GorphService.CreateInfrastructure:output_type -> gorph.v1.CreateInfrastructureResponse
-	30, // 69: gorph.v1.GorphService.GetInfrastructure:output_type -> gorph.v1.GetInfrastructureResponse
-	32, // 70: gorph.v1.GorphService.UpdateInfrastructure:output_type -> gorph.v1.UpdateInfrastructureResponse
-	34, // 71: gorph.v1.GorphService.DeleteInfrastructure:output_type -> gorph.v1.DeleteInfrastructureResponse
-	36, // 72: gorph.v1.GorphService.ListInfrastructures:output_type -> gorph.v1.ListInfrastructuresResponse
-	38, // 73: gorph.v1.GorphService.GenerateDiagram:output_type -> gorph.v1.GenerateDiagramResponse
-	40, // 74: gorph.v1.GorphService.ImportYAML:output_type -> gorph.v1.ImportYAMLResponse
-	42, // 75: gorph.v1.GorphService.ExportYAML:output_type -> gorph.v1.ExportYAMLResponse
-	44, // 76: gorph.v1.GorphService.ValidateInfrastructure:output_type -> gorph.v1.ValidateInfrastructureResponse
-	58, // [58:77] is the sub-list for method output_type
-	39, // [39:58] is the sub-list for method input_type
-	39, // [39:39] is the sub-list for extension type_name
-	39, // [39:39] is the sub-list for extension extendee
-	0,  // [0:39] is the sub-list for field type_name
+	5,  // 21: gorph.v1.ListEntitiesResponse.entities:type_name -> gorph.v1.Entity
+	6,  // 22: gorph.v1.CreateConnectionRequest.connection:type_name -> gorph.v1.Connection
+	6,  // 23: gorph.v1.CreateConnectionResponse.connection:type_name -> gorph.v1.Connection
+	2,  // 24: gorph.v1.GetConnectionRequest.type:type_name -> gorph.v1.ConnectionType
+	6,  // 25: gorph.v1.GetConnectionResponse.connection:type_name -> gorph.v1.Connection
+	6,  // 26: gorph.v1.UpdateConnectionRequest.connection:type_name -> gorph.v1.Connection
+	6,  // 27: gorph.v1.UpdateConnectionResponse.connection:type_name -> gorph.v1.Connection
+	2,  // 28: gorph.v1.DeleteConnectionRequest.type:type_name -> gorph.v1.ConnectionType
+	2,  // 29: gorph.v1.ListConnectionsRequest.type:type_name -> gorph.v1.ConnectionType
+	6,  // 30: gorph.v1.ListConnectionsResponse.connections:type_name -> gorph.v1.Connection
+	7,  // 31: gorph.v1.CreateInfrastructureRequest.infrastructure:type_name -> gorph.v1.Infrastructure
+	7,  // 32: gorph.v1.CreateInfrastructureResponse.infrastructure:type_name -> gorph.v1.Infrastructure
+	7,  // 33: gorph.v1.GetInfrastructureResponse.infrastructure:type_name -> gorph.v1.Infrastructure
+	7,  // 34: gorph.v1.UpdateInfrastructureRequest.infrastructure:type_name -> gorph.v1.Infrastructure
+	7,  // 35: gorph.v1.UpdateInfrastructureResponse.infrastructure:type_name -> gorph.v1.Infrastructure
+	7,  // 36: gorph.v1.ListInfrastructuresResponse.infrastructures:type_name -> gorph.v1.Infrastructure
+	3,  // 37: gorph.v1.GenerateDiagramRequest.format:type_name -> gorph.v1.OutputFormat
+	7,  // 38: gorph.v1.ImportYAMLResponse.infrastructure:type_name -> gorph.v1.Infrastructure
+	7,  // 39: gorph.v1.ValidateInfrastructureRequest.infrastructure:type_name -> gorph.v1.Infrastructure
+	46, // 40: gorph.v1.ValidateInfrastructureResponse.errors:type_name -> gorph.v1.ValidationError
+	4,  // 41: gorph.v1.ChangeEvent.type:type_name -> gorph.v1.ChangeType
+	52, // 42: gorph.v1.ChangeEvent.time:type_name -> google.protobuf.Timestamp
+	5,  // 43: gorph.v1.ChangeEvent.old_entity:type_name -> gorph.v1.Entity
+	5,  // 44: gorph.v1.ChangeEvent.new_entity:type_name -> gorph.v1.Entity
+	6,  // 45: gorph.v1.ChangeEvent.old_connection:type_name -> gorph.v1.Connection
+	6,  // 46: gorph.v1.ChangeEvent.new_connection:type_name -> gorph.v1.Connection
+	7,  // 47: gorph.v1.ChangeEvent.infrastructure:type_name -> gorph.v1.Infrastructure
+	8,  // 48: gorph.v1.GorphService.CreateEntity:input_type -> gorph.v1.CreateEntityRequest
+	10, // 49: gorph.v1.GorphService.GetEntity:input_type -> gorph.v1.GetEntityRequest
+	12, // 50: gorph.v1.GorphService.UpdateEntity:input_type -> gorph.v1.UpdateEntityRequest
+	14, // 51: gorph.v1.GorphService.DeleteEntity:input_type -> gorph.v1.DeleteEntityRequest
+	16, // 52: gorph.v1.GorphService.ListEntities:input_type -> gorph.v1.ListEntitiesRequest
+	18, // 53: gorph.v1.GorphService.CreateConnection:input_type -> gorph.v1.CreateConnectionRequest
+	20, // 54: gorph.v1.GorphService.GetConnection:input_type -> gorph.v1.GetConnectionRequest
+	22, // 55: gorph.v1.GorphService.UpdateConnection:input_type -> gorph.v1.UpdateConnectionRequest
+	24, // 56: gorph.v1.GorphService.DeleteConnection:input_type -> gorph.v1.DeleteConnectionRequest
+	26, // 57: gorph.v1.GorphService.ListConnections:input_type -> gorph.v1.ListConnectionsRequest
+	28, // 58: gorph.v1.GorphService.CreateInfrastructure:input_type -> gorph.v1.CreateInfrastructureRequest
+	30, // 59: gorph.v1.GorphService.GetInfrastructure:input_type -> gorph.v1.GetInfrastructureRequest
+	32, // 60: gorph.v1.GorphService.UpdateInfrastructure:input_type -> gorph.v1.UpdateInfrastructureRequest
+	34, // 61: gorph.v1.GorphService.DeleteInfrastructure:input_type -> gorph.v1.DeleteInfrastructureRequest
+	36, // 62: gorph.v1.GorphService.ListInfrastructures:input_type -> gorph.v1.ListInfrastructuresRequest
+	47, // 63: gorph.v1.GorphService.WatchInfrastructure:input_type -> gorph.v1.WatchInfrastructureRequest
+	38, // 64: gorph.v1.GorphService.GenerateDiagram:input_type -> gorph.v1.GenerateDiagramRequest
+	40, // 65: gorph.v1.GorphService.ImportYAML:input_type -> gorph.v1.ImportYAMLRequest
+	42, // 66: gorph.v1.GorphService.ExportYAML:input_type -> gorph.v1.ExportYAMLRequest
+	44, // 67: gorph.v1.GorphService.ValidateInfrastructure:input_type -> gorph.v1.ValidateInfrastructureRequest
+	9,  // 68: gorph.v1.GorphService.CreateEntity:output_type -> gorph.v1.CreateEntityResponse
+	11, // 69: gorph.v1.GorphService.GetEntity:output_type -> gorph.v1.GetEntityResponse
+	13, // 70: gorph.v1.GorphService.UpdateEntity:output_type -> gorph.v1.UpdateEntityResponse
+	15, // 71: gorph.v1.GorphService.DeleteEntity:output_type -> gorph.v1.DeleteEntityResponse
+	17, // 72: gorph.v1.GorphService.ListEntities:output_type -> gorph.v1.ListEntitiesResponse
+	19, // 73: gorph.v1.GorphService.CreateConnection:output_type -> gorph.v1.CreateConnectionResponse
+	21, // 74: gorph.v1.GorphService.GetConnection:output_type -> gorph.v1.GetConnectionResponse
+	23, // 75: gorph.v1.GorphService.UpdateConnection:output_type -> gorph.v1.UpdateConnectionResponse
+	25, // 76: gorph.v1.GorphService.DeleteConnection:output_type -> gorph.v1.DeleteConnectionResponse
+	27, // 77: gorph.v1.GorphService.ListConnections:output_type -> gorph.v1.ListConnectionsResponse
+	29, // 78: gorph.v1.GorphService.CreateInfrastructure:output_type -> gorph.v1.CreateInfrastructureResponse
+	31, // 79: gorph.v1.GorphService.GetInfrastructure:output_type -> gorph.v1.GetInfrastructureResponse
+	33, // 80: gorph.v1.GorphService.UpdateInfrastructure:output_type -> gorph.v1.UpdateInfrastructureResponse
+	35, // 81: gorph.v1.GorphService.DeleteInfrastructure:output_type -> gorph.v1.DeleteInfrastructureResponse
+	37, // 82: gorph.v1.GorphService.ListInfrastructures:output_type -> gorph.v1.ListInfrastructuresResponse
+	48, // 83: gorph.v1.GorphService.WatchInfrastructure:output_type -> gorph.v1.ChangeEvent
+	39, // 84: gorph.v1.GorphService.GenerateDiagram:output_type -> gorph.v1.GenerateDiagramResponse
+	41, // 85: gorph.v1.GorphService.ImportYAML:output_type -> gorph.v1.ImportYAMLResponse
+	43, // 86: gorph.v1.GorphService.ExportYAML:output_type -> gorph.v1.ExportYAMLResponse
+	45, // 87: gorph.v1.GorphService.ValidateInfrastructure:output_type -> gorph.v1.ValidateInfrastructureResponse
+	68, // [68:88] is the sub-list for method output_type
+	48, // [48:68] is the sub-list for method input_type
+	48, // [48:48] is the sub-list for extension type_name
+	48, // [48:48] is the sub-list for extension extendee
+	0,  // [0:48] is the sub-list for field type_name
 }
 
 func init() { file_gorph_proto_init() }
@@ -3088,8 +3634,8 @@ func file_gorph_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gorph_proto_rawDesc), len(file_gorph_proto_rawDesc)),
-			NumEnums:      4,
-			NumMessages:   44,
+			NumEnums:      5,
+			NumMessages:   46,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
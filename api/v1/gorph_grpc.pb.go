@@ -34,6 +34,7 @@ const (
 	GorphService_UpdateInfrastructure_FullMethodName   = "/gorph.v1.GorphService/UpdateInfrastructure"
 	GorphService_DeleteInfrastructure_FullMethodName   = "/gorph.v1.GorphService/DeleteInfrastructure"
 	GorphService_ListInfrastructures_FullMethodName    = "/gorph.v1.GorphService/ListInfrastructures"
+	GorphService_WatchInfrastructure_FullMethodName    = "/gorph.v1.GorphService/WatchInfrastructure"
 	GorphService_GenerateDiagram_FullMethodName        = "/gorph.v1.GorphService/GenerateDiagram"
 	GorphService_ImportYAML_FullMethodName             = "/gorph.v1.GorphService/ImportYAML"
 	GorphService_ExportYAML_FullMethodName             = "/gorph.v1.GorphService/ExportYAML"
@@ -62,6 +63,7 @@ type GorphServiceClient interface {
 	UpdateInfrastructure(ctx context.Context, in *UpdateInfrastructureRequest, opts ...grpc.CallOption) (*UpdateInfrastructureResponse, error)
 	DeleteInfrastructure(ctx context.Context, in *DeleteInfrastructureRequest, opts ...grpc.CallOption) (*DeleteInfrastructureResponse, error)
 	ListInfrastructures(ctx context.Context, in *ListInfrastructuresRequest, opts ...grpc.CallOption) (*ListInfrastructuresResponse, error)
+	WatchInfrastructure(ctx context.Context, in *WatchInfrastructureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error)
 	// Diagram operations
 	GenerateDiagram(ctx context.Context, in *GenerateDiagramRequest, opts ...grpc.CallOption) (*GenerateDiagramResponse, error)
 	// Import/Export
@@ -229,6 +231,25 @@ func (c *gorphServiceClient) ListInfrastructures(ctx context.Context, in *ListIn
 	return out, nil
 }
 
+func (c *gorphServiceClient) WatchInfrastructure(ctx context.Context, in *WatchInfrastructureRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GorphService_ServiceDesc.Streams[0], GorphService_WatchInfrastructure_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchInfrastructureRequest, ChangeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GorphService_WatchInfrastructureClient = grpc.ServerStreamingClient[ChangeEvent]
+
 func (c *gorphServiceClient) GenerateDiagram(ctx context.Context, in *GenerateDiagramRequest, opts ...grpc.CallOption) (*GenerateDiagramResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GenerateDiagramResponse)
@@ -291,6 +312,7 @@ type GorphServiceServer interface {
 	UpdateInfrastructure(context.Context, *UpdateInfrastructureRequest) (*UpdateInfrastructureResponse, error)
 	DeleteInfrastructure(context.Context, *DeleteInfrastructureRequest) (*DeleteInfrastructureResponse, error)
 	ListInfrastructures(context.Context, *ListInfrastructuresRequest) (*ListInfrastructuresResponse, error)
+	WatchInfrastructure(*WatchInfrastructureRequest, grpc.ServerStreamingServer[ChangeEvent]) error
 	// Diagram operations
 	GenerateDiagram(context.Context, *GenerateDiagramRequest) (*GenerateDiagramResponse, error)
 	// Import/Export
@@ -353,6 +375,9 @@ func (UnimplementedGorphServiceServer) DeleteInfrastructure(context.Context, *De
 func (UnimplementedGorphServiceServer) ListInfrastructures(context.Context, *ListInfrastructuresRequest) (*ListInfrastructuresResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListInfrastructures not implemented")
 }
+func (UnimplementedGorphServiceServer) WatchInfrastructure(*WatchInfrastructureRequest, grpc.ServerStreamingServer[ChangeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchInfrastructure not implemented")
+}
 func (UnimplementedGorphServiceServer) GenerateDiagram(context.Context, *GenerateDiagramRequest) (*GenerateDiagramResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GenerateDiagram not implemented")
 }
@@ -656,6 +681,17 @@ func _GorphService_ListInfrastructures_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GorphService_WatchInfrastructure_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchInfrastructureRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GorphServiceServer).WatchInfrastructure(m, &grpc.GenericServerStream[WatchInfrastructureRequest, ChangeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GorphService_WatchInfrastructureServer = grpc.ServerStreamingServer[ChangeEvent]
+
 func _GorphService_GenerateDiagram_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GenerateDiagramRequest)
 	if err := dec(in); err != nil {
@@ -812,6 +848,12 @@ var GorphService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _GorphService_ValidateInfrastructure_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchInfrastructure",
+			Handler:       _GorphService_WatchInfrastructure_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "gorph.proto",
 }
@@ -1,87 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
-	"gopkg.in/yaml.v3"
+	"gorph/v2/pkg/gorph"
 )
 
-// Infrastructure entity and connection definitions
-type Entity struct {
-	ID               string                 `yaml:"id"`
-	Category         string                 `yaml:"category"`
-	Description      string                 `yaml:"description"`
-	Status           string                 `yaml:"status"`
-	Owner            string                 `yaml:"owner"`
-	Environment      string                 `yaml:"environment"`
-	Tags             []string               `yaml:"tags"`
-	Attributes       map[string]string      `yaml:"attributes"`
-	DeploymentConfig map[string]interface{} `yaml:"deployment_config"`
-	Shape            string                 `yaml:"shape"`
-	Icon             string                 `yaml:"icon"`
-}
-
-type Connection struct {
-	From string `yaml:"from"`
-	To   string `yaml:"to"`
-	Type string `yaml:"type"`
-}
-
-type Infrastructure struct {
-	Entities    []Entity     `yaml:"entities"`
-	Connections []Connection `yaml:"connections"`
-}
-
-// Style configuration structures
-type GraphConfig struct {
-	Direction  string `yaml:"direction"`
-	FontFamily string `yaml:"font_family"`
-	NodeShape  string `yaml:"node_shape"`
-}
-
-type ConnectionStyle struct {
-	Style string `yaml:"style"`
-	Color string `yaml:"color"`
-}
-
-type CategoryConfig struct {
-	DisplayName  string `yaml:"display_name"`
-	ClusterStyle string `yaml:"cluster_style"`
-}
-
-type NodeConfig struct {
-	MaxDescriptionLength int    `yaml:"max_description_length"`
-	TruncationSuffix     string `yaml:"truncation_suffix"`
-	BorderWidth          int    `yaml:"border_width"`
-	CellBorder           int    `yaml:"cell_border"`
-	CellSpacing          int    `yaml:"cell_spacing"`
-	StatusBarHeight      int    `yaml:"status_bar_height"`
-}
-
-type TooltipConfig struct {
-	IncludeStatus      bool `yaml:"include_status"`
-	IncludeOwner       bool `yaml:"include_owner"`
-	IncludeEnvironment bool `yaml:"include_environment"`
-	IncludeTags        bool `yaml:"include_tags"`
-	IncludeDeployment  bool `yaml:"include_deployment"`
-}
-
-type StyleConfig struct {
-	Graph            GraphConfig                `yaml:"graph"`
-	StatusColors     map[string]string          `yaml:"status_colors"`
-	ConnectionStyles map[string]ConnectionStyle `yaml:"connection_styles"`
-	Categories       map[string]CategoryConfig  `yaml:"categories"`
-	Node             NodeConfig                 `yaml:"node"`
-	Tooltip          TooltipConfig              `yaml:"tooltip"`
-}
-
 // Application configuration
 type Config struct {
 	StyleFile          string
@@ -90,28 +22,60 @@ type Config struct {
 	OutputToStdout     bool
 	GeneratePNG        bool
 	PNGFile            string
+	Format             string
+	Engine             string
+	Timeout            time.Duration
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "serve":
+			os.Exit(runServe(os.Args[2:]))
+		case "openapi":
+			os.Exit(runOpenAPI(os.Args[2:]))
+		case "schema":
+			os.Exit(runSchema(os.Args[2:]))
+		}
+	}
+
 	var (
-		inputFile  = flag.String("input", "infra.yml", "Infrastructure YAML file")
+		inputFile  = flag.String("input", "infra.yml", "Infrastructure file in YAML, JSON or TOML (- for stdin)")
 		styleFile  = flag.String("style", "style.yml", "Style configuration file")
-		outputFile = flag.String("output", "", "Output DOT file (default: stdout)")
+		outputFile = flag.String("output", "", "Output file (default: stdout)")
 		pngFile    = flag.String("png", "", "Generate PNG file using Graphviz")
+		format     = flag.String("format", "dot", "Output format: dot, png, svg, pdf, mermaid, plantuml, c4 or drawio")
+		engine     = flag.String("engine", "", "Renderer for svg output: graphviz or native (no Graphviz needed); by default graphviz, or native if Graphviz is not installed")
+		timeout    = flag.Duration("timeout", gorph.DefaultGraphvizLimits.Timeout, "Time limit for Graphviz rendering")
 		help       = flag.Bool("help", false, "Show help message")
 	)
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Gorph - Infrastructure visualization tool\n\n")
-		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s validate [options] [file ...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s serve [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s openapi [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s schema [options] [infrastructure|style]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  %s -input infra.yml                    # Output DOT to stdout\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -input infra.yml -output out.dot   # Output DOT to file\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -input infra.yml -png diagram.png  # Generate PNG directly\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -input infra.yml -format pdf -output diagram.pdf  # PDF via Graphviz\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -input infra.yml -output out.dot -png out.png  # Generate both\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -input infra.yml | dot -Tpng > diagram.png  # Pipe to graphviz\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  jq .infrastructure export.json | %s -input - > out.dot  # Read JSON, YAML or TOML from stdin\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -input infra.yml -format svg -engine native -output out.svg  # SVG without Graphviz\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -input infra.yml -format mermaid > diagram.mmd  # Mermaid flowchart\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -input infra.yml -format c4 > diagram.puml  # C4-PlantUML container diagram\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -input infra.yml -format drawio -output infra.drawio  # Editable in diagrams.net\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s validate infra.yml                  # Check for errors only\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s serve -addr :9090                   # Run the gRPC API\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s serve -http :8080                   # Also serve the HTTP/JSON API\n", os.Args[0])
 	}
 
 	flag.Parse()
@@ -128,49 +92,86 @@ func main() {
 		OutputToStdout:     *outputFile == "" && *pngFile == "",
 		GeneratePNG:        *pngFile != "",
 		PNGFile:            *pngFile,
+		Format:             *format,
+		Engine:             *engine,
+		Timeout:            *timeout,
+	}
+
+	if config.Engine != "" && config.Engine != gorph.EngineGraphviz && config.Engine != gorph.EngineNative {
+		log.Fatalf("Unknown engine %q: use graphviz or native", config.Engine)
 	}
 
 	// Load style configuration
-	styleConfig, err := loadStyleConfig(config.StyleFile)
+	styleConfig, err := gorph.LoadStyleConfig(config.StyleFile)
 	if err != nil {
 		log.Fatalf("Error loading style config: %v", err)
 	}
+	printWarnings(styleConfig.Warnings())
 
 	// Load infrastructure definition
 	infra, err := loadInfrastructure(config.InfrastructureFile)
 	if err != nil {
-		log.Fatalf("Error reading infrastructure YAML: %v", err)
+		log.Fatalf("Error reading infrastructure: %v", err)
+	}
+	printWarnings(infra.Warnings())
+
+	// Refuse to render definitions that would produce broken DOT
+	if errs := gorph.Validate(infra); len(errs) > 0 {
+		printError(errs)
+		log.Fatalf("Infrastructure %s is invalid (%d errors)", displayName(config.InfrastructureFile), len(errs))
 	}
 
-	// Generate DOT output
-	generator := NewDOTGenerator(styleConfig)
-	dotOutput := generator.Generate(infra)
+	// Render the requested output format
+	opts := gorph.RenderOptions{Engine: config.Engine, Graphviz: gorph.GraphvizLimits{Timeout: config.Timeout}}
+	if config.Format != gorph.FormatSVG {
+		// Only svg has a native renderer, so other formats always need
+		// Graphviz
+		opts.Engine = gorph.EngineGraphviz
+	}
+	output, _, err := renderDiagram(infra, styleConfig, config.Format, opts)
+	if errors.Is(err, gorph.ErrUnknownFormat) {
+		log.Fatal(err)
+	} else if err != nil {
+		log.Fatalf("Error generating %s: %v", strings.ToUpper(config.Format), err)
+	}
 
-	// Handle DOT output
+	// Handle output
 	if config.OutputToStdout {
-		fmt.Print(dotOutput)
+		os.Stdout.Write(output)
 	} else if config.OutputFile != "" {
-		if err := ioutil.WriteFile(config.OutputFile, []byte(dotOutput), 0644); err != nil {
-			log.Fatalf("Error writing DOT file: %v", err)
+		if err := os.WriteFile(config.OutputFile, output, 0644); err != nil {
+			log.Fatalf("Error writing %s file: %v", strings.ToUpper(config.Format), err)
 		}
-		fmt.Fprintf(os.Stderr, "Graphviz DOT file generated: %s\n", config.OutputFile)
+		fmt.Fprintf(os.Stderr, "%s file generated: %s\n", strings.ToUpper(config.Format), config.OutputFile)
 	}
 
 	// Handle PNG generation
 	if config.GeneratePNG {
-		if err := generatePNG(dotOutput, config.PNGFile); err != nil {
+		if err := generatePNG(infra, styleConfig, config.PNGFile, opts.Graphviz); err != nil {
 			log.Fatalf("Error generating PNG: %v", err)
 		}
 		fmt.Fprintf(os.Stderr, "PNG diagram generated: %s\n", config.PNGFile)
 	}
 }
 
-func generatePNG(dotContent string, outputPath string) error {
-	// Check if dot command is available
-	if _, err := exec.LookPath("dot"); err != nil {
-		return fmt.Errorf("Graphviz 'dot' command not found. Please install Graphviz: %w", err)
+// loadInfrastructure reads an infrastructure file, or standard input if path
+// is "-".
+func loadInfrastructure(path string) (*gorph.Infrastructure, error) {
+	if path == "-" {
+		return gorph.ReadInfrastructure(os.Stdin, displayName(path))
 	}
+	return gorph.LoadInfrastructure(path)
+}
+
+// displayName names an input file in messages.
+func displayName(path string) string {
+	if path == "-" {
+		return "<stdin>"
+	}
+	return path
+}
 
+func generatePNG(infra *gorph.Infrastructure, style *gorph.StyleConfig, outputPath string, limits gorph.GraphvizLimits) error {
 	// Create output directory if it doesn't exist
 	if dir := filepath.Dir(outputPath); dir != "." {
 		if err := os.MkdirAll(dir, 0755); err != nil {
@@ -178,214 +179,25 @@ func generatePNG(dotContent string, outputPath string) error {
 		}
 	}
 
-	// Execute dot command
-	cmd := exec.Command("dot", "-Tpng", "-o", outputPath)
-	cmd.Stdin = strings.NewReader(dotContent)
-
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("running dot command: %w\nOutput: %s", err, string(output))
-	}
-
-	return nil
-}
-
-func loadStyleConfig(filepath string) (*StyleConfig, error) {
-	data, err := ioutil.ReadFile(filepath)
+	png, _, err := renderDiagram(infra, style, gorph.FormatPNG, gorph.RenderOptions{Graphviz: limits})
 	if err != nil {
-		return nil, fmt.Errorf("reading style config file: %w", err)
-	}
-
-	var config StyleConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("parsing style config: %w", err)
+		return err
 	}
-
-	return &config, nil
+	return os.WriteFile(outputPath, png, 0644)
 }
 
-func loadInfrastructure(filepath string) (*Infrastructure, error) {
-	data, err := ioutil.ReadFile(filepath)
-	if err != nil {
-		return nil, fmt.Errorf("reading infrastructure file: %w", err)
+// renderDiagram renders an infrastructure. When Graphviz is missing, svg
+// is rendered natively unless an engine was chosen, as the server does;
+// other formats fail with suggested alternatives.
+func renderDiagram(infra *gorph.Infrastructure, style *gorph.StyleConfig, format string, opts gorph.RenderOptions) ([]byte, string, error) {
+	output, contentType, err := gorph.Render(context.Background(), infra, style, format, opts)
+	if errors.Is(err, gorph.ErrGraphvizNotFound) && format == gorph.FormatSVG && opts.Engine == "" {
+		fmt.Fprintf(os.Stderr, "Graphviz is not installed, rendering SVG with the native engine\n")
+		opts.Engine = gorph.EngineNative
+		return gorph.Render(context.Background(), infra, style, format, opts)
 	}
-
-	var infra Infrastructure
-	if err := yaml.Unmarshal(data, &infra); err != nil {
-		return nil, fmt.Errorf("parsing infrastructure YAML: %w", err)
-	}
-
-	return &infra, nil
-}
-
-// DOT Generator with style configuration
-type DOTGenerator struct {
-	style *StyleConfig
-}
-
-func NewDOTGenerator(style *StyleConfig) *DOTGenerator {
-	return &DOTGenerator{style: style}
-}
-
-func (g *DOTGenerator) Generate(infra *Infrastructure) string {
-	var sb strings.Builder
-
-	// Graph header with configuration
-	sb.WriteString("digraph Infrastructure {\n")
-	sb.WriteString(fmt.Sprintf("  rankdir=%s;\n", g.style.Graph.Direction))
-	sb.WriteString(fmt.Sprintf("  node [shape=%s, fontname=%s];\n",
-		g.style.Graph.NodeShape, g.style.Graph.FontFamily))
-
-	// Group entities by category
-	categories := g.groupEntitiesByCategory(infra.Entities)
-
-	// Generate clusters for each category
-	for category, entities := range categories {
-		g.generateCluster(&sb, category, entities)
-	}
-
-	// Generate connections
-	for _, conn := range infra.Connections {
-		g.generateConnection(&sb, conn)
+	if errors.Is(err, gorph.ErrGraphvizNotFound) {
+		return nil, "", fmt.Errorf("%w. Please install Graphviz, or use -format svg -engine native", err)
 	}
-
-	sb.WriteString("}\n")
-	return sb.String()
-}
-
-func (g *DOTGenerator) groupEntitiesByCategory(entities []Entity) map[string][]Entity {
-	categories := make(map[string][]Entity)
-	for _, e := range entities {
-		categories[e.Category] = append(categories[e.Category], e)
-	}
-	return categories
-}
-
-func (g *DOTGenerator) generateCluster(sb *strings.Builder, category string, entities []Entity) {
-	displayName := g.getCategoryDisplayName(category)
-
-	sb.WriteString(fmt.Sprintf("  subgraph cluster_%s {\n", category))
-	sb.WriteString(fmt.Sprintf("    label=\"%s\";\n", displayName))
-
-	for _, entity := range entities {
-		g.generateEntityNode(sb, entity)
-	}
-
-	sb.WriteString("  }\n")
-}
-
-func (g *DOTGenerator) getCategoryDisplayName(category string) string {
-	if config, exists := g.style.Categories[category]; exists && config.DisplayName != "" {
-		return config.DisplayName
-	}
-	// Fallback to title case transformation
-	return strings.Title(strings.ToLower(category))
-}
-
-func (g *DOTGenerator) generateEntityNode(sb *strings.Builder, entity Entity) {
-	tooltip := g.generateTooltip(entity)
-	description := g.truncateDescription(entity.Description)
-	statusColor := g.getStatusColor(entity.Status)
-
-	sb.WriteString(fmt.Sprintf(`    %s [tooltip="%s" label=<
-      <TABLE BORDER="%d" CELLBORDER="%d" CELLSPACING="%d">
-        <TR><TD><B>%s</B></TD></TR>
-        <TR><TD>%s</TD></TR>
-        <TR><TD BGCOLOR="%s" HEIGHT="%d"></TD></TR>
-      </TABLE>
-    >];
-`, entity.ID,
-		sanitizeDOTLabel(tooltip),
-		g.style.Node.BorderWidth,
-		g.style.Node.CellBorder,
-		g.style.Node.CellSpacing,
-		entity.ID,
-		description,
-		statusColor,
-		g.style.Node.StatusBarHeight))
-}
-
-func (g *DOTGenerator) generateTooltip(entity Entity) string {
-	var parts []string
-
-	parts = append(parts, fmt.Sprintf("%s: %s", entity.ID, entity.Description))
-
-	if g.style.Tooltip.IncludeStatus {
-		parts = append(parts, fmt.Sprintf("Status: %s", entity.Status))
-	}
-
-	if g.style.Tooltip.IncludeOwner {
-		parts = append(parts, fmt.Sprintf("Owner: %s", entity.Owner))
-	}
-
-	if g.style.Tooltip.IncludeEnvironment && entity.Environment != "" {
-		parts = append(parts, fmt.Sprintf("Environment: %s", entity.Environment))
-	}
-
-	if g.style.Tooltip.IncludeTags && len(entity.Tags) > 0 {
-		parts = append(parts, fmt.Sprintf("Tags: %v", entity.Tags))
-	}
-
-	if g.style.Tooltip.IncludeDeployment && len(entity.DeploymentConfig) > 0 {
-		deploymentYAML, _ := yaml.Marshal(entity.DeploymentConfig)
-		parts = append(parts, fmt.Sprintf("Deployment:\n%s", string(deploymentYAML)))
-	}
-
-	return strings.Join(parts, "\n")
-}
-
-func (g *DOTGenerator) truncateDescription(desc string) string {
-	if len(desc) > g.style.Node.MaxDescriptionLength {
-		return desc[:g.style.Node.MaxDescriptionLength] + g.style.Node.TruncationSuffix
-	}
-	return desc
-}
-
-func (g *DOTGenerator) getStatusColor(status string) string {
-	if color, exists := g.style.StatusColors[strings.ToLower(status)]; exists {
-		return color
-	}
-	return g.style.StatusColors["unknown"]
-}
-
-func (g *DOTGenerator) generateConnection(sb *strings.Builder, conn Connection) {
-	edgeAttrs := g.getConnectionAttributes(conn.Type)
-
-	sb.WriteString(fmt.Sprintf("  %s -> %s [label=\"%s\"%s];\n",
-		conn.From, conn.To, conn.Type, edgeAttrs))
-}
-
-func (g *DOTGenerator) getConnectionAttributes(connType string) string {
-	style, exists := g.style.ConnectionStyles[connType]
-	if !exists {
-		return ""
-	}
-
-	var attrs []string
-
-	if style.Color != "" {
-		attrs = append(attrs, fmt.Sprintf("color=%s", style.Color))
-	}
-
-	if style.Style != "" {
-		attrs = append(attrs, fmt.Sprintf("style=%s", style.Style))
-	}
-
-	if len(attrs) == 0 {
-		return ""
-	}
-
-	return ", " + strings.Join(attrs, ", ")
-}
-
-func sanitizeDOTLabel(input string) string {
-	replacer := strings.NewReplacer(
-		"\"", "\\\"",
-		"\n", "\\n",
-		"{", "\\{",
-		"}", "\\}",
-		"<", "\\<",
-		">", "\\>",
-		"|", "\\|",
-	)
-	return replacer.Replace(input)
+	return output, contentType, err
 }
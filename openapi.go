@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"gorph/v2/pkg/gateway"
+)
+
+// runOpenAPI implements the "openapi" command, which writes the OpenAPI
+// document of the HTTP/JSON gateway and returns the process exit code.
+func runOpenAPI(args []string) int {
+	fs := flag.NewFlagSet("openapi", flag.ExitOnError)
+	outputFile := fs.String("output", "", "Output file (default: stdout)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s openapi [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Writes the OpenAPI document describing the HTTP/JSON gateway.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *outputFile == "" {
+		os.Stdout.Write(gateway.OpenAPI())
+		return 0
+	}
+	if err := os.WriteFile(*outputFile, gateway.OpenAPI(), 0644); err != nil {
+		log.Printf("Error writing OpenAPI document: %v", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,151 @@
+// Package auth identifies the callers of the gorph.v1 API. Authenticators
+// accept static bearer tokens, verified TLS client certificates or JWTs,
+// and gRPC interceptors attach the resulting Identity to each request's
+// context, where the server checks it against the infrastructure changed.
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Identity is an authenticated caller.
+type Identity struct {
+	// Subject names the user or service
+	Subject string
+	// Teams lists the teams the subject belongs to, as used in entity
+	// owners and infrastructure admins
+	Teams []string
+	// Admin grants full access to every infrastructure
+	Admin bool
+}
+
+// SubjectPrefix marks owners and admins that name a single subject rather
+// than a team, as in "user:alice".
+const SubjectPrefix = "user:"
+
+// Member reports whether name is one of the identity's teams, or names its
+// subject with SubjectPrefix. Plain names are only compared with teams, so
+// a subject cannot gain the rights of a team that shares its name.
+func (id *Identity) Member(name string) bool {
+	if name == "" {
+		return false
+	}
+	if subject, ok := strings.CutPrefix(name, SubjectPrefix); ok {
+		return subject != "" && subject == id.Subject
+	}
+	for _, team := range id.Teams {
+		if team == name {
+			return true
+		}
+	}
+	return false
+}
+
+type identityKey struct{}
+
+// NewContext returns a context carrying id.
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity stored in ctx by the interceptors.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// Authenticator identifies the caller of a request from its metadata or
+// connection. It returns a nil identity and no error if the request carries
+// no credentials of the kind it checks.
+type Authenticator interface {
+	Authenticate(ctx context.Context) (*Identity, error)
+}
+
+// Chain returns an authenticator trying each of authenticators in turn.
+// The first identity found wins; if there is none, the errors of all
+// authenticators are reported.
+func Chain(authenticators ...Authenticator) Authenticator {
+	return chain(authenticators)
+}
+
+type chain []Authenticator
+
+func (c chain) Authenticate(ctx context.Context) (*Identity, error) {
+	var errs []string
+	for _, a := range c {
+		id, err := a.Authenticate(ctx)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if id != nil {
+			return id, nil
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+	return nil, nil
+}
+
+// authenticate identifies the caller or fails with Unauthenticated.
+func authenticate(ctx context.Context, a Authenticator) (context.Context, error) {
+	id, err := a.Authenticate(ctx)
+	switch {
+	case err != nil:
+		return nil, status.Errorf(codes.Unauthenticated, "%v", err)
+	case id == nil:
+		return nil, status.Error(codes.Unauthenticated, "credentials required: a bearer token or client certificate")
+	}
+	return NewContext(ctx, id), nil
+}
+
+// UnaryInterceptor rejects unary calls whose caller a does not identify.
+func UnaryInterceptor(a Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor rejects streaming calls whose caller a does not
+// identify.
+func StreamInterceptor(a Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), a)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &identityStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context { return s.ctx }
+
+// bearerToken returns the token of an "authorization: Bearer ..." metadata
+// entry.
+func bearerToken(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		scheme, token, ok := strings.Cut(strings.TrimSpace(value), " ")
+		if ok && strings.EqualFold(scheme, "bearer") {
+			return strings.TrimSpace(token), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,25 @@
+package auth
+
+import "testing"
+
+func TestMember(t *testing.T) {
+	id := &Identity{Subject: "payments", Teams: []string{"platform", "search"}}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"platform", true},
+		{"search", true},
+		{"user:payments", true},
+		// A subject does not get the rights of a team sharing its name
+		{"payments", false},
+		{"user:platform", false},
+		{"user:", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := id.Member(tt.name); got != tt.want {
+			t.Errorf("Member(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
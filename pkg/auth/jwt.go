@@ -0,0 +1,274 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// JWTOptions configures JWT verification.
+type JWTOptions struct {
+	// Issuer, if set, must equal the iss claim
+	Issuer string
+	// Audience, if set, must be one of the aud claim's values
+	Audience string
+	// Leeway allows for clock skew in the exp and nbf checks
+	Leeway time.Duration
+}
+
+// JWT authenticates bearer tokens that are JWTs signed with one of the keys
+// of a JSON Web Key Set. RS*, PS*, ES* and EdDSA signatures are supported.
+//
+// The sub claim is the subject, the groups claim (a list of strings) lists
+// its teams, and "admin" in the roles claim makes it an admin.
+type JWT struct {
+	keys []jwk
+	opts JWTOptions
+	now  func() time.Time
+}
+
+type jwk struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// LoadJWKS reads a JSON Web Key Set file and returns an authenticator
+// accepting JWTs signed with its keys.
+func LoadJWKS(path string, opts JWTOptions) (*JWT, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading JWKS file: %w", err)
+	}
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+			Crv string `json:"crv"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parsing JWKS file %s: %w", path, err)
+	}
+
+	j := &JWT{opts: opts, now: time.Now}
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		switch k.Kty {
+		case "RSA":
+			n, errN := decodeBigInt(k.N)
+			e, errE := decodeBigInt(k.E)
+			if errN != nil || errE != nil || !e.IsInt64() {
+				return nil, fmt.Errorf("JWKS file %s: key %d: invalid RSA key", path, i+1)
+			}
+			key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			curve := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}[k.Crv]
+			x, errX := decodeBigInt(k.X)
+			y, errY := decodeBigInt(k.Y)
+			if curve == nil || errX != nil || errY != nil || !curve.IsOnCurve(x, y) {
+				return nil, fmt.Errorf("JWKS file %s: key %d: invalid EC key", path, i+1)
+			}
+			key = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		case "OKP":
+			x, err := base64.RawURLEncoding.DecodeString(k.X)
+			if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("JWKS file %s: key %d: invalid OKP key", path, i+1)
+			}
+			key = ed25519.PublicKey(x)
+		default:
+			continue
+		}
+		j.keys = append(j.keys, jwk{kid: k.Kid, alg: k.Alg, key: key})
+	}
+	if len(j.keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s has no signing keys", path)
+	}
+	return j, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid number")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// Authenticate verifies a JWT bearer token and identifies its subject.
+func (j *JWT) Authenticate(ctx context.Context) (*Identity, error) {
+	token, ok := bearerToken(ctx)
+	if !ok {
+		return nil, nil
+	}
+	id, err := j.verify(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT: %w", err)
+	}
+	return id, nil
+}
+
+func (j *JWT) verify(token string) (*Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, k := range j.keys {
+		if (header.Kid != "" && k.kid != "" && k.kid != header.Kid) || (k.alg != "" && k.alg != header.Alg) {
+			continue
+		}
+		if verifySignature(header.Alg, k.key, signed, sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("no key verifies the %s signature", header.Alg)
+	}
+
+	var claims struct {
+		Sub    string          `json:"sub"`
+		Iss    string          `json:"iss"`
+		Aud    json.RawMessage `json:"aud"`
+		Exp    *float64        `json:"exp"`
+		Nbf    *float64        `json:"nbf"`
+		Groups []string        `json:"groups"`
+		Roles  []string        `json:"roles"`
+	}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	now := j.now()
+	switch {
+	case claims.Sub == "":
+		return nil, errors.New("no sub claim")
+	case claims.Exp == nil:
+		return nil, errors.New("no exp claim")
+	case now.After(unixTime(*claims.Exp).Add(j.opts.Leeway)):
+		return nil, errors.New("token expired")
+	case claims.Nbf != nil && now.Add(j.opts.Leeway).Before(unixTime(*claims.Nbf)):
+		return nil, errors.New("token not valid yet")
+	case j.opts.Issuer != "" && claims.Iss != j.opts.Issuer:
+		return nil, fmt.Errorf("issuer %q not accepted", claims.Iss)
+	case j.opts.Audience != "" && !hasAudience(claims.Aud, j.opts.Audience):
+		return nil, fmt.Errorf("token is not for audience %q", j.opts.Audience)
+	}
+
+	id := &Identity{Subject: claims.Sub, Teams: claims.Groups}
+	for _, role := range claims.Roles {
+		if role == "admin" {
+			id.Admin = true
+		}
+	}
+	return id, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("malformed base64")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("malformed JSON")
+	}
+	return nil
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// hasAudience reports whether an aud claim, a string or a list of strings,
+// includes audience.
+func hasAudience(aud json.RawMessage, audience string) bool {
+	var one string
+	if json.Unmarshal(aud, &one) == nil {
+		return one == audience
+	}
+	var many []string
+	if json.Unmarshal(aud, &many) == nil {
+		for _, a := range many {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var signatureHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// verifySignature checks a JWS signature made with alg. Keys of another
+// type than alg requires never verify.
+func verifySignature(alg string, key crypto.PublicKey, signed, sig []byte) bool {
+	if alg == "EdDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, signed, sig)
+	}
+	hash, ok := signatureHashes[alg]
+	if !ok {
+		return false
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(pub, hash, digest, sig) == nil
+		case "PS":
+			return rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if alg[:2] != "ES" || len(sig) != 2*size || pub.Curve.Params().BitSize != map[string]int{"ES256": 256, "ES384": 384, "ES512": 521}[alg] {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+	return false
+}
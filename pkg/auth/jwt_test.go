@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jwtKeys are the signing keys of a test JWKS.
+type jwtKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+	ed  ed25519.PrivateKey
+}
+
+func newJWTKeys(t *testing.T) jwtKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwtKeys{rsa: rsaKey, ec: ecKey, ed: edKey}
+}
+
+// jwks writes the public keys as a JWKS file. The RSA key is restricted to
+// RS256.
+func (k jwtKeys) jwks(t *testing.T) string {
+	t.Helper()
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	coord := func(n *big.Int) string { return b64(n.FillBytes(make([]byte, 32))) }
+	set := map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "alg": "RS256", "use": "sig", "n": b64(k.rsa.N.Bytes()), "e": b64(big.NewInt(int64(k.rsa.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": coord(k.ec.X), "y": coord(k.ec.Y)},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(k.ed.Public().(ed25519.PublicKey))},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writeFile(t, "jwks.json", string(data))
+}
+
+// sign returns a JWT with the given header and claims. The signing key is
+// chosen by alg; "none" and unknown algorithms get an empty signature.
+func (k jwtKeys) sign(t *testing.T, header, claims map[string]any) string {
+	t.Helper()
+	segment := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := segment(header) + "." + segment(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	var err error
+	switch header["alg"] {
+	case "RS256":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+	case "PS256":
+		sig, err = rsa.SignPSS(rand.Reader, k.rsa, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		if err == nil {
+			sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case "EdDSA":
+		sig = ed25519.Sign(k.ed, []byte(signed))
+	case "HS256":
+		// Signed with the public RSA modulus, as in key confusion attacks
+		mac := hmac.New(sha256.New, k.rsa.N.Bytes())
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestJWT(t *testing.T) {
+	keys := newJWTKeys(t)
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	verifier, err := LoadJWKS(keys.jwks(t), JWTOptions{Issuer: "https://issuer", Audience: "gorph"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier.now = func() time.Time { return now }
+	if len(verifier.keys) != 3 {
+		t.Fatalf("loaded %d keys, want 3 signing keys", len(verifier.keys))
+	}
+
+	// claims returns valid claims with the given changes; nil values
+	// remove a claim
+	claims := func(changes map[string]any) map[string]any {
+		c := map[string]any{
+			"sub":    "alice",
+			"iss":    "https://issuer",
+			"aud":    "gorph",
+			"exp":    now.Add(time.Minute).Unix(),
+			"groups": []string{"payments"},
+		}
+		for name, value := range changes {
+			if value == nil {
+				delete(c, name)
+			} else {
+				c[name] = value
+			}
+		}
+		return c
+	}
+	header := func(alg, kid string) map[string]any {
+		h := map[string]any{"alg": alg, "typ": "JWT"}
+		if kid != "" {
+			h["kid"] = kid
+		}
+		return h
+	}
+	tampered := func(token string) string {
+		parts := strings.Split(token, ".")
+		other := keys.sign(t, header("ES256", "ec"), claims(map[string]any{"sub": "mallory"}))
+		return parts[0] + "." + strings.Split(other, ".")[1] + "." + parts[2]
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		leeway time.Duration
+		err    string // empty for a valid token
+	}{
+		{"RS256", keys.sign(t, header("RS256", "rsa"), claims(nil)), 0, ""},
+		{"ES256", keys.sign(t, header("ES256", "ec"), claims(nil)), 0, ""},
+		{"EdDSA", keys.sign(t, header("EdDSA", ""), claims(nil)), 0, ""},
+		{"audience list", keys.sign(t, header("ES256", "ec"), claims(map[string]any{"aud": []string{"other", "gorph"}})), 0, ""},
+
+		{"bad signature", tampered(keys.sign(t, header("ES256", "ec"), claims(nil))), 0, "no key verifies the ES256 signature"},
+		{"alg none", keys.sign(t, header("none", ""), claims(nil)), 0, "no key verifies the none signature"},
+		{"HMAC with the public key", keys.sign(t, header("HS256", "rsa"), claims(nil)), 0, "no key verifies the HS256 signature"},
+		{"alg not allowed for key", keys.sign(t, header("PS256", "rsa"), claims(nil)), 0, "no key verifies the PS256 signature"},
+		{"kid of another key", keys.sign(t, header("ES256", "ed"), claims(nil)), 0, "no key verifies the ES256 signature"},
+		{"malformed", "a.b", 0, "malformed token"},
+
+		{"expired", keys.sign(t, header("ES256", "ec"), claims(map[string]any{"exp": now.Add(-10 * time.Second).Unix()})), 0, "token expired"},
+		{"expired within leeway", keys.sign(t, header("ES256", "ec"), claims(map[string]any{"exp": now.Add(-10 * time.Second).Unix()})), 30 * time.Second, ""},
+		{"not valid yet", keys.sign(t, header("ES256", "ec"), claims(map[string]any{"nbf": now.Add(10 * time.Second).Unix()})), 0, "token not valid yet"},
+		{"not valid yet within leeway", keys.sign(t, header("ES256", "ec"), claims(map[string]any{"nbf": now.Add(10 * time.Second).Unix()})), 30 * time.Second, ""},
+		{"no exp", keys.sign(t, header("ES256", "ec"), claims(map[string]any{"exp": nil})), 0, "no exp claim"},
+		{"no sub", keys.sign(t, header("ES256", "ec"), claims(map[string]any{"sub": nil})), 0, "no sub claim"},
+		{"wrong issuer", keys.sign(t, header("ES256", "ec"), claims(map[string]any{"iss": "https://evil"})), 0, `issuer "https://evil" not accepted`},
+		{"no issuer", keys.sign(t, header("ES256", "ec"), claims(map[string]any{"iss": nil})), 0, `issuer "" not accepted`},
+		{"wrong audience", keys.sign(t, header("ES256", "ec"), claims(map[string]any{"aud": "other"})), 0, `token is not for audience "gorph"`},
+		{"no audience", keys.sign(t, header("ES256", "ec"), claims(map[string]any{"aud": nil})), 0, `token is not for audience "gorph"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := *verifier
+			j.opts.Leeway = tt.leeway
+			id, err := j.Authenticate(withBearer(tt.token))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Authenticate() = %+v, %v, want error %q", id, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id.Subject != "alice" || len(id.Teams) != 1 || id.Teams[0] != "payments" || id.Admin {
+				t.Errorf("Authenticate() = %+v, want alice of payments", id)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// ClientCertificates authenticates callers by the TLS client certificate
+// they presented, which the server must have verified against its client
+// CAs. The subject is the certificate's common name, or its first URI,
+// email or DNS name; its organizations and organizational units are the
+// caller's teams.
+type ClientCertificates struct{}
+
+// Authenticate identifies the owner of a verified client certificate.
+func (ClientCertificates) Authenticate(ctx context.Context) (*Identity, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	return certificateIdentity(info.State.VerifiedChains[0][0]), nil
+}
+
+func certificateIdentity(cert *x509.Certificate) *Identity {
+	id := &Identity{Subject: cert.Subject.CommonName}
+	switch {
+	case id.Subject != "":
+	case len(cert.URIs) > 0:
+		id.Subject = cert.URIs[0].String()
+	case len(cert.EmailAddresses) > 0:
+		id.Subject = cert.EmailAddresses[0]
+	case len(cert.DNSNames) > 0:
+		id.Subject = cert.DNSNames[0]
+	}
+	id.Teams = append(id.Teams, cert.Subject.Organization...)
+	id.Teams = append(id.Teams, cert.Subject.OrganizationalUnit...)
+	return id
+}
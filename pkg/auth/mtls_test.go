@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestCertificateIdentity(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/deploy")
+	tests := []struct {
+		name string
+		cert *x509.Certificate
+		want *Identity
+	}{
+		{
+			"common name",
+			&x509.Certificate{
+				Subject:        pkix.Name{CommonName: "alice", Organization: []string{"payments"}, OrganizationalUnit: []string{"platform", "search"}},
+				URIs:           []*url.URL{spiffe},
+				EmailAddresses: []string{"alice@example.org"},
+			},
+			&Identity{Subject: "alice", Teams: []string{"payments", "platform", "search"}},
+		},
+		{
+			"URI",
+			&x509.Certificate{URIs: []*url.URL{spiffe}, EmailAddresses: []string{"deploy@example.org"}, DNSNames: []string{"deploy.example.org"}},
+			&Identity{Subject: "spiffe://example.org/deploy"},
+		},
+		{
+			"email",
+			&x509.Certificate{EmailAddresses: []string{"deploy@example.org"}, DNSNames: []string{"deploy.example.org"}},
+			&Identity{Subject: "deploy@example.org"},
+		},
+		{
+			"DNS name",
+			&x509.Certificate{Subject: pkix.Name{OrganizationalUnit: []string{"platform"}}, DNSNames: []string{"deploy.example.org"}},
+			&Identity{Subject: "deploy.example.org", Teams: []string{"platform"}},
+		},
+		{
+			"no name",
+			&x509.Certificate{Subject: pkix.Name{Organization: []string{"payments"}}},
+			&Identity{Teams: []string{"payments"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := certificateIdentity(tt.cert); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("certificateIdentity() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientCertificatesAuthenticate(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "alice", Organization: []string{"payments"}}}
+	withTLS := func(state tls.ConnectionState) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want *Identity
+	}{
+		{"verified", withTLS(tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}, VerifiedChains: [][]*x509.Certificate{{cert}}}), &Identity{Subject: "alice", Teams: []string{"payments"}}},
+		// A certificate the server did not verify proves nothing
+		{"unverified", withTLS(tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}), nil},
+		{"empty chain", withTLS(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{}}}), nil},
+		{"no TLS", peer.NewContext(context.Background(), &peer.Peer{}), nil},
+		{"no peer", context.Background(), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ClientCertificates{}.Authenticate(tt.ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Authenticate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// tokenFile is the format of a static token file:
+//
+//	tokens:
+//	  - token: 3f1c...            # or sha256: <hex digest of the token>
+//	    subject: deploy-bot
+//	    teams: [platform]
+//	    admin: false
+type tokenFile struct {
+	Tokens []tokenEntry `yaml:"tokens"`
+}
+
+type tokenEntry struct {
+	Token   string   `yaml:"token"`
+	SHA256  string   `yaml:"sha256"`
+	Subject string   `yaml:"subject"`
+	Teams   []string `yaml:"teams"`
+	Admin   bool     `yaml:"admin"`
+}
+
+// Tokens authenticates static bearer tokens listed in a file.
+type Tokens struct {
+	entries []tokenEntry // with SHA256 set
+}
+
+// LoadTokens reads a static token file.
+func LoadTokens(path string) (*Tokens, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading token file: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var file tokenFile
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("parsing token file %s: %w", path, err)
+	}
+
+	t := &Tokens{}
+	seen := make(map[string]int, len(file.Tokens))
+	for i, entry := range file.Tokens {
+		switch {
+		case entry.Subject == "":
+			return nil, fmt.Errorf("token file %s: token %d has no subject", path, i+1)
+		case entry.Token != "" && entry.SHA256 != "":
+			return nil, fmt.Errorf("token file %s: token %d sets both token and sha256", path, i+1)
+		case entry.Token != "":
+			entry.SHA256 = tokenHash(entry.Token)
+			entry.Token = ""
+		case entry.SHA256 != "":
+			digest, err := hex.DecodeString(entry.SHA256)
+			if err != nil || len(digest) != sha256.Size {
+				return nil, fmt.Errorf("token file %s: token %d: sha256 must be a hex SHA-256 digest", path, i+1)
+			}
+			entry.SHA256 = strings.ToLower(entry.SHA256)
+		default:
+			return nil, fmt.Errorf("token file %s: token %d sets neither token nor sha256", path, i+1)
+		}
+		// The same token for two subjects would identify whichever came
+		// first
+		if j, ok := seen[entry.SHA256]; ok {
+			return nil, fmt.Errorf("token file %s: token %d repeats token %d", path, i+1, j)
+		}
+		seen[entry.SHA256] = i + 1
+		t.entries = append(t.entries, entry)
+	}
+	return t, nil
+}
+
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate identifies the subject of a listed bearer token.
+func (t *Tokens) Authenticate(ctx context.Context) (*Identity, error) {
+	token, ok := bearerToken(ctx)
+	if !ok {
+		return nil, nil
+	}
+	hash := []byte(tokenHash(token))
+	for _, entry := range t.entries {
+		if subtle.ConstantTimeCompare(hash, []byte(entry.SHA256)) == 1 {
+			return &Identity{Subject: entry.Subject, Teams: entry.Teams, Admin: entry.Admin}, nil
+		}
+	}
+	return nil, errors.New("unknown bearer token")
+}
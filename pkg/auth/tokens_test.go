@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// withBearer returns a context carrying an incoming bearer token.
+func withBearer(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadTokensErrors(t *testing.T) {
+	secretHash := tokenHash("secret")
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"no subject", "tokens:\n  - token: secret\n", "token 1 has no subject"},
+		{"no token", "tokens:\n  - subject: bot\n", "token 1 sets neither token nor sha256"},
+		{"both", "tokens:\n  - {token: secret, sha256: " + secretHash + ", subject: bot}\n", "sets both token and sha256"},
+		{"bad digest", "tokens:\n  - {sha256: abc, subject: bot}\n", "token 1: sha256 must be a hex SHA-256 digest"},
+		{"duplicate", "tokens:\n  - {token: secret, subject: bot}\n  - {token: other, subject: ci}\n  - {token: secret, subject: admin, admin: true}\n", "token 3 repeats token 1"},
+		{"duplicate digest", "tokens:\n  - {token: secret, subject: bot}\n  - {sha256: " + strings.ToUpper(secretHash) + ", subject: ci}\n", "token 2 repeats token 1"},
+		{"unknown field", "tokens:\n  - {token: secret, subject: bot, team: x}\n", "field team not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTokens(writeFile(t, "tokens.yml", tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadTokens() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := LoadTokens(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Error("LoadTokens() of a missing file succeeded")
+	}
+}
+
+func TestTokensAuthenticate(t *testing.T) {
+	tokens, err := LoadTokens(writeFile(t, "tokens.yml", `tokens:
+  - token: deploy-secret
+    subject: deploy-bot
+    teams: [platform]
+  - sha256: `+tokenHash("admin-secret")+`
+    subject: root
+    admin: true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		subject string
+		admin   bool
+		err     bool
+	}{
+		{"plain token", withBearer("deploy-secret"), "deploy-bot", false, false},
+		{"hashed token", withBearer("admin-secret"), "root", true, false},
+		{"unknown token", withBearer("guess"), "", false, true},
+		{"no token", context.Background(), "", false, false},
+		{"other scheme", metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic deploy-secret")), "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := tokens.Authenticate(tt.ctx)
+			if (err != nil) != tt.err {
+				t.Fatalf("Authenticate() error = %v, want error %v", err, tt.err)
+			}
+			if tt.subject == "" {
+				if id != nil {
+					t.Errorf("Authenticate() = %+v, want no identity", id)
+				}
+				return
+			}
+			if id == nil || id.Subject != tt.subject || id.Admin != tt.admin {
+				t.Errorf("Authenticate() = %+v, want subject %s, admin %v", id, tt.subject, tt.admin)
+			}
+		})
+	}
+}
@@ -0,0 +1,547 @@
+// Package gateway serves the gorph.v1 GorphService as an HTTP/JSON API. It
+// calls the same handlers as the gRPC server, encoding messages with
+// protojson, and describes its routes in a generated OpenAPI document.
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	pb "gorph/v2/api/v1"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// maxBodySize bounds request bodies.
+const maxBodySize = 8 << 20
+
+// route maps an HTTP method and path to a GorphService RPC.
+//
+// Path wildcards and query parameters name request fields. The body, if
+// any, holds the whole request ("*") or one field of it; a string field
+// receives the raw body instead of JSON.
+type route struct {
+	method string
+	path   string
+	rpc    string
+	body   string
+
+	// fields maps path wildcards whose request field has another name,
+	// such as a field of the message in the body
+	fields map[string]string
+
+	// raw names a bytes or string response field sent as the response
+	// body; contentType names the field holding its type, if any
+	raw         string
+	contentType string
+
+	newRequest func() proto.Message
+	call       func(ctx context.Context, req proto.Message) (proto.Message, error)
+}
+
+// unary adapts a GorphService method for use in a route.
+func unary[Req, Resp proto.Message](fn func(context.Context, Req) (Resp, error)) func(*route) {
+	return func(r *route) {
+		var req Req
+		r.newRequest = func() proto.Message { return req.ProtoReflect().New().Interface() }
+		r.call = func(ctx context.Context, in proto.Message) (proto.Message, error) {
+			return fn(ctx, in.(Req))
+		}
+	}
+}
+
+func routes(srv pb.GorphServiceServer) []*route {
+	list := []*route{
+		{method: "GET", path: "/v1/infrastructures", rpc: "ListInfrastructures"},
+		{method: "POST", path: "/v1/infrastructures", rpc: "CreateInfrastructure", body: "infrastructure"},
+		{method: "GET", path: "/v1/infrastructures/{infrastructure_id}", rpc: "GetInfrastructure"},
+		{method: "PATCH", path: "/v1/infrastructures/{infrastructure_id}", rpc: "UpdateInfrastructure", body: "infrastructure",
+			fields: map[string]string{"infrastructure_id": "infrastructure.id"}},
+		{method: "DELETE", path: "/v1/infrastructures/{infrastructure_id}", rpc: "DeleteInfrastructure"},
+
+		{method: "GET", path: "/v1/infrastructures/{infrastructure_id}/entities", rpc: "ListEntities"},
+		{method: "POST", path: "/v1/infrastructures/{infrastructure_id}/entities", rpc: "CreateEntity", body: "entity"},
+		{method: "GET", path: "/v1/infrastructures/{infrastructure_id}/entities/{entity_id}", rpc: "GetEntity"},
+		{method: "PATCH", path: "/v1/infrastructures/{infrastructure_id}/entities/{entity_id}", rpc: "UpdateEntity", body: "entity",
+			fields: map[string]string{"entity_id": "entity.id"}},
+		{method: "DELETE", path: "/v1/infrastructures/{infrastructure_id}/entities/{entity_id}", rpc: "DeleteEntity"},
+
+		{method: "GET", path: "/v1/infrastructures/{infrastructure_id}/connections", rpc: "ListConnections"},
+		{method: "POST", path: "/v1/infrastructures/{infrastructure_id}/connections", rpc: "CreateConnection", body: "connection"},
+		{method: "GET", path: "/v1/infrastructures/{infrastructure_id}/connections/{from}/{to}", rpc: "GetConnection"},
+		{method: "PATCH", path: "/v1/infrastructures/{infrastructure_id}/connections/{from}/{to}", rpc: "UpdateConnection", body: "connection",
+			fields: map[string]string{"from": "connection.from", "to": "connection.to"}},
+		{method: "DELETE", path: "/v1/infrastructures/{infrastructure_id}/connections/{from}/{to}", rpc: "DeleteConnection"},
+
+		{method: "GET", path: "/v1/infrastructures/{infrastructure_id}/diagram", rpc: "GenerateDiagram",
+			raw: "content", contentType: "content_type"},
+		{method: "GET", path: "/v1/infrastructures/{infrastructure_id}/yaml", rpc: "ExportYAML", raw: "yaml_content"},
+		{method: "PUT", path: "/v1/infrastructures/{infrastructure_id}/yaml", rpc: "ImportYAML", body: "yaml_content"},
+		{method: "POST", path: "/v1/validate", rpc: "ValidateInfrastructure", body: "infrastructure"},
+	}
+
+	bind := map[string]func(*route){
+		"ListInfrastructures":    unary(srv.ListInfrastructures),
+		"CreateInfrastructure":   unary(srv.CreateInfrastructure),
+		"GetInfrastructure":      unary(srv.GetInfrastructure),
+		"UpdateInfrastructure":   unary(srv.UpdateInfrastructure),
+		"DeleteInfrastructure":   unary(srv.DeleteInfrastructure),
+		"ListEntities":           unary(srv.ListEntities),
+		"CreateEntity":           unary(srv.CreateEntity),
+		"GetEntity":              unary(srv.GetEntity),
+		"UpdateEntity":           unary(srv.UpdateEntity),
+		"DeleteEntity":           unary(srv.DeleteEntity),
+		"ListConnections":        unary(srv.ListConnections),
+		"CreateConnection":       unary(srv.CreateConnection),
+		"GetConnection":          unary(srv.GetConnection),
+		"UpdateConnection":       unary(srv.UpdateConnection),
+		"DeleteConnection":       unary(srv.DeleteConnection),
+		"GenerateDiagram":        unary(srv.GenerateDiagram),
+		"ExportYAML":             unary(srv.ExportYAML),
+		"ImportYAML":             unary(srv.ImportYAML),
+		"ValidateInfrastructure": unary(srv.ValidateInfrastructure),
+	}
+	for _, r := range list {
+		bind[r.rpc](r)
+	}
+	return list
+}
+
+// Handler serves the HTTP/JSON API.
+type Handler struct {
+	mux    *http.ServeMux
+	srv    pb.GorphServiceServer
+	unary  grpc.UnaryServerInterceptor
+	stream grpc.StreamServerInterceptor
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// UnaryInterceptor runs calls to unary RPCs through i, like the
+// grpc.UnaryInterceptor server option.
+func UnaryInterceptor(i grpc.UnaryServerInterceptor) Option {
+	return func(h *Handler) { h.unary = i }
+}
+
+// StreamInterceptor runs calls to streaming RPCs through i, like the
+// grpc.StreamInterceptor server option.
+func StreamInterceptor(i grpc.StreamServerInterceptor) Option {
+	return func(h *Handler) { h.stream = i }
+}
+
+// New returns a handler calling srv, usually the *server.Server also
+// registered with the gRPC server. Interceptors given as options see the
+// request's Authorization header as metadata and its verified TLS client
+// certificate as the peer.
+func New(srv pb.GorphServiceServer, opts ...Option) *Handler {
+	h := &Handler{mux: http.NewServeMux(), srv: srv}
+	for _, opt := range opts {
+		opt(h)
+	}
+	for _, r := range routes(srv) {
+		h.mux.Handle(r.method+" "+r.path, h.unaryHandler(r))
+	}
+	h.mux.HandleFunc("GET /v1/infrastructures/{infrastructure_id}/watch", h.watch)
+
+	h.mux.HandleFunc("GET /v1/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPI)
+	})
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) unaryHandler(rt *route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := rt.newRequest()
+		if err := bindRequest(req, rt, r); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		method := "/gorph.v1.GorphService/" + rt.rpc
+		ctx, stream := incomingContext(r, method)
+		var resp proto.Message
+		var err error
+		if h.unary != nil {
+			var out any
+			out, err = h.unary(ctx, req, &grpc.UnaryServerInfo{Server: h.srv, FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+				return rt.call(ctx, req.(proto.Message))
+			})
+			resp, _ = out.(proto.Message)
+		} else {
+			resp, err = rt.call(ctx, req)
+		}
+		stream.copyHeaders(w)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if rt.raw != "" {
+			msg := resp.ProtoReflect()
+			fields := msg.Descriptor().Fields()
+			contentType := "text/plain; charset=utf-8"
+			if rt.contentType != "" {
+				contentType = msg.Get(fields.ByName(protoreflect.Name(rt.contentType))).String()
+			} else if rt.raw == "yaml_content" {
+				contentType = "application/yaml"
+			}
+			var body []byte
+			switch v := msg.Get(fields.ByName(protoreflect.Name(rt.raw))).Interface().(type) {
+			case []byte:
+				body = v
+			case string:
+				body = []byte(v)
+			}
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Write(body)
+			return
+		}
+		writeMessage(w, http.StatusOK, resp)
+	})
+}
+
+// bindRequest fills a request message from the path, query and body of an
+// HTTP request.
+func bindRequest(req proto.Message, rt *route, r *http.Request) error {
+	msg := req.ProtoReflect()
+	bound := map[string]bool{}
+
+	if rt.body != "" {
+		bound[rt.body] = true
+		data, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxBodySize))
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "reading request body: %v", err)
+		}
+		if err := setBody(msg, rt.body, data); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range pathParams(rt.path) {
+		field := name
+		if mapped, ok := rt.fields[name]; ok {
+			field = mapped
+		}
+		bound[field] = true
+		if err := setField(msg, field, []string{r.PathValue(name)}); err != nil {
+			return err
+		}
+	}
+
+	for key, values := range r.URL.Query() {
+		if bound[key] {
+			return status.Errorf(codes.InvalidArgument, "query parameter %q is set by the path or body", key)
+		}
+		if err := setField(msg, key, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setBody(msg protoreflect.Message, field string, data []byte) error {
+	if field == "*" {
+		return unmarshalJSON(data, msg.Interface())
+	}
+	fd := msg.Descriptor().Fields().ByName(protoreflect.Name(field))
+	if fd.Kind() == protoreflect.StringKind {
+		msg.Set(fd, protoreflect.ValueOfString(string(data)))
+		return nil
+	}
+	return unmarshalJSON(data, msg.Mutable(fd).Message().Interface())
+}
+
+func unmarshalJSON(data []byte, msg proto.Message) error {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil
+	}
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+// setField sets a scalar, enum or repeated field named by a dot-separated
+// path of proto or JSON field names. Repeated fields also accept
+// comma-separated values.
+func setField(msg protoreflect.Message, path string, values []string) error {
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		fd := findField(msg.Descriptor(), part)
+		if fd == nil {
+			return status.Errorf(codes.InvalidArgument, "unknown parameter %q", path)
+		}
+		if i < len(parts)-1 {
+			if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+				return status.Errorf(codes.InvalidArgument, "unknown parameter %q", path)
+			}
+			msg = msg.Mutable(fd).Message()
+			continue
+		}
+
+		switch {
+		case fd.IsMap() || fd.Message() != nil:
+			return status.Errorf(codes.InvalidArgument, "parameter %q cannot be set in the URL", path)
+		case fd.IsList():
+			list := msg.Mutable(fd).List()
+			for _, value := range values {
+				for _, item := range strings.Split(value, ",") {
+					v, err := parseScalar(fd, item)
+					if err != nil {
+						return status.Errorf(codes.InvalidArgument, "parameter %q: %v", path, err)
+					}
+					list.Append(v)
+				}
+			}
+		default:
+			v, err := parseScalar(fd, values[len(values)-1])
+			if err != nil {
+				return status.Errorf(codes.InvalidArgument, "parameter %q: %v", path, err)
+			}
+			msg.Set(fd, v)
+		}
+	}
+	return nil
+}
+
+func findField(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	if fd := md.Fields().ByName(protoreflect.Name(name)); fd != nil {
+		return fd
+	}
+	return md.Fields().ByJSONName(name)
+}
+
+func parseScalar(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.EnumKind:
+		if ev := enumValue(fd.Enum(), s); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		return protoreflect.Value{}, fmt.Errorf("unknown value %q", s)
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported field type %s", fd.Kind())
+}
+
+// enumValue looks up an enum value by number or by name, with or without
+// the prefix shared by its values: "svg" and "OUTPUT_FORMAT_SVG" both
+// name OUTPUT_FORMAT_SVG.
+func enumValue(ed protoreflect.EnumDescriptor, s string) protoreflect.EnumValueDescriptor {
+	if n, err := strconv.Atoi(s); err == nil {
+		return ed.Values().ByNumber(protoreflect.EnumNumber(n))
+	}
+	name := strings.ToUpper(s)
+	if ev := ed.Values().ByName(protoreflect.Name(name)); ev != nil {
+		return ev
+	}
+	return ed.Values().ByName(protoreflect.Name(enumPrefix(ed) + name))
+}
+
+// enumPrefix returns the prefix of an enum's value names, such as
+// "CONNECTION_TYPE_" for ConnectionType.
+func enumPrefix(ed protoreflect.EnumDescriptor) string {
+	var sb strings.Builder
+	for i, r := range string(ed.Name()) {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			sb.WriteByte('_')
+		}
+		sb.WriteRune(r)
+	}
+	return strings.ToUpper(sb.String()) + "_"
+}
+
+// pathParams returns the wildcard names of a path pattern in order.
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.TrimSuffix(segment[1:], "}"))
+		}
+	}
+	return names
+}
+
+// forwardedHeaders are passed to the handlers as gRPC metadata.
+var forwardedHeaders = []string{"Authorization", "If-Match"}
+
+// incomingContext makes an HTTP request look like a gRPC call to the
+// handlers, including a stream that records the response headers they
+// set.
+func incomingContext(r *http.Request, method string) (context.Context, *headerStream) {
+	md := metadata.MD{}
+	for _, name := range forwardedHeaders {
+		if values := r.Header.Values(name); len(values) > 0 {
+			md.Set(strings.ToLower(name), values...)
+		}
+	}
+	stream := &headerStream{method: method, header: metadata.MD{}}
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+
+	p := &peer.Peer{Addr: remoteAddr(r.RemoteAddr)}
+	if r.TLS != nil {
+		p.AuthInfo = credentials.TLSInfo{State: *r.TLS, CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity}}
+	}
+	ctx = peer.NewContext(ctx, p)
+	return grpc.NewContextWithServerTransportStream(ctx, stream), stream
+}
+
+// remoteAddr is the client address of an HTTP request as a net.Addr.
+type remoteAddr string
+
+func (a remoteAddr) Network() string { return "tcp" }
+func (a remoteAddr) String() string  { return string(a) }
+
+// headerStream collects the metadata a handler sets with grpc.SetHeader.
+type headerStream struct {
+	method string
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return s.method }
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *headerStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *headerStream) SetTrailer(md metadata.MD) error { return nil }
+
+func (s *headerStream) copyHeaders(w http.ResponseWriter) {
+	if etag := s.header.Get("etag"); len(etag) > 0 {
+		w.Header().Set("ETag", etag[0])
+	}
+}
+
+var jsonOptions = protojson.MarshalOptions{}
+
+func writeMessage(w http.ResponseWriter, code int, msg proto.Message) {
+	data, err := jsonOptions.Marshal(msg)
+	if err != nil {
+		code = http.StatusInternalServerError
+		data = []byte(`{"code":13,"message":"encoding response"}`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+	w.Write([]byte("\n"))
+}
+
+// writeError sends a gRPC status as a google.rpc.Status JSON object with
+// the matching HTTP status code.
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	writeMessage(w, httpStatus(st), st.Proto())
+}
+
+// httpStatus maps a gRPC status to an HTTP status code. FailedPrecondition
+// is 412 Precondition Failed only for a version conflict, which the server
+// reports with a VERSION violation, so that it answers a stale If-Match;
+// other failed preconditions, such as a read-only store or a missing
+// Graphviz, are 400 Bad Request.
+func httpStatus(st *status.Status) int {
+	switch st.Code() {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		if versionConflict(st) {
+			return http.StatusPreconditionFailed
+		}
+		return http.StatusBadRequest
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// versionConflict reports whether st names a VERSION precondition.
+func versionConflict(st *status.Status) bool {
+	for _, detail := range st.Details() {
+		if failure, ok := detail.(*errdetails.PreconditionFailure); ok {
+			for _, v := range failure.GetViolations() {
+				if v.GetType() == "VERSION" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// CORS lets browser clients served from the given origins, or from any
+// origin if one of them is "*", call next.
+func CORS(next http.Handler, origins []string) http.Handler {
+	allowed := map[string]bool{}
+	for _, origin := range origins {
+		allowed[strings.TrimRight(origin, "/")] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !(allowed["*"] || allowed[origin]) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Add("Vary", "Origin")
+		h.Set("Access-Control-Expose-Headers", "ETag")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE")
+			h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match")
+			h.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
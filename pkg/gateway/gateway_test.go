@@ -0,0 +1,314 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorph/v2/pkg/gorph"
+	"gorph/v2/pkg/server"
+	"gorph/v2/pkg/storage"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const shopJSON = `{
+  "id": "shop",
+  "entities": [
+    {"id": "API", "category": "CATEGORY_BACKEND", "description": "Shop API", "status": "STATUS_HEALTHY"},
+    {"id": "DB", "category": "CATEGORY_DATABASE", "description": "Orders", "status": "STATUS_HEALTHY"}
+  ],
+  "connections": [{"from": "API", "to": "DB", "type": "CONNECTION_TYPE_DB_CONNECTION"}]
+}`
+
+// newHandler returns a gateway to a server holding the shop
+// infrastructure, at version 1.
+func newHandler(t *testing.T, opts ...Option) *Handler {
+	t.Helper()
+	h := New(server.New(gorph.DefaultStyle(), storage.NewMemory()), opts...)
+	if w := do(h, "POST", "/v1/infrastructures", shopJSON); w.Code != http.StatusOK {
+		t.Fatalf("creating shop: %d %s", w.Code, w.Body)
+	}
+	return h
+}
+
+// do serves a request, taking headers as name and value pairs.
+func do(h http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// statusCode returns the gRPC code of a google.rpc.Status error body.
+func statusCode(t *testing.T, w *httptest.ResponseRecorder) codes.Code {
+	t.Helper()
+	var st struct {
+		Code    codes.Code `json:"code"`
+		Message string     `json:"message"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &st); err != nil {
+		t.Fatalf("error body %q: %v", w.Body, err)
+	}
+	if st.Message == "" {
+		t.Errorf("error body %s has no message", w.Body)
+	}
+	return st.Code
+}
+
+func TestRoutes(t *testing.T) {
+	h := newHandler(t)
+	tests := []struct {
+		method      string
+		path        string
+		body        string
+		code        int
+		contentType string
+		contains    string
+	}{
+		{"GET", "/v1/infrastructures", "", 200, "application/json", `"id":"shop"`},
+		{"GET", "/v1/infrastructures/shop", "", 200, "application/json", `"id":"shop"`},
+		{"GET", "/v1/infrastructures/shop/entities?pageSize=1", "", 200, "application/json", `"nextPageToken"`},
+		{"GET", "/v1/infrastructures/shop/entities/DB", "", 200, "application/json", `"description":"Orders"`},
+		{"GET", "/v1/infrastructures/shop/connections?type=db_connection", "", 200, "application/json", `"to":"DB"`},
+		{"GET", "/v1/infrastructures/shop/connections/API/DB", "", 200, "application/json", `"from":"API"`},
+		{"POST", "/v1/infrastructures/shop/entities", `{"id": "Web", "category": "CATEGORY_FRONTEND", "description": "Store", "status": "STATUS_HEALTHY"}`, 200, "application/json", `"id":"Web"`},
+		{"PATCH", "/v1/infrastructures/shop/entities/DB?update_mask=description", `{"description": "Invoices"}`, 200, "application/json", `"description":"Invoices"`},
+		{"PATCH", "/v1/infrastructures/shop/connections/API/DB", `{"type": "CONNECTION_TYPE_API_CALL"}`, 200, "application/json", `"type":"CONNECTION_TYPE_API_CALL"`},
+		{"DELETE", "/v1/infrastructures/shop/connections/API/DB?type=api_call", "", 200, "application/json", `"version":"5"`},
+		{"GET", "/v1/infrastructures/shop/diagram?format=dot", "", 200, "text/vnd.graphviz", "digraph"},
+		{"GET", "/v1/infrastructures/shop/yaml", "", 200, "application/yaml", "id: Web"},
+		{"PUT", "/v1/infrastructures/blog/yaml", "entities:\n  - {id: Blog, category: BACKEND, description: d, status: healthy}\nconnections: []\n", 200, "application/json", `"id":"blog"`},
+		{"POST", "/v1/validate", shopJSON, 200, "application/json", `"valid":true`},
+		{"DELETE", "/v1/infrastructures/blog", "", 200, "application/json", `{}`},
+		{"GET", "/v1/openapi.json", "", 200, "application/json", `"openapi"`},
+
+		{"GET", "/v1/missing", "", 404, "", ""},
+		{"PUT", "/v1/infrastructures/shop", "", 405, "", ""},
+	}
+	// The requests run in order, each seeing the changes of the ones before
+	for _, tt := range tests {
+		w := do(h, tt.method, tt.path, tt.body)
+		if w.Code != tt.code {
+			t.Errorf("%s %s: status %d, want %d: %s", tt.method, tt.path, w.Code, tt.code, w.Body)
+			continue
+		}
+		if got := w.Header().Get("Content-Type"); tt.contentType != "" && got != tt.contentType {
+			t.Errorf("%s %s: Content-Type %q, want %q", tt.method, tt.path, got, tt.contentType)
+		}
+		if !strings.Contains(w.Body.String(), tt.contains) {
+			t.Errorf("%s %s: body lacks %q:\n%s", tt.method, tt.path, tt.contains, w.Body)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	h := newHandler(t)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+		status codes.Code
+	}{
+		{"malformed JSON", "POST", "/v1/infrastructures", `{"id": `, 400, codes.InvalidArgument},
+		{"unknown JSON field", "POST", "/v1/infrastructures/shop/entities", `{"id": "X", "colour": "red"}`, 400, codes.InvalidArgument},
+		{"wrong JSON type", "POST", "/v1/infrastructures/shop/entities", `{"id": 7}`, 400, codes.InvalidArgument},
+		{"unknown parameter", "GET", "/v1/infrastructures/shop?colour=red", "", 400, codes.InvalidArgument},
+		{"parameter set by path", "GET", "/v1/infrastructures/shop?infrastructure_id=blog", "", 400, codes.InvalidArgument},
+		{"parameter set by body", "POST", "/v1/infrastructures/shop/entities?entity.id=X", `{}`, 400, codes.InvalidArgument},
+		{"message parameter", "GET", "/v1/infrastructures?page_token.x=1", "", 400, codes.InvalidArgument},
+		{"malformed number", "GET", "/v1/infrastructures?page_size=ten", "", 400, codes.InvalidArgument},
+		{"unknown enum value", "GET", "/v1/infrastructures/shop/diagram?format=gif", "", 400, codes.InvalidArgument},
+		{"invalid infrastructure", "POST", "/v1/infrastructures/shop/entities", `{"id": "X"}`, 400, codes.InvalidArgument},
+		{"not found", "GET", "/v1/infrastructures/blog", "", 404, codes.NotFound},
+		{"entity not found", "DELETE", "/v1/infrastructures/shop/entities/Web", "", 404, codes.NotFound},
+		{"already exists", "POST", "/v1/infrastructures", shopJSON, 409, codes.AlreadyExists},
+		{"stale expected_version", "DELETE", "/v1/infrastructures/shop?expected_version=7", "", 412, codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(h, tt.method, tt.path, tt.body)
+			if w.Code != tt.code {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type %q, want application/json", got)
+			}
+			if got := statusCode(t, w); got != tt.status {
+				t.Errorf("code %v, want %v", got, tt.status)
+			}
+		})
+	}
+}
+
+func TestETags(t *testing.T) {
+	h := newHandler(t)
+	const path = "/v1/infrastructures/shop/entities/API?update_mask=description"
+
+	w := do(h, "GET", "/v1/infrastructures/shop", "")
+	if got := w.Header().Get("ETag"); got != `"1"` {
+		t.Errorf("GET ETag = %q, want %q", got, `"1"`)
+	}
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		code    int
+		etag    string // returned by a successful update
+	}{
+		{"current version", `"1"`, 200, `"2"`},
+		{"stale version", `"1"`, 412, ""},
+		{"weak tag", `W/"2"`, 200, `"3"`},
+		{"any version", "*", 200, `"4"`},
+		{"malformed", "yesterday", 400, ""},
+		{"no condition", "", 200, `"5"`},
+	}
+	for _, tt := range tests {
+		var header []string
+		if tt.ifMatch != "" {
+			header = []string{"If-Match", tt.ifMatch}
+		}
+		w := do(h, "PATCH", path, `{"description": "`+tt.name+`"}`, header...)
+		if w.Code != tt.code {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.code, w.Body)
+			continue
+		}
+		if got := w.Header().Get("ETag"); got != tt.etag {
+			t.Errorf("%s: ETag %q, want %q", tt.name, got, tt.etag)
+		}
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	var method string
+	var authorization []string
+	h := newHandler(t, UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		method = info.FullMethod
+		md, _ := metadata.FromIncomingContext(ctx)
+		authorization = md.Get("authorization")
+		if len(authorization) == 0 && info.FullMethod != "/gorph.v1.GorphService/CreateInfrastructure" {
+			return nil, status.Error(codes.Unauthenticated, "credentials required")
+		}
+		return handler(ctx, req)
+	}))
+
+	w := do(h, "GET", "/v1/infrastructures/shop", "")
+	if w.Code != http.StatusUnauthorized || statusCode(t, w) != codes.Unauthenticated {
+		t.Errorf("call without credentials: status %d: %s", w.Code, w.Body)
+	}
+	w = do(h, "GET", "/v1/infrastructures/shop", "", "Authorization", "Bearer secret")
+	if w.Code != http.StatusOK {
+		t.Errorf("call with credentials: status %d: %s", w.Code, w.Body)
+	}
+	if method != "/gorph.v1.GorphService/GetInfrastructure" || len(authorization) != 1 || authorization[0] != "Bearer secret" {
+		t.Errorf("interceptor saw method %q, authorization %q", method, authorization)
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want int
+	}{
+		{codes.OK, 200},
+		{codes.Canceled, 499},
+		{codes.Unknown, 500},
+		{codes.InvalidArgument, 400},
+		{codes.DeadlineExceeded, 504},
+		{codes.NotFound, 404},
+		{codes.AlreadyExists, 409},
+		{codes.PermissionDenied, 403},
+		{codes.ResourceExhausted, 429},
+		{codes.FailedPrecondition, 400},
+		{codes.Aborted, 409},
+		{codes.OutOfRange, 400},
+		{codes.Unimplemented, 501},
+		{codes.Internal, 500},
+		{codes.Unavailable, 503},
+		{codes.DataLoss, 500},
+		{codes.Unauthenticated, 401},
+	}
+	for _, tt := range tests {
+		if got := httpStatus(status.New(tt.code, "")); got != tt.want {
+			t.Errorf("httpStatus(%v) = %d, want %d", tt.code, got, tt.want)
+		}
+	}
+
+	conflict, err := status.New(codes.FailedPrecondition, "stale").WithDetails(&errdetails.PreconditionFailure{
+		Violations: []*errdetails.PreconditionFailure_Violation{{Type: "VERSION", Subject: "shop"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := httpStatus(conflict); got != 412 {
+		t.Errorf("httpStatus(version conflict) = %d, want 412", got)
+	}
+}
+
+func TestFailedPreconditions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"platform.yml": `include: [search.yml]
+entities:
+  - id: Gateway
+    category: FRONTEND
+    description: Edge
+    status: healthy
+connections: []
+`,
+		"search.yml": `entities:
+  - id: Index
+    category: DATABASE
+    description: Search index
+    status: healthy
+connections: []
+`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := storage.OpenDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := New(server.New(gorph.DefaultStyle(), store))
+	t.Setenv("PATH", t.TempDir())
+
+	// Only a version conflict answers a stale If-Match with 412
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		header       []string
+		code         int
+	}{
+		{"stale version", "PATCH", "/v1/infrastructures/search", `{"description": "x"}`, []string{"If-Match", `"7"`}, 412},
+		{"read-only definition", "PATCH", "/v1/infrastructures/platform", `{"description": "x"}`, nil, 400},
+		{"no graphviz", "GET", "/v1/infrastructures/search/diagram?format=png", "", nil, 400},
+	}
+	for _, tt := range tests {
+		w := do(h, tt.method, tt.path, tt.body, tt.header...)
+		if w.Code != tt.code {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.code, w.Body)
+		}
+		if got := statusCode(t, w); got != codes.FailedPrecondition {
+			t.Errorf("%s: code %v, want FailedPrecondition", tt.name, got)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	pb "gorph/v2/api/v1"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// openAPI is the document served at /v1/openapi.json.
+var openAPI = mustOpenAPI()
+
+// OpenAPI returns an OpenAPI 3.0 description of the HTTP/JSON API,
+// generated from the route table and the gorph.v1 message definitions.
+func OpenAPI() []byte {
+	return append([]byte(nil), openAPI...)
+}
+
+func mustOpenAPI() []byte {
+	doc, err := json.MarshalIndent(newSpec().document(), "", "  ")
+	if err != nil {
+		panic(fmt.Sprintf("generating OpenAPI document: %v", err))
+	}
+	return append(doc, '\n')
+}
+
+// rawContentTypes lists the media types of raw response fields.
+var rawContentTypes = map[string][]string{
+	"content":      {"text/vnd.graphviz", "image/png", "image/svg+xml", "application/pdf"},
+	"yaml_content": {"application/yaml"},
+}
+
+type object = map[string]any
+
+// spec accumulates the paths and schemas of the document.
+type spec struct {
+	service protoreflect.ServiceDescriptor
+	paths   object
+	schemas object
+}
+
+func newSpec() *spec {
+	return &spec{
+		service: pb.File_gorph_proto.Services().ByName("GorphService"),
+		paths:   object{},
+		schemas: object{},
+	}
+}
+
+func (s *spec) document() object {
+	for _, rt := range routes(pb.UnimplementedGorphServiceServer{}) {
+		s.addRoute(rt)
+	}
+	s.addWatch()
+	s.schemas["Status"] = object{
+		"type":        "object",
+		"description": "google.rpc.Status describing a failed request.",
+		"properties": object{
+			"code":    object{"type": "integer", "format": "int32"},
+			"message": object{"type": "string"},
+			"details": object{"type": "array", "items": object{"type": "object"}},
+		},
+	}
+
+	return object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":       "Gorph API",
+			"version":     "v1",
+			"description": "HTTP/JSON mapping of the gorph.v1.GorphService gRPC API. Messages use the protobuf JSON encoding.",
+		},
+		"paths":      s.paths,
+		"components": object{"schemas": s.schemas},
+	}
+}
+
+func (s *spec) addRoute(rt *route) {
+	method := s.service.Methods().ByName(protoreflect.Name(rt.rpc))
+	input := method.Input()
+
+	bound := map[string]bool{}
+	var params []any
+	for _, name := range pathParams(rt.path) {
+		field := name
+		if mapped, ok := rt.fields[name]; ok {
+			field = mapped
+		}
+		bound[field] = true
+		params = append(params, object{"name": name, "in": "path", "required": true, "schema": object{"type": "string"}})
+	}
+
+	op := object{"operationId": rt.rpc}
+	if rt.body != "" {
+		bound[rt.body] = true
+		op["requestBody"] = s.requestBody(input, rt.body)
+	}
+
+	fields := input.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if bound[string(fd.Name())] || fd.IsMap() || fd.Message() != nil {
+			continue
+		}
+		schema := s.fieldSchema(fd)
+		param := object{"name": string(fd.Name()), "in": "query", "schema": schema}
+		if fd.IsList() {
+			param["explode"] = true
+		}
+		params = append(params, param)
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	responses := object{"default": errorResponse()}
+	if types, ok := rawContentTypes[rt.raw]; ok {
+		content := object{}
+		for _, t := range types {
+			content[t] = object{"schema": object{"type": "string", "format": "binary"}}
+		}
+		responses["200"] = object{"description": "OK", "content": content}
+	} else {
+		responses["200"] = object{"description": "OK", "content": jsonContent(s.ref(method.Output()))}
+	}
+	op["responses"] = responses
+
+	s.path(rt.path)[strings.ToLower(rt.method)] = op
+}
+
+func (s *spec) addWatch() {
+	s.path("/v1/infrastructures/{infrastructure_id}/watch")["get"] = object{
+		"operationId": "WatchInfrastructure",
+		"description": "Streams change events as newline-delimited JSON. An error after the first event is sent as a final {\"error\": Status} line.",
+		"parameters": []any{
+			object{"name": "infrastructure_id", "in": "path", "required": true, "schema": object{"type": "string"}},
+			object{"name": "start_version", "in": "query", "schema": object{"type": "string", "format": "int64"}},
+		},
+		"responses": object{
+			"200": object{
+				"description": "OK",
+				"content":     object{"application/x-ndjson": object{"schema": s.ref(s.service.Methods().ByName("WatchInfrastructure").Output())}},
+			},
+			"default": errorResponse(),
+		},
+	}
+}
+
+func (s *spec) path(path string) object {
+	item, ok := s.paths[path].(object)
+	if !ok {
+		item = object{}
+		s.paths[path] = item
+	}
+	return item
+}
+
+func (s *spec) requestBody(input protoreflect.MessageDescriptor, body string) object {
+	if body == "*" {
+		return object{"required": true, "content": jsonContent(s.ref(input))}
+	}
+	fd := input.Fields().ByName(protoreflect.Name(body))
+	if fd.Kind() == protoreflect.StringKind {
+		return object{"required": true, "content": object{"application/yaml": object{"schema": object{"type": "string"}}}}
+	}
+	return object{"required": true, "content": jsonContent(s.ref(fd.Message()))}
+}
+
+func jsonContent(schema object) object {
+	return object{"application/json": object{"schema": schema}}
+}
+
+func errorResponse() object {
+	return object{"description": "Error", "content": jsonContent(object{"$ref": "#/components/schemas/Status"})}
+}
+
+// ref returns a reference to the schema of a message, adding it and the
+// messages it refers to on first use.
+func (s *spec) ref(md protoreflect.MessageDescriptor) object {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return object{"type": "string", "format": "date-time"}
+	case "google.protobuf.Struct":
+		return object{"type": "object", "additionalProperties": true}
+	case "google.protobuf.Value":
+		return object{}
+	}
+
+	name := string(md.Name())
+	if _, ok := s.schemas[name]; !ok {
+		properties := object{}
+		schema := object{"type": "object", "properties": properties}
+		s.schemas[name] = schema
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			properties[fd.JSONName()] = s.fieldSchema(fd)
+		}
+	}
+	return object{"$ref": "#/components/schemas/" + name}
+}
+
+func (s *spec) fieldSchema(fd protoreflect.FieldDescriptor) object {
+	if fd.IsMap() {
+		return object{"type": "object", "additionalProperties": s.valueSchema(fd.MapValue())}
+	}
+	if fd.IsList() {
+		return object{"type": "array", "items": s.valueSchema(fd)}
+	}
+	return s.valueSchema(fd)
+}
+
+func (s *spec) valueSchema(fd protoreflect.FieldDescriptor) object {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return s.ref(fd.Message())
+	case protoreflect.EnumKind:
+		return s.enum(fd.Enum())
+	case protoreflect.BoolKind:
+		return object{"type": "boolean"}
+	case protoreflect.StringKind:
+		return object{"type": "string"}
+	case protoreflect.BytesKind:
+		return object{"type": "string", "format": "byte"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return object{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return object{"type": "integer", "format": "int64", "minimum": 0}
+	case protoreflect.FloatKind:
+		return object{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return object{"type": "number", "format": "double"}
+	default:
+		// 64-bit integers are strings in the protobuf JSON encoding
+		return object{"type": "string", "format": "int64"}
+	}
+}
+
+func (s *spec) enum(ed protoreflect.EnumDescriptor) object {
+	name := string(ed.Name())
+	if _, ok := s.schemas[name]; !ok {
+		var values []any
+		for i := 0; i < ed.Values().Len(); i++ {
+			values = append(values, string(ed.Values().Get(i).Name()))
+		}
+		s.schemas[name] = object{"type": "string", "enum": values}
+	}
+	return object{"$ref": "#/components/schemas/" + name}
+}
@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+
+	pb "gorph/v2/api/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// watch streams WatchInfrastructure events as newline-delimited JSON, one
+// ChangeEvent per line. An error after the first event is sent as a final
+// {"error": Status} line, since the HTTP status has already been sent.
+func (h *Handler) watch(w http.ResponseWriter, r *http.Request) {
+	req := &pb.WatchInfrastructureRequest{}
+	rt := &route{path: "/v1/infrastructures/{infrastructure_id}/watch"}
+	if err := bindRequest(req, rt, r); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	ctx, header := incomingContext(r, pb.GorphService_WatchInfrastructure_FullMethodName)
+	stream := &eventStream{ctx: ctx, w: w, header: header}
+	var err error
+	if h.stream != nil {
+		info := &grpc.StreamServerInfo{FullMethod: pb.GorphService_WatchInfrastructure_FullMethodName, IsServerStream: true}
+		err = h.stream(h.srv, stream, info, func(srv any, ss grpc.ServerStream) error {
+			return h.srv.WatchInfrastructure(req, &grpc.GenericServerStream[pb.WatchInfrastructureRequest, pb.ChangeEvent]{ServerStream: ss})
+		})
+	} else {
+		err = h.srv.WatchInfrastructure(req, stream)
+	}
+	if err == nil || status.Code(err) == codes.Canceled {
+		return
+	}
+	if !stream.started {
+		header.copyHeaders(w)
+		writeError(w, err)
+		return
+	}
+	data, merr := jsonOptions.Marshal(status.Convert(err).Proto())
+	if merr != nil {
+		return
+	}
+	w.Write([]byte(`{"error":`))
+	w.Write(data)
+	w.Write([]byte("}\n"))
+}
+
+// eventStream writes the events sent by WatchInfrastructure to an HTTP
+// response.
+type eventStream struct {
+	ctx     context.Context
+	w       http.ResponseWriter
+	header  *headerStream
+	started bool
+}
+
+func (s *eventStream) Context() context.Context { return s.ctx }
+
+func (s *eventStream) SetHeader(md metadata.MD) error { return s.header.SetHeader(md) }
+
+func (s *eventStream) SendHeader(md metadata.MD) error { return s.header.SetHeader(md) }
+
+func (s *eventStream) SetTrailer(md metadata.MD) {}
+
+func (s *eventStream) SendMsg(m any) error {
+	event, ok := m.(*pb.ChangeEvent)
+	if !ok {
+		return status.Errorf(codes.Internal, "unexpected message %T", m)
+	}
+	return s.Send(event)
+}
+
+// RecvMsg is never called: the request has already been read.
+func (s *eventStream) RecvMsg(m any) error {
+	return status.Error(codes.Internal, "no messages to receive")
+}
+
+func (s *eventStream) Send(event *pb.ChangeEvent) error {
+	data, err := jsonOptions.Marshal(event)
+	if err != nil {
+		return status.Errorf(codes.Internal, "encoding event: %v", err)
+	}
+	if !s.started {
+		s.header.copyHeaders(s.w)
+		s.w.Header().Set("Content-Type", "application/x-ndjson")
+		s.w.Header().Set("Cache-Control", "no-cache")
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+	if _, err := s.w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package gorph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DOT Generator with style configuration
+type DOTGenerator struct {
+	style *StyleConfig
+	icons *iconResolver
+}
+
+func NewDOTGenerator(style *StyleConfig) *DOTGenerator {
+	return &DOTGenerator{style: style, icons: newIconResolver(style.Node.IconDir)}
+}
+
+func (g *DOTGenerator) Generate(infra *Infrastructure) string {
+	var sb strings.Builder
+
+	// Graph header with configuration
+	sb.WriteString("digraph Infrastructure {\n")
+	sb.WriteString(fmt.Sprintf("  rankdir=%s;\n", quoteDOTID(g.style.Graph.Direction)))
+	sb.WriteString(fmt.Sprintf("  node [shape=%s, fontname=%s];\n",
+		quoteDOTID(g.style.Graph.NodeShape), quoteDOTID(g.style.Graph.FontFamily)))
+
+	// Group entities by namespace and category
+	g.generateGroups(&sb, groupEntities(infra.Entities, g.style.CategoryOrder), "  ")
+
+	// Generate connections
+	for _, conn := range infra.Connections {
+		g.generateConnection(&sb, conn)
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// generateGroups writes the category clusters of a namespace, followed by
+// its nested namespaces as clusters around theirs.
+func (g *DOTGenerator) generateGroups(sb *strings.Builder, group *namespaceGroup, indent string) {
+	for _, category := range group.Categories {
+		g.generateCluster(sb, indent, group.Name, category.Category, category.Entities)
+	}
+
+	for _, nested := range group.Namespaces {
+		config := g.style.Namespaces[nested.Name]
+		if config.NoCluster {
+			g.generateGroups(sb, nested, indent)
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%ssubgraph %s {\n", indent, quoteDOTID("cluster_"+namespaceCluster(nested.Name))))
+		sb.WriteString(fmt.Sprintf("%s  label=%s;\n", indent, quoteDOTString(namespaceDisplayName(g.style, nested.Name))))
+		for _, attr := range clusterAttributes(config) {
+			sb.WriteString(fmt.Sprintf("%s  %s;\n", indent, attr))
+		}
+		g.generateGroups(sb, nested, indent+"  ")
+		sb.WriteString(indent + "}\n")
+	}
+}
+
+func (g *DOTGenerator) generateCluster(sb *strings.Builder, indent, namespace, category string, entities []Entity) {
+	config := g.style.Categories[category]
+	name := categoryCluster(namespace, category)
+
+	// Plain subgraphs keep the entities together without drawing a box
+	if config.NoCluster {
+		sb.WriteString(fmt.Sprintf("%ssubgraph %s {\n", indent, quoteDOTID(name)))
+	} else {
+		sb.WriteString(fmt.Sprintf("%ssubgraph %s {\n", indent, quoteDOTID("cluster_"+name)))
+		sb.WriteString(fmt.Sprintf("%s  label=%s;\n", indent, quoteDOTString(categoryDisplayName(g.style, category))))
+		for _, attr := range clusterAttributes(config) {
+			sb.WriteString(fmt.Sprintf("%s  %s;\n", indent, attr))
+		}
+	}
+
+	for _, entity := range entities {
+		g.generateEntityNode(sb, indent+"  ", entity)
+	}
+
+	sb.WriteString(indent + "}\n")
+}
+
+// clusterAttributes returns the DOT attribute assignments for a category
+// cluster, in a fixed order.
+func clusterAttributes(config CategoryConfig) []string {
+	var attrs []string
+
+	style := config.ClusterStyle
+	if config.FillColor != "" && !strings.Contains(style, "filled") {
+		style = strings.Trim(style+",filled", ",")
+	}
+	if style != "" {
+		attrs = append(attrs, "style="+quoteDOTString(style))
+	}
+	if config.FillColor != "" {
+		attrs = append(attrs, "fillcolor="+quoteDOTString(config.FillColor))
+	}
+	if config.BorderColor != "" {
+		attrs = append(attrs, "pencolor="+quoteDOTString(config.BorderColor))
+	}
+	if config.BorderWidth > 0 {
+		attrs = append(attrs, fmt.Sprintf("penwidth=%d", config.BorderWidth))
+	}
+	if config.FontName != "" {
+		attrs = append(attrs, "fontname="+quoteDOTString(config.FontName))
+	}
+	if config.FontSize > 0 {
+		attrs = append(attrs, fmt.Sprintf("fontsize=%d", config.FontSize))
+	}
+	if config.FontColor != "" {
+		attrs = append(attrs, "fontcolor="+quoteDOTString(config.FontColor))
+	}
+
+	loc, just := labelPosition(config.LabelPosition)
+	if loc != "" {
+		attrs = append(attrs, "labelloc="+loc)
+	}
+	if just != "" {
+		attrs = append(attrs, "labeljust="+just)
+	}
+
+	return attrs
+}
+
+// labelPosition converts a label_position value such as "bottom-right" to
+// Graphviz labelloc and labeljust values. Unrecognized parts are ignored.
+func labelPosition(position string) (loc, just string) {
+	for _, part := range strings.FieldsFunc(strings.ToLower(position), func(r rune) bool {
+		return r == '-' || r == ' ' || r == '_'
+	}) {
+		switch part {
+		case "top":
+			loc = "t"
+		case "bottom":
+			loc = "b"
+		case "left":
+			just = "l"
+		case "right":
+			just = "r"
+		case "center":
+			just = "c"
+		}
+	}
+	return loc, just
+}
+
+func (g *DOTGenerator) generateEntityNode(sb *strings.Builder, indent string, entity Entity) {
+	tooltip := generateTooltip(g.style, entity)
+	description := truncateRunes(entity.Description, g.style.Node.MaxDescriptionLength, g.style.Node.TruncationSuffix)
+	statusColor := statusColor(g.style, entity.Status)
+	shape := resolveShape(g.style, entity.Shape)
+
+	// A node shape replaces the table border as the entity outline
+	var nodeAttrs, tableAttrs string
+	border := g.style.Node.BorderWidth
+	if shape.Shape != "" {
+		nodeAttrs = fmt.Sprintf("shape=%s ", quoteDOTID(shape.Shape))
+		border = 0
+	}
+	if shape.Rounded {
+		tableAttrs = ` STYLE="rounded"`
+	}
+
+	sb.WriteString(fmt.Sprintf("%s%s [%stooltip=%s label=<\n", indent, quoteDOTID(entity.ID), nodeAttrs, quoteDOTString(tooltip)))
+	sb.WriteString(fmt.Sprintf("%s  <TABLE BORDER=\"%d\" CELLBORDER=\"%d\" CELLSPACING=\"%d\"%s>\n",
+		indent, border, g.style.Node.CellBorder, g.style.Node.CellSpacing, tableAttrs))
+
+	if icon := g.icons.Resolve(entity.Icon); icon != "" {
+		sb.WriteString(indent + g.iconRow(icon))
+	}
+
+	sb.WriteString(fmt.Sprintf("%s    <TR><TD><B>%s</B></TD></TR>\n", indent, escapeHTML(entityLabel(g.style, entity.ID))))
+	sb.WriteString(fmt.Sprintf("%s    <TR><TD>%s</TD></TR>\n", indent, escapeHTML(description)))
+
+	for _, attr := range displayedAttributes(g.style, entity) {
+		sb.WriteString(fmt.Sprintf("%s    <TR><TD ALIGN=\"LEFT\"><I>%s</I>: %s</TD></TR>\n",
+			indent, escapeHTML(attr.Key), escapeHTML(truncateRunes(attr.Value, g.style.Node.MaxDescriptionLength, g.style.Node.TruncationSuffix))))
+	}
+
+	sb.WriteString(fmt.Sprintf("%s    <TR><TD BGCOLOR=\"%s\" HEIGHT=\"%d\"></TD></TR>\n",
+		indent, escapeHTML(statusColor), g.style.Node.StatusBarHeight))
+	sb.WriteString(indent + "  </TABLE>\n" + indent + ">];\n")
+}
+
+func (g *DOTGenerator) iconRow(path string) string {
+	if size := g.style.Node.IconSize; size > 0 {
+		return fmt.Sprintf("    <TR><TD FIXEDSIZE=\"TRUE\" WIDTH=\"%d\" HEIGHT=\"%d\"><IMG SRC=\"%s\" SCALE=\"TRUE\"/></TD></TR>\n",
+			size, size, escapeHTML(path))
+	}
+	return fmt.Sprintf("    <TR><TD><IMG SRC=\"%s\"/></TD></TR>\n", escapeHTML(path))
+}
+
+func (g *DOTGenerator) generateConnection(sb *strings.Builder, conn Connection) {
+	edgeAttrs := g.getConnectionAttributes(conn.Type)
+
+	sb.WriteString(fmt.Sprintf("  %s -> %s [label=%s%s];\n",
+		quoteDOTID(conn.From), quoteDOTID(conn.To), quoteDOTString(conn.Type), edgeAttrs))
+}
+
+func (g *DOTGenerator) getConnectionAttributes(connType string) string {
+	style, exists := g.style.ConnectionStyles[connType]
+	if !exists {
+		return ""
+	}
+
+	var attrs []string
+
+	if style.Color != "" {
+		attrs = append(attrs, fmt.Sprintf("color=%s", quoteDOTID(style.Color)))
+	}
+
+	if style.Style != "" {
+		attrs = append(attrs, fmt.Sprintf("style=%s", quoteDOTID(style.Style)))
+	}
+
+	if len(attrs) == 0 {
+		return ""
+	}
+
+	return ", " + strings.Join(attrs, ", ")
+}
@@ -0,0 +1,147 @@
+package gorph
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares output with the golden file testdata/name, or
+// rewrites the file when the tests run with -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s (run go test -update to accept it):\n%s", path, got)
+	}
+}
+
+func TestDOTGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		style func(*StyleConfig)
+	}{
+		{
+			name:  "shapes",
+			input: "shapes.yml",
+			style: func(style *StyleConfig) {
+				style.Node.IconDir = filepath.Join("testdata", "icons")
+				style.Node.ShowAttributes = []string{"runtime", "*", "engine"}
+			},
+		},
+		{
+			name:  "clusters",
+			input: "clusters.yml",
+			style: func(style *StyleConfig) {
+				style.Categories["FRONTEND"] = CategoryConfig{
+					DisplayName:   "Frontend",
+					ClusterStyle:  "rounded,dashed",
+					FillColor:     "#eef",
+					BorderColor:   "blue",
+					BorderWidth:   2,
+					FontName:      "Courier",
+					FontSize:      18,
+					FontColor:     "navy",
+					LabelPosition: "bottom-right",
+				}
+				style.Categories["BACKEND"] = CategoryConfig{DisplayName: "Backend", FillColor: "lightgray", LabelPosition: "top center"}
+				style.Categories["DATABASE"] = CategoryConfig{DisplayName: "Database", ClusterStyle: "filled", FillColor: "white", LabelPosition: "left"}
+				style.Categories["INTEGRATION"] = CategoryConfig{NoCluster: true}
+			},
+		},
+		{
+			name:  "namespaces",
+			input: filepath.Join("namespaces", "root.yml"),
+			style: func(style *StyleConfig) {
+				style.Namespaces = map[string]CategoryConfig{
+					"platform": {DisplayName: "Platform", ClusterStyle: "rounded,dashed", LabelPosition: "bottom-left"},
+					"shared":   {NoCluster: true},
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			infra, err := LoadInfrastructure(filepath.Join("testdata", tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if errs := Validate(infra); len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			style := DefaultStyle()
+			tt.style(style)
+			checkGolden(t, tt.name+".dot", NewDOTGenerator(style).Generate(infra))
+		})
+	}
+}
+
+func TestLabelPosition(t *testing.T) {
+	tests := []struct {
+		position  string
+		loc, just string
+	}{
+		{"", "", ""},
+		{"top", "t", ""},
+		{"bottom-left", "b", "l"},
+		{"Top Right", "t", "r"},
+		{"bottom_center", "b", "c"},
+		{"right", "", "r"},
+		{"middle", "", ""},
+	}
+	for _, tt := range tests {
+		if loc, just := labelPosition(tt.position); loc != tt.loc || just != tt.just {
+			t.Errorf("labelPosition(%q) = %q, %q, want %q, %q", tt.position, loc, just, tt.loc, tt.just)
+		}
+	}
+}
+
+func TestClusterAttributes(t *testing.T) {
+	tests := []struct {
+		name   string
+		config CategoryConfig
+		want   []string
+	}{
+		{"empty", CategoryConfig{}, nil},
+		{"fill adds filled", CategoryConfig{ClusterStyle: "rounded", FillColor: "red"},
+			[]string{`style="rounded,filled"`, `fillcolor="red"`}},
+		{"fill alone", CategoryConfig{FillColor: "red"},
+			[]string{`style="filled"`, `fillcolor="red"`}},
+		{"filled kept once", CategoryConfig{ClusterStyle: "filled,dashed", FillColor: "red"},
+			[]string{`style="filled,dashed"`, `fillcolor="red"`}},
+		{"quoted values", CategoryConfig{BorderColor: `x" fontsize=99`, FontName: "Sans", BorderWidth: 3, FontSize: 9, FontColor: "blue"},
+			[]string{`pencolor="x\" fontsize=99"`, "penwidth=3", `fontname="Sans"`, "fontsize=9", `fontcolor="blue"`}},
+		{"label position", CategoryConfig{LabelPosition: "bottom-right"},
+			[]string{"labelloc=b", "labeljust=r"}},
+	}
+	for _, tt := range tests {
+		got := clusterAttributes(tt.config)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: clusterAttributes() = %q, want %q", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: clusterAttributes() = %q, want %q", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
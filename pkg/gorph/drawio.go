@@ -0,0 +1,351 @@
+package gorph
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"gorph/v2/pkg/layout"
+)
+
+// drawioShapes maps resolved shapes to diagrams.net vertex styles.
+var drawioShapes = map[string]string{
+	"cylinder":      "shape=cylinder3;boundedLbl=1;size=8;",
+	"ellipse":       "ellipse;",
+	"oval":          "ellipse;",
+	"egg":           "ellipse;",
+	"circle":        "ellipse;aspect=fixed;",
+	"doublecircle":  "ellipse;shape=doubleEllipse;aspect=fixed;",
+	"diamond":       "rhombus;",
+	"hexagon":       "shape=hexagon;perimeter=hexagonPerimeter2;",
+	"parallelogram": "shape=parallelogram;perimeter=parallelogramPerimeter;",
+	"trapezium":     "shape=trapezoid;perimeter=trapezoidPerimeter;",
+	"cds":           "shape=cylinder3;boundedLbl=1;size=8;direction=south;",
+	"note":          "shape=note;size=12;",
+	"folder":        "shape=folder;tabWidth=40;tabHeight=12;",
+	"component":     "shape=component;",
+}
+
+// drawioStyleValue strips the separators of the mxGraph style syntax.
+var drawioStyleValue = strings.NewReplacer(";", "", "=", "")
+
+// draw.io Generator producing pre-arranged mxGraph files for diagrams.net
+type DrawIOGenerator struct {
+	style *StyleConfig
+	icons *iconResolver
+}
+
+func NewDrawIOGenerator(style *StyleConfig) *DrawIOGenerator {
+	return &DrawIOGenerator{style: style, icons: newIconResolver(style.Node.IconDir)}
+}
+
+// drawioCluster is the container of a category or namespace.
+type drawioCluster struct {
+	name   string
+	label  string
+	config CategoryConfig
+}
+
+func (g *DrawIOGenerator) Generate(infra *Infrastructure) string {
+	root := groupEntities(infra.Entities, g.style.CategoryOrder)
+
+	// Lay out with the same engine as the native SVG renderer
+	graph := layout.Graph{Direction: g.style.Graph.Direction, Parents: clusterParents(g.style, root)}
+	var clusters []drawioCluster
+	var entities []Entity
+	var entityClusters []string
+	root.walk(func(group *namespaceGroup) {
+		if group.Name != "" {
+			clusters = append(clusters, drawioCluster{
+				name:   namespaceCluster(group.Name),
+				label:  namespaceDisplayName(g.style, group.Name),
+				config: g.style.Namespaces[group.Name],
+			})
+		}
+		for _, category := range group.Categories {
+			cluster := categoryCluster(group.Name, category.Category)
+			graph.Groups = append(graph.Groups, cluster)
+			clusters = append(clusters, drawioCluster{
+				name:   cluster,
+				label:  categoryDisplayName(g.style, category.Category),
+				config: g.style.Categories[category.Category],
+			})
+			for _, entity := range category.Entities {
+				width, height := g.measureNode(entity)
+				graph.Nodes = append(graph.Nodes, layout.Node{
+					ID:     entity.ID,
+					Width:  width,
+					Height: height,
+					Group:  cluster,
+				})
+				entities = append(entities, entity)
+				entityClusters = append(entityClusters, cluster)
+			}
+		}
+	})
+	for _, conn := range infra.Connections {
+		graph.Edges = append(graph.Edges, layout.Edge{From: conn.From, To: conn.To})
+	}
+	res := layout.Layered(graph, layout.Options{})
+
+	var sb strings.Builder
+	sb.WriteString(`<mxfile host="gorph" type="device">` + "\n")
+	sb.WriteString(`  <diagram id="infrastructure" name="Infrastructure">` + "\n")
+	sb.WriteString(fmt.Sprintf(`    <mxGraphModel grid="1" gridSize="10" guides="1" tooltips="1" connect="1" arrows="1" fold="1" page="1" pageScale="1" pageWidth="%s" pageHeight="%s" math="0" shadow="0">`+"\n",
+		num(math.Ceil(res.Width)), num(math.Ceil(res.Height))))
+	sb.WriteString("      <root>\n")
+	sb.WriteString(`        <mxCell id="0"/>` + "\n")
+	sb.WriteString(`        <mxCell id="1" parent="0"/>` + "\n")
+
+	// Containers come first, outer before nested ones, so they are drawn
+	// behind their contents. Geometry is relative to the parent container.
+	containers := make(map[string]string)
+	origins := make(map[string]layout.Point)
+	// container returns the innermost container drawn around a cluster
+	container := func(cluster string) (string, layout.Point) {
+		for {
+			if id, ok := containers[cluster]; ok {
+				return id, origins[cluster]
+			}
+			parent, ok := graph.Parents[cluster]
+			if !ok {
+				return "1", layout.Point{}
+			}
+			cluster = parent
+		}
+	}
+	for _, cluster := range clusters {
+		box, ok := res.Groups[cluster.name]
+		if !ok || cluster.config.NoCluster {
+			continue
+		}
+		parent, origin := container(graph.Parents[cluster.name])
+		id := "cluster-" + cluster.name
+		containers[cluster.name] = id
+		origins[cluster.name] = layout.Point{X: box.X, Y: box.Y}
+		box.X -= origin.X
+		box.Y -= origin.Y
+		g.writeContainer(&sb, id, parent, cluster, box)
+	}
+
+	nodeIDs := make(map[string]string)
+	for i, entity := range entities {
+		parent, origin := container(entityClusters[i])
+		box := res.Nodes[i]
+		box.X -= origin.X
+		box.Y -= origin.Y
+		nodeIDs[entity.ID] = "node-" + entity.ID
+		g.writeEntity(&sb, nodeIDs[entity.ID], parent, entity, box)
+	}
+
+	for i, conn := range infra.Connections {
+		if len(res.Edges[i]) < 2 {
+			continue
+		}
+		g.writeEdge(&sb, i, conn, nodeIDs, res.Edges[i])
+	}
+
+	sb.WriteString("      </root>\n")
+	sb.WriteString("    </mxGraphModel>\n")
+	sb.WriteString("  </diagram>\n")
+	sb.WriteString("</mxfile>\n")
+	return sb.String()
+}
+
+// measureNode estimates the size of an entity's label in diagrams.net.
+func (g *DrawIOGenerator) measureNode(entity Entity) (float64, float64) {
+	desc := truncateRunes(entity.Description, g.style.Node.MaxDescriptionLength, g.style.Node.TruncationSuffix)
+	width := math.Max(textWidth(entityLabel(g.style, entity.ID), svgFontSize)*1.1, textWidth(desc, svgFontSize))
+	attrs := displayedAttributes(g.style, entity)
+	for _, attr := range attrs {
+		value := truncateRunes(attr.Value, g.style.Node.MaxDescriptionLength, g.style.Node.TruncationSuffix)
+		width = math.Max(width, textWidth(attr.Key+": "+value, svgSmallFontSize))
+	}
+	width = math.Max(width+2*svgCellPadding, svgMinNodeWidth)
+	height := svgRowHeight*float64(2+len(attrs)) + svgCellPadding
+	if g.icons.Resolve(entity.Icon) != "" {
+		height += g.iconSize() + svgCellPadding/2
+	}
+
+	switch resolveShape(g.style, entity.Shape).Shape {
+	case "", "box", "rectangle":
+	case "cylinder", "cds":
+		height += 2 * svgCylinderCap
+	case "ellipse", "oval", "egg", "circle", "doublecircle", "diamond":
+		width *= 1.42
+		height *= 1.42
+	default:
+		width += 2 * svgCellPadding
+		height += 2 * svgCellPadding
+	}
+	return width, height
+}
+
+func (g *DrawIOGenerator) iconSize() float64 {
+	if g.style.Node.IconSize > 0 {
+		return float64(g.style.Node.IconSize)
+	}
+	return 32
+}
+
+func (g *DrawIOGenerator) writeContainer(sb *strings.Builder, id, parent string, cluster drawioCluster, box layout.Rect) {
+	config := cluster.config
+
+	style := "rounded=0;whiteSpace=wrap;html=1;container=1;collapsible=0;recursiveResize=0;"
+	fill := "none"
+	if config.FillColor != "" {
+		fill = config.FillColor
+	}
+	style += "fillColor=" + drawioStyleValue.Replace(fill) + ";"
+	if config.BorderColor != "" {
+		style += "strokeColor=" + drawioStyleValue.Replace(config.BorderColor) + ";"
+	}
+	width := 1
+	if config.BorderWidth > 0 {
+		width = config.BorderWidth
+	}
+	for _, part := range strings.Split(config.ClusterStyle, ",") {
+		switch strings.TrimSpace(part) {
+		case "rounded":
+			style += "rounded=1;arcSize=4;"
+		case "dashed":
+			style += "dashed=1;"
+		case "dotted":
+			style += "dashed=1;dashPattern=1 4;"
+		case "bold":
+			width *= 2
+		case "invis":
+			style += "strokeColor=none;"
+		}
+	}
+	style += fmt.Sprintf("strokeWidth=%d;", width)
+
+	// Label position defaults to top-center like Graphviz
+	loc, just := labelPosition(config.LabelPosition)
+	if loc == "b" {
+		style += "verticalAlign=bottom;"
+	} else {
+		style += "verticalAlign=top;"
+	}
+	switch just {
+	case "l":
+		style += "align=left;spacingLeft=8;"
+	case "r":
+		style += "align=right;spacingRight=8;"
+	}
+	style += g.fontStyle(config.FontName, config.FontSize, config.FontColor)
+
+	sb.WriteString(fmt.Sprintf(`        <mxCell id="%s" value="%s" style="%s" vertex="1" parent="%s">`+"\n",
+		escapeXML(id), escapeXML(escapeHTML(cluster.label)), escapeXML(style), escapeXML(parent)))
+	sb.WriteString(g.geometry(box))
+	sb.WriteString("        </mxCell>\n")
+}
+
+func (g *DrawIOGenerator) fontStyle(name string, size int, color string) string {
+	if name == "" {
+		name = g.style.Graph.FontFamily
+	}
+	var style string
+	if name != "" {
+		style += "fontFamily=" + drawioStyleValue.Replace(name) + ";"
+	}
+	if size > 0 {
+		style += fmt.Sprintf("fontSize=%d;", size)
+	}
+	if color != "" {
+		style += "fontColor=" + drawioStyleValue.Replace(color) + ";"
+	}
+	return style
+}
+
+// writeEntity writes an entity as an object cell, so the tooltip survives
+// editing in diagrams.net. The status color is drawn as the outline.
+func (g *DrawIOGenerator) writeEntity(sb *strings.Builder, id, parent string, entity Entity, box layout.Rect) {
+	var label strings.Builder
+	if icon := g.icons.Resolve(entity.Icon); icon != "" {
+		if src := imageDataURI(icon); src != "" {
+			size := g.iconSize()
+			label.WriteString(fmt.Sprintf(`<img src="%s" width="%s" height="%s"><br>`, src, num(size), num(size)))
+		}
+	}
+	label.WriteString(fmt.Sprintf("<b>%s</b><br>%s", escapeHTML(entityLabel(g.style, entity.ID)),
+		escapeHTML(truncateRunes(entity.Description, g.style.Node.MaxDescriptionLength, g.style.Node.TruncationSuffix))))
+	for _, attr := range displayedAttributes(g.style, entity) {
+		label.WriteString(fmt.Sprintf("<br><i>%s</i>: %s", escapeHTML(attr.Key),
+			escapeHTML(truncateRunes(attr.Value, g.style.Node.MaxDescriptionLength, g.style.Node.TruncationSuffix))))
+	}
+
+	shape := resolveShape(g.style, entity.Shape)
+	style := drawioShapes[shape.Shape]
+	if shape.Rounded {
+		style += "rounded=1;"
+	}
+	style += "whiteSpace=wrap;html=1;fillColor=white;strokeWidth=3;"
+	if color := statusColor(g.style, entity.Status); color != "" {
+		style += "strokeColor=" + drawioStyleValue.Replace(color) + ";"
+	}
+	style += g.fontStyle("", 0, "")
+
+	sb.WriteString(fmt.Sprintf(`        <object id="%s" label="%s" tooltip="%s" category="%s" status="%s">`+"\n",
+		escapeXML(id), escapeXML(label.String()), escapeXMLAttr(generateTooltip(g.style, entity)),
+		escapeXML(entity.Category), escapeXML(entity.Status)))
+	sb.WriteString(fmt.Sprintf(`          <mxCell style="%s" vertex="1" parent="%s">`+"\n", escapeXML(style), escapeXML(parent)))
+	sb.WriteString("  " + g.geometry(box))
+	sb.WriteString("          </mxCell>\n")
+	sb.WriteString("        </object>\n")
+}
+
+// writeEdge writes a connection with the bends of the computed route as
+// waypoints. The end points are left to diagrams.net so edges stay attached.
+func (g *DrawIOGenerator) writeEdge(sb *strings.Builder, index int, conn Connection, nodeIDs map[string]string, points []layout.Point) {
+	style := "endArrow=classic;html=1;rounded=1;edgeStyle=none;"
+	if connStyle, ok := g.style.ConnectionStyles[conn.Type]; ok {
+		if connStyle.Color != "" {
+			style += "strokeColor=" + drawioStyleValue.Replace(connStyle.Color) + ";"
+			style += "fontColor=" + drawioStyleValue.Replace(connStyle.Color) + ";"
+		}
+		switch connStyle.Style {
+		case "dashed":
+			style += "dashed=1;"
+		case "dotted":
+			style += "dashed=1;dashPattern=1 4;"
+		case "bold":
+			style += "strokeWidth=3;"
+		}
+	}
+	style += g.fontStyle("", 0, "")
+
+	var endpoints string
+	if source, ok := nodeIDs[conn.From]; ok {
+		endpoints += fmt.Sprintf(` source="%s"`, escapeXML(source))
+	}
+	if target, ok := nodeIDs[conn.To]; ok {
+		endpoints += fmt.Sprintf(` target="%s"`, escapeXML(target))
+	}
+
+	sb.WriteString(fmt.Sprintf(`        <mxCell id="%s" value="%s" style="%s" edge="1" parent="1"%s>`+"\n",
+		fmt.Sprintf("edge-%d", index), escapeXML(escapeHTML(conn.Type)), escapeXML(style), endpoints))
+	if len(points) > 2 {
+		sb.WriteString(`          <mxGeometry relative="1" as="geometry">` + "\n")
+		sb.WriteString(`            <Array as="points">` + "\n")
+		for _, p := range points[1 : len(points)-1] {
+			sb.WriteString(fmt.Sprintf(`              <mxPoint x="%s" y="%s"/>`+"\n", num(p.X), num(p.Y)))
+		}
+		sb.WriteString("            </Array>\n")
+		sb.WriteString("          </mxGeometry>\n")
+	} else {
+		sb.WriteString(`          <mxGeometry relative="1" as="geometry"/>` + "\n")
+	}
+	sb.WriteString("        </mxCell>\n")
+}
+
+// escapeXMLAttr escapes text for an attribute value, keeping line breaks
+// that XML parsers would otherwise normalize to spaces.
+func escapeXMLAttr(s string) string {
+	return strings.ReplaceAll(escapeXML(s), "\n", "&#10;")
+}
+
+func (g *DrawIOGenerator) geometry(box layout.Rect) string {
+	return fmt.Sprintf(`          <mxGeometry x="%s" y="%s" width="%s" height="%s" as="geometry"/>`+"\n",
+		num(box.X), num(box.Y), num(box.Width), num(box.Height))
+}
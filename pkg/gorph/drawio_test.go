@@ -0,0 +1,55 @@
+package gorph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDrawIOGolden(t *testing.T) {
+	for _, name := range examples {
+		t.Run(name, func(t *testing.T) {
+			out := NewDrawIOGenerator(DefaultStyle()).Generate(loadExample(t, name))
+			checkXML(t, out)
+			checkGolden(t, name+".drawio", out)
+		})
+	}
+}
+
+func TestDrawIOEscaping(t *testing.T) {
+	infra := &Infrastructure{
+		Entities: []Entity{
+			{ID: `A&"B"`, Category: "BACKEND", Description: "<i>x</i>", Status: "healthy"},
+			{ID: "DB", Category: "DATABASE", Description: "line\nbreak", Status: "healthy"},
+		},
+		Connections: []Connection{{From: `A&"B"`, To: "DB", Type: `reads <all> & "more"`}},
+	}
+	style := DefaultStyle()
+	style.Graph.FontFamily = "Helvetica;fillColor=red"
+	style.Categories["BACKEND"] = CategoryConfig{DisplayName: "R&D <core>"}
+	out := NewDrawIOGenerator(style).Generate(infra)
+	checkXML(t, out)
+
+	for _, want := range []string{
+		// Cell IDs and labels are XML-escaped; labels are HTML within that
+		`<object id="node-A&amp;&quot;B&quot;" label="&lt;b&gt;A&amp;amp;&amp;quot;B&amp;quot;&lt;/b&gt;&lt;br&gt;&amp;lt;i&amp;gt;x&amp;lt;/i&amp;gt;"`,
+		`source="node-A&amp;&quot;B&quot;" target="node-DB"`,
+		// Edge and container values are HTML too
+		`value="reads &amp;lt;all&amp;gt; &amp;amp; &amp;quot;more&amp;quot;"`,
+		`value="R&amp;amp;D &amp;lt;core&amp;gt;"`,
+		// Style values cannot add properties of their own
+		"fontFamily=HelveticafillColorred;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<i>") {
+		t.Errorf("output contains unescaped markup:\n%s", out)
+	}
+}
+
+func TestDrawIOTooltipLineBreaks(t *testing.T) {
+	if got, want := escapeXMLAttr("a <b>\nc"), "a &lt;b&gt;&#10;c"; got != want {
+		t.Errorf("escapeXMLAttr() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+package gorph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DOT quoting helpers shared by every generator that emits Graphviz source.
+// IDs that are not plain identifiers are written as quoted strings, so
+// distinct entity IDs such as "a-b" and "a_b" stay distinct nodes and no
+// lossy sanitizing is required.
+
+var dotStringEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+var htmlEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+	"'", "&#39;",
+	"\n", "<BR/>",
+)
+
+// dotKeywords cannot be used as bare identifiers; DOT matches them
+// case-insensitively.
+var dotKeywords = map[string]bool{
+	"node": true, "edge": true, "graph": true,
+	"digraph": true, "subgraph": true, "strict": true,
+}
+
+var xmlEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+	"'", "&#39;",
+)
+
+// escapeXML escapes s for use as XML text or attribute value in SVG and
+// draw.io output.
+func escapeXML(s string) string {
+	return xmlEscaper.Replace(s)
+}
+
+// quoteDOTID returns id as a DOT identifier, quoting it unless it is a
+// plain alphanumeric identifier.
+func quoteDOTID(id string) string {
+	if isPlainDOTID(id) {
+		return id
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(id) + `"`
+}
+
+func isPlainDOTID(id string) bool {
+	if id == "" || dotKeywords[strings.ToLower(id)] {
+		return false
+	}
+	for i, c := range id {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// quoteDOTString returns s as a quoted DOT attribute value. Newlines become
+// the "\n" escape Graphviz renders as a centered line break.
+func quoteDOTString(s string) string {
+	return `"` + dotStringEscaper.Replace(s) + `"`
+}
+
+// escapeHTML escapes s for use as text or an attribute value inside a DOT
+// HTML-like label.
+func escapeHTML(s string) string {
+	return htmlEscaper.Replace(s)
+}
+
+// idMapper assigns every name a stable identifier made of letters, digits
+// and underscores, for output formats that cannot quote identifiers. Names
+// that sanitize to the same identifier get numeric suffixes, so the mapping
+// stays one-to-one; register names in source order for stable output.
+type idMapper struct {
+	reserved map[string]bool
+	ids      map[string]string
+	used     map[string]bool
+}
+
+// newIDMapper returns a mapper that never produces the given reserved
+// words, compared case-insensitively.
+func newIDMapper(reserved ...string) *idMapper {
+	m := &idMapper{
+		reserved: make(map[string]bool),
+		ids:      make(map[string]string),
+		used:     make(map[string]bool),
+	}
+	for _, word := range reserved {
+		m.reserved[strings.ToLower(word)] = true
+	}
+	return m
+}
+
+// ID returns the identifier for name, assigning one on first use.
+func (m *idMapper) ID(name string) string {
+	if id, ok := m.ids[name]; ok {
+		return id
+	}
+
+	var sb strings.Builder
+	for _, c := range name {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	base := sb.String()
+	if base == "" || (base[0] >= '0' && base[0] <= '9') || m.reserved[strings.ToLower(base)] {
+		base = "n_" + base
+	}
+
+	id := base
+	for i := 2; m.used[id]; i++ {
+		id = fmt.Sprintf("%s_%d", base, i)
+	}
+	m.used[id] = true
+	m.ids[name] = id
+	return id
+}
@@ -0,0 +1,76 @@
+package gorph
+
+import "testing"
+
+func TestQuoteDOTID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"WebServer", "WebServer"},
+		{"web_server2", "web_server2"},
+		{"a-b", `"a-b"`},
+		{"2fa", `"2fa"`},
+		{"payments/Database", `"payments/Database"`},
+		{"node", `"node"`},
+		{"Subgraph", `"Subgraph"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`back\slash`, `"back\\slash"`},
+		{"", `""`},
+	}
+	for _, tt := range tests {
+		if got := quoteDOTID(tt.id); got != tt.want {
+			t.Errorf("quoteDOTID(%q) = %s, want %s", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteDOTString(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"Database", `"Database"`},
+		{`a "quoted" word`, `"a \"quoted\" word"`},
+		{`C:\path`, `"C:\\path"`},
+		{"two\nlines", `"two\nlines"`},
+		{"crlf\r\nline", `"crlf\nline"`},
+	}
+	for _, tt := range tests {
+		if got := quoteDOTString(tt.s); got != tt.want {
+			t.Errorf("quoteDOTString(%q) = %s, want %s", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeHTML(t *testing.T) {
+	got := escapeHTML(`<b>"R&D"</b> it's` + "\nnext")
+	want := "&lt;b&gt;&quot;R&amp;D&quot;&lt;/b&gt; it&#39;s<BR/>next"
+	if got != want {
+		t.Errorf("escapeHTML = %s, want %s", got, want)
+	}
+}
+
+func TestIDMapper(t *testing.T) {
+	m := newIDMapper("end")
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"WebServer", "WebServer"},
+		{"a-b", "a_b"},
+		{"a_b", "a_b_2"},
+		{"a b", "a_b_3"},
+		{"2fa", "n_2fa"},
+		{"End", "n_End"},
+		{"payments/Database", "payments_Database"},
+		{"", "n_"},
+		// Names keep the identifier they were first given
+		{"a-b", "a_b"},
+	}
+	for _, tt := range tests {
+		if got := m.ID(tt.name); got != tt.want {
+			t.Errorf("ID(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
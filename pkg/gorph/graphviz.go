@@ -0,0 +1,120 @@
+package gorph
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ErrGraphvizNotFound is returned when the Graphviz dot command is not
+// installed.
+var ErrGraphvizNotFound = errors.New("Graphviz 'dot' command not found")
+
+// ErrOutputTooLarge is returned when Graphviz output exceeds
+// GraphvizLimits.MaxOutputBytes.
+var ErrOutputTooLarge = errors.New("Graphviz output too large")
+
+// GraphvizLimits bounds the resources a Graphviz run may use. Zero fields
+// take their value from DefaultGraphvizLimits.
+type GraphvizLimits struct {
+	// Timeout stops dot after this long; the context may end it sooner
+	Timeout time.Duration
+	// MaxOutputBytes stops dot once it has written this much
+	MaxOutputBytes int64
+	// MaxMemoryBytes caps the address space of dot (Linux only)
+	MaxMemoryBytes uint64
+}
+
+// DefaultGraphvizLimits are generous enough for any hand-written
+// infrastructure while stopping runaway layouts.
+var DefaultGraphvizLimits = GraphvizLimits{
+	Timeout:        30 * time.Second,
+	MaxOutputBytes: 64 << 20,
+	MaxMemoryBytes: 2 << 30,
+}
+
+// maxStderr bounds the dot diagnostics kept for error messages.
+const maxStderr = 16 << 10
+
+func (l GraphvizLimits) withDefaults() GraphvizLimits {
+	if l.Timeout <= 0 {
+		l.Timeout = DefaultGraphvizLimits.Timeout
+	}
+	if l.MaxOutputBytes <= 0 {
+		l.MaxOutputBytes = DefaultGraphvizLimits.MaxOutputBytes
+	}
+	if l.MaxMemoryBytes == 0 {
+		l.MaxMemoryBytes = DefaultGraphvizLimits.MaxMemoryBytes
+	}
+	return l
+}
+
+// RenderGraphviz renders DOT source to the given Graphviz output format
+// (png, svg, pdf, ...) with the dot command and the default limits.
+func RenderGraphviz(dotContent string, format string) ([]byte, error) {
+	return RunGraphviz(context.Background(), dotContent, format, DefaultGraphvizLimits)
+}
+
+// RunGraphviz renders DOT source with the dot command, stopping it when ctx
+// ends or a limit is exceeded. A timeout is reported as
+// context.DeadlineExceeded.
+func RunGraphviz(ctx context.Context, dotContent string, format string, limits GraphvizLimits) ([]byte, error) {
+	dot, err := exec.LookPath("dot")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGraphvizNotFound, err)
+	}
+	limits = limits.withDefaults()
+
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	stdout := &cappedBuffer{limit: limits.MaxOutputBytes, overflow: cancel}
+	stderr := &cappedBuffer{limit: maxStderr}
+	cmd := graphvizCommand(ctx, dot, limits.MaxMemoryBytes, "-T"+format)
+	cmd.Stdin = strings.NewReader(dotContent)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("running dot command: %w", err)
+	}
+	err = cmd.Wait()
+
+	switch {
+	case stdout.overflowed:
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrOutputTooLarge, limits.MaxOutputBytes)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return nil, fmt.Errorf("dot command did not finish within %v: %w", limits.Timeout, context.DeadlineExceeded)
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case err != nil:
+		return nil, fmt.Errorf("running dot command: %w\nOutput: %s", err, stderr.buf.String())
+	}
+	return stdout.buf.Bytes(), nil
+}
+
+// cappedBuffer keeps the first limit bytes written to it. Beyond that it
+// calls overflow, if set, and drops the rest.
+type cappedBuffer struct {
+	buf        bytes.Buffer
+	limit      int64
+	overflow   func()
+	overflowed bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - int64(b.buf.Len()); int64(len(p)) > room {
+		b.buf.Write(p[:max(room, 0)])
+		if !b.overflowed && b.overflow != nil {
+			b.overflow()
+		}
+		b.overflowed = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
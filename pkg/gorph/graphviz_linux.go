@@ -0,0 +1,16 @@
+package gorph
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+)
+
+// graphvizCommand runs dot through the shell, which caps its own address
+// space and then replaces itself with dot, so the limit holds from dot's
+// first instruction. If the limit cannot be set the shell exits with the
+// reason on stderr, and dot does not run.
+func graphvizCommand(ctx context.Context, dot string, maxMemory uint64, args ...string) *exec.Cmd {
+	script := `ulimit -v ` + strconv.FormatUint(max(maxMemory>>10, 1), 10) + ` && exec "$0" "$@"`
+	return exec.CommandContext(ctx, "/bin/sh", append([]string{"-c", script, dot}, args...)...)
+}
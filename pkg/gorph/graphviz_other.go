@@ -0,0 +1,14 @@
+//go:build !linux
+
+package gorph
+
+import (
+	"context"
+	"os/exec"
+)
+
+// graphvizCommand runs dot directly; its memory is not limited outside
+// Linux.
+func graphvizCommand(ctx context.Context, dot string, maxMemory uint64, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, dot, args...)
+}
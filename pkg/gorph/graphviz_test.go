@@ -0,0 +1,114 @@
+package gorph
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDot puts a shell script first on PATH as the dot command.
+func fakeDot(t *testing.T, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "dot"), []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestRunGraphviz(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		limits GraphvizLimits
+		want   string
+		err    error  // wrapped by the error, if any
+		errMsg string // contained in the error, if any
+	}{
+		{"output", `read line; echo "$1 $line"`, GraphvizLimits{}, "-Tsvg digraph {}\n", nil, ""},
+		{"too large", `echo 0123456789`, GraphvizLimits{MaxOutputBytes: 4}, "", ErrOutputTooLarge, ""},
+		{"timeout", `exec sleep 10`, GraphvizLimits{Timeout: 50 * time.Millisecond}, "", context.DeadlineExceeded, ""},
+		{"failure", `echo "syntax error" >&2; exit 1`, GraphvizLimits{}, "", nil, "syntax error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeDot(t, tt.script)
+			out, err := RunGraphviz(context.Background(), "digraph {}\n", FormatSVG, tt.limits)
+			switch {
+			case tt.errMsg != "":
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("err = %v, want one containing %q", err, tt.errMsg)
+				}
+			case tt.err != nil:
+				if !errors.Is(err, tt.err) {
+					t.Errorf("err = %v, want %v", err, tt.err)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case string(out) != tt.want:
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunGraphvizNotFound(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	if _, err := RenderGraphviz("digraph {}", FormatPNG); !errors.Is(err, ErrGraphvizNotFound) {
+		t.Errorf("err = %v, want %v", err, ErrGraphvizNotFound)
+	}
+}
+
+func TestRunGraphvizMemoryLimit(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("memory is only limited on Linux")
+	}
+	// The limit is in place before dot runs, so dot sees it at once
+	fakeDot(t, `ulimit -v`)
+	out, err := RunGraphviz(context.Background(), "digraph {}", FormatSVG, GraphvizLimits{MaxMemoryBytes: 1 << 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimSpace(string(out)), "1048576"; got != want {
+		t.Errorf("dot ran with a limit of %s KiB, want %s", got, want)
+	}
+}
+
+func TestRunGraphvizCanceled(t *testing.T) {
+	fakeDot(t, `exec sleep 10`)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := RunGraphviz(ctx, "digraph {}", FormatSVG, GraphvizLimits{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	overflows := 0
+	b := &cappedBuffer{limit: 5, overflow: func() { overflows++ }}
+	for _, s := range []string{"abc", "def", "ghi"} {
+		if n, err := b.Write([]byte(s)); n != len(s) || err != nil {
+			t.Errorf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	if got := b.buf.String(); got != "abcde" || !b.overflowed || overflows != 1 {
+		t.Errorf("buffer = %q, overflowed %v, %d overflow calls; want \"abcde\", true, 1", got, b.overflowed, overflows)
+	}
+}
+
+func TestGraphvizLimitsDefaults(t *testing.T) {
+	got := GraphvizLimits{Timeout: time.Second}.withDefaults()
+	want := DefaultGraphvizLimits
+	want.Timeout = time.Second
+	if got != want {
+		t.Errorf("limits = %+v, want %+v", got, want)
+	}
+}
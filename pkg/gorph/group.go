@@ -0,0 +1,131 @@
+package gorph
+
+// categoryGroup is the set of entities rendered together in one cluster.
+type categoryGroup struct {
+	Category string
+	Entities []Entity
+}
+
+// groupEntitiesByCategory groups entities by category in a stable order:
+// categories listed in order come first, in that order, followed by the
+// remaining categories in order of first appearance. Entities keep their
+// source order within each group.
+func groupEntitiesByCategory(entities []Entity, order []string) []categoryGroup {
+	index := make(map[string]int)
+	var groups []categoryGroup
+
+	for _, category := range order {
+		if _, seen := index[category]; seen {
+			continue
+		}
+		index[category] = len(groups)
+		groups = append(groups, categoryGroup{Category: category})
+	}
+
+	for _, e := range entities {
+		i, seen := index[e.Category]
+		if !seen {
+			i = len(groups)
+			index[e.Category] = i
+			groups = append(groups, categoryGroup{Category: e.Category})
+		}
+		groups[i].Entities = append(groups[i].Entities, e)
+	}
+
+	// Drop configured categories that have no entities
+	result := groups[:0]
+	for _, group := range groups {
+		if len(group.Entities) > 0 {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// namespaceGroup holds the entities of a namespace: the category groups of
+// those declared directly in it, and its nested namespaces. The root group,
+// with an empty Name, holds the entities without a namespace.
+type namespaceGroup struct {
+	Name       string
+	Categories []categoryGroup
+	Namespaces []*namespaceGroup
+}
+
+// groupEntities groups entities by namespace, in order of first appearance,
+// and within each namespace by category like groupEntitiesByCategory.
+// Definitions without namespaces give a root group of categories only.
+func groupEntities(entities []Entity, order []string) *namespaceGroup {
+	root := &namespaceGroup{}
+	groups := map[string]*namespaceGroup{"": root}
+	members := make(map[*namespaceGroup][]Entity)
+
+	var lookup func(name string) *namespaceGroup
+	lookup = func(name string) *namespaceGroup {
+		if group, ok := groups[name]; ok {
+			return group
+		}
+		group := &namespaceGroup{Name: name}
+		groups[name] = group
+		parent := lookup(entityNamespace(name))
+		parent.Namespaces = append(parent.Namespaces, group)
+		return group
+	}
+
+	for _, e := range entities {
+		group := lookup(entityNamespace(e.ID))
+		members[group] = append(members[group], e)
+	}
+	for group, entities := range members {
+		group.Categories = groupEntitiesByCategory(entities, order)
+	}
+	return root
+}
+
+// walk calls fn for g and its nested namespaces, parents first.
+func (g *namespaceGroup) walk(fn func(*namespaceGroup)) {
+	fn(g)
+	for _, nested := range g.Namespaces {
+		nested.walk(fn)
+	}
+}
+
+// categoryCluster names the cluster of a category within a namespace; root
+// categories keep their plain name.
+func categoryCluster(namespace, category string) string {
+	if namespace == "" {
+		return category
+	}
+	return namespace + "/" + category
+}
+
+// namespaceCluster names the cluster of a namespace. The trailing slash
+// keeps it apart from category clusters.
+func namespaceCluster(namespace string) string {
+	return namespace + "/"
+}
+
+// clusterParents maps the clusters of the categories and namespaces of root
+// to the namespace clusters drawn around them, for layouts.
+func clusterParents(style *StyleConfig, root *namespaceGroup) map[string]string {
+	parents := make(map[string]string)
+	root.walk(func(group *namespaceGroup) {
+		// The innermost enclosing namespace drawn as a cluster
+		enclosing := ""
+		for ns := group.Name; ns != ""; ns = entityNamespace(ns) {
+			if !style.Namespaces[ns].NoCluster {
+				enclosing = namespaceCluster(ns)
+				break
+			}
+		}
+		if enclosing == "" {
+			return
+		}
+		for _, category := range group.Categories {
+			parents[categoryCluster(group.Name, category.Category)] = enclosing
+		}
+		for _, nested := range group.Namespaces {
+			parents[namespaceCluster(nested.Name)] = enclosing
+		}
+	})
+	return parents
+}
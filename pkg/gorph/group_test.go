@@ -0,0 +1,88 @@
+package gorph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupEntitiesByCategory(t *testing.T) {
+	entities := []Entity{
+		{ID: "Cache", Category: "DATABASE"},
+		{ID: "API", Category: "BACKEND"},
+		{ID: "Queue", Category: "MESSAGING"},
+		{ID: "Store", Category: "DATABASE"},
+		{ID: "Web", Category: "FRONTEND"},
+	}
+	tests := []struct {
+		name  string
+		order []string
+		keys  []string
+	}{
+		{
+			name: "appearance order without configured order",
+			keys: []string{"DATABASE", "BACKEND", "MESSAGING", "FRONTEND"},
+		},
+		{
+			name:  "configured order first, unused categories dropped",
+			order: []string{"FRONTEND", "NETWORK", "BACKEND", "DATABASE"},
+			keys:  []string{"FRONTEND", "BACKEND", "DATABASE", "MESSAGING"},
+		},
+		{
+			name:  "duplicates in configured order are ignored",
+			order: []string{"BACKEND", "BACKEND", "DATABASE"},
+			keys:  []string{"BACKEND", "DATABASE", "MESSAGING", "FRONTEND"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := groupEntitiesByCategory(entities, tt.order)
+			var keys []string
+			members := make(map[string][]string)
+			for _, group := range groups {
+				keys = append(keys, group.Category)
+				for _, e := range group.Entities {
+					members[group.Category] = append(members[group.Category], e.ID)
+				}
+			}
+			if !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("categories = %v, want %v", keys, tt.keys)
+			}
+			// Entities keep their source order within a category
+			if got := members["DATABASE"]; !reflect.DeepEqual(got, []string{"Cache", "Store"}) {
+				t.Errorf("DATABASE entities = %v, want [Cache Store]", got)
+			}
+		})
+	}
+}
+
+func TestGroupEntitiesByNamespace(t *testing.T) {
+	entities := []Entity{
+		{ID: "search/API", Category: "BACKEND"},
+		{ID: "Gateway", Category: "NETWORK"},
+		{ID: "payments/ledger/Store", Category: "DATABASE"},
+		{ID: "payments/API", Category: "BACKEND"},
+	}
+	root := groupEntities(entities, nil)
+
+	var visited []string
+	root.walk(func(group *namespaceGroup) {
+		visited = append(visited, group.Name)
+	})
+	want := []string{"", "search", "payments", "payments/ledger"}
+	if !reflect.DeepEqual(visited, want) {
+		t.Errorf("namespaces = %v, want %v", visited, want)
+	}
+	if len(root.Categories) != 1 || root.Categories[0].Category != "NETWORK" {
+		t.Errorf("root categories = %+v, want only NETWORK", root.Categories)
+	}
+
+	style := &StyleConfig{Namespaces: map[string]CategoryConfig{"payments": {NoCluster: true}}}
+	parents := clusterParents(style, root)
+	wantParents := map[string]string{
+		"search/BACKEND":           "search/",
+		"payments/ledger/DATABASE": "payments/ledger/",
+	}
+	if !reflect.DeepEqual(parents, wantParents) {
+		t.Errorf("clusterParents = %v, want %v", parents, wantParents)
+	}
+}
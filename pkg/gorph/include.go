@@ -0,0 +1,169 @@
+package gorph
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// resolveIncludes merges the files listed by infra.Include, and the files
+// they include in turn, into infra, which was loaded from path. Includes are
+// paths or glob patterns relative to the including file; each file is merged
+// once, so files may include each other. Only entities and connections are
+// merged: the name, description and admins are those of the root file.
+func resolveIncludes(infra *Infrastructure, path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{abs: true}
+	return mergeIncludes(infra, infra, path, seen)
+}
+
+// IncludedFiles returns the files LoadInfrastructure merged into the
+// definition, in the order they were merged.
+func (infra *Infrastructure) IncludedFiles() []string {
+	return infra.included
+}
+
+func mergeIncludes(dst, src *Infrastructure, path string, seen map[string]bool) error {
+	includes := src.Include
+	src.Include = nil
+
+	var errs ValidationErrors
+	for i, pattern := range includes {
+		field := "include." + strconv.Itoa(i)
+		files, err := expandInclude(filepath.Dir(path), pattern)
+		if err != nil {
+			errs = append(errs, ValidationError{
+				Pos:     src.source.lookup(field),
+				Field:   field,
+				Message: fmt.Sprintf("Include %s: %v", pattern, err),
+			})
+			continue
+		}
+
+		for _, file := range files {
+			abs, err := filepath.Abs(file)
+			if err != nil {
+				return err
+			}
+			if seen[abs] {
+				continue
+			}
+			seen[abs] = true
+			dst.included = append(dst.included, file)
+
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("reading included file: %w", err)
+			}
+			included, err := parseInfrastructure(file, data, detectInput(file, data))
+			if err == nil {
+				dst.merge(included)
+				err = mergeIncludes(dst, included, file, seen)
+			}
+			// Errors with positions are collected, so that problems
+			// in several files are reported together
+			var fileErrs ValidationErrors
+			if errors.As(err, &fileErrs) {
+				errs = append(errs, fileErrs...)
+			} else if err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// expandInclude returns the files an include names, in lexical order.
+// Patterns use the syntax of filepath.Match; directories are skipped.
+func expandInclude(dir, pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			files = append(files, match)
+		}
+	}
+	if len(files) == 0 {
+		if strings.ContainsAny(pattern, "*?[") {
+			return nil, errors.New("no files match")
+		}
+		return nil, errors.New("file not found")
+	}
+	return files, nil
+}
+
+// merge appends the entities and connections of an included definition,
+// keeping the positions, schema errors and namespaces of the included file.
+func (infra *Infrastructure) merge(included *Infrastructure) {
+	entities, connections := len(infra.Entities), len(infra.Connections)
+	infra.Entities = append(infra.Entities, included.Entities...)
+	infra.Connections = append(infra.Connections, included.Connections...)
+	infra.namespaces = append(infra.namespaces, included.namespaces...)
+
+	if infra.source != nil && included.source != nil {
+		for path, pos := range included.source.positions {
+			if strings.HasPrefix(path, "entities.") || strings.HasPrefix(path, "connections.") {
+				infra.source.positions[shiftPath(path, entities, connections)] = pos
+			}
+		}
+		if infra.source.indices == nil {
+			infra.source.indices = make(map[string]int)
+		}
+		for i := range included.Entities {
+			infra.source.indices["entities."+strconv.Itoa(entities+i)] = i
+		}
+		for i := range included.Connections {
+			infra.source.indices["connections."+strconv.Itoa(connections+i)] = i
+		}
+	}
+	for _, err := range included.schemaErrors {
+		err.Field = shiftPath(err.Field, entities, connections)
+		infra.schemaErrors = append(infra.schemaErrors, err)
+	}
+	for _, warning := range included.warnings {
+		warning.Field = shiftPath(warning.Field, entities, connections)
+		infra.warnings = append(infra.warnings, warning)
+	}
+}
+
+// shiftPath renumbers a path such as "entities.3.id" for a definition whose
+// entities and connections were appended after the given numbers of others.
+func shiftPath(path string, entities, connections int) string {
+	key, rest, _ := strings.Cut(path, ".")
+	offset := 0
+	switch key {
+	case "entities":
+		offset = entities
+	case "connections":
+		offset = connections
+	default:
+		return path
+	}
+
+	index, rest, nested := strings.Cut(rest, ".")
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return path
+	}
+	path = key + "." + strconv.Itoa(i+offset)
+	if nested {
+		path += "." + rest
+	}
+	return path
+}
@@ -0,0 +1,113 @@
+package gorph
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func includeFixture(parts ...string) string {
+	return filepath.Join(append([]string{"testdata", "include"}, parts...)...)
+}
+
+func entityIDs(infra *Infrastructure) []string {
+	var ids []string
+	for _, e := range infra.Entities {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestIncludeGlob(t *testing.T) {
+	infra, err := LoadInfrastructure(includeFixture("glob", "main.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := Validate(infra); len(errs) > 0 {
+		t.Fatalf("Validate() = %v", errs)
+	}
+
+	// Matches are merged in lexical order, each once, after the root file
+	if got, want := entityIDs(infra), []string{"Gateway", "Payments", "Search"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("entities = %v, want %v", got, want)
+	}
+	want := []string{includeFixture("glob", "teams", "a.yml"), includeFixture("glob", "teams", "b.yml")}
+	if got := infra.IncludedFiles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("IncludedFiles() = %v, want %v", got, want)
+	}
+	if len(infra.Connections) != 2 {
+		t.Errorf("got %d connections, want 2", len(infra.Connections))
+	}
+	// Only entities and connections are merged
+	if infra.Name != "Platform" || infra.Include != nil {
+		t.Errorf("name = %q, include = %v, want the root's name and no includes", infra.Name, infra.Include)
+	}
+}
+
+func TestIncludeCycle(t *testing.T) {
+	infra, err := LoadInfrastructure(includeFixture("cycle", "a.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := Validate(infra); len(errs) > 0 {
+		t.Fatalf("Validate() = %v", errs)
+	}
+	if got, want := entityIDs(infra), []string{"A", "B"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("entities = %v, want %v", got, want)
+	}
+	if got, want := infra.IncludedFiles(), []string{includeFixture("cycle", "b.yml")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("IncludedFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestIncludeMissing(t *testing.T) {
+	_, err := LoadInfrastructure(includeFixture("missing", "main.yml"))
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("err = %v, want validation errors", err)
+	}
+	file := includeFixture("missing", "main.yml")
+	want := []string{
+		file + ":2:5: Include nope.yml: file not found",
+		file + ":3:5: Include none/*.yml: no files match",
+		file + ":4:5: Include [bad: syntax error in pattern",
+	}
+	if got := errs.Strings(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("errors = %q, want %q", got, want)
+	}
+}
+
+func TestIncludeDuplicateIDs(t *testing.T) {
+	infra, err := LoadInfrastructure(includeFixture("duplicate", "main.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := Validate(infra)
+	want := []string{
+		includeFixture("duplicate", "team.yml") + ":6:9: Duplicate entity ID: API (first declared at " +
+			includeFixture("duplicate", "main.yml") + ":3:9)",
+	}
+	if got := errs.Strings(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Validate() = %q, want %q", got, want)
+	}
+	if len(errs) == 1 && errs[0].Field != "entities.2.id" {
+		t.Errorf("field = %q, want entities.2.id", errs[0].Field)
+	}
+}
+
+func TestShiftPath(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"entities.0.id", "entities.3.id"},
+		{"entities.2", "entities.5"},
+		{"connections.1.from", "connections.11.from"},
+		{"name", "name"},
+		{"entities.x.id", "entities.x.id"},
+	}
+	for _, tt := range tests {
+		if got := shiftPath(tt.path, 3, 10); got != tt.want {
+			t.Errorf("shiftPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
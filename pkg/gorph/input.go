@@ -0,0 +1,208 @@
+package gorph
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Input formats of infrastructure definitions.
+const (
+	InputYAML = "yaml"
+	InputJSON = "json"
+	InputTOML = "toml"
+)
+
+// ReadInfrastructure reads an infrastructure definition in any input format
+// from r, such as standard input. Name is used in error positions.
+func ReadInfrastructure(r io.Reader, name string) (*Infrastructure, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading infrastructure: %w", err)
+	}
+	infra, err := parseInfrastructure(name, data, detectInput("", data))
+	if err != nil {
+		return nil, err
+	}
+	infra.resolveReferences()
+	return infra, nil
+}
+
+// detectInput returns the format of a definition from the extension of
+// file, or else from its content: JSON starts with an object, and TOML with
+// a table header or a "key = value" line.
+func detectInput(file string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		return InputJSON
+	case ".toml":
+		return InputTOML
+	case ".yml", ".yaml":
+		return InputYAML
+	}
+
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("{")) && json.Valid(trimmed) {
+		return InputJSON
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if tomlLine.MatchString(line) {
+			return InputTOML
+		}
+		break
+	}
+	return InputYAML
+}
+
+// tomlLine matches TOML table headers and key/value pairs, neither of which
+// is a valid start of an infrastructure YAML document.
+var tomlLine = regexp.MustCompile(`^(\[\[?\s*[\w."' -]+\s*\]\]?|[\w."'-]+\s*=)`)
+
+// decodeNode parses data in the given format into a YAML node tree, so that
+// every format is decoded, and reports positions, the same way.
+func decodeNode(format string, data []byte) (*yaml.Node, error) {
+	var root yaml.Node
+	switch format {
+	case InputJSON:
+		node, err := jsonNode(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing infrastructure JSON: %w", err)
+		}
+		return node, nil
+	case InputTOML:
+		// TOML values carry no positions, so problems are reported
+		// against the file as a whole
+		var v map[string]interface{}
+		if _, err := toml.Decode(string(data), &v); err != nil {
+			return nil, fmt.Errorf("parsing infrastructure TOML: %w", err)
+		}
+		var node yaml.Node
+		if err := node.Encode(v); err != nil {
+			return nil, fmt.Errorf("parsing infrastructure TOML: %w", err)
+		}
+		root = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&node}}
+	default:
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("parsing infrastructure YAML: %w", err)
+		}
+	}
+	return &root, nil
+}
+
+// jsonNode parses a JSON document into YAML nodes carrying the line and
+// column of each value.
+func jsonNode(data []byte) (*yaml.Node, error) {
+	p := &jsonParser{data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	p.dec.UseNumber()
+	for i, b := range data {
+		if b == '\n' {
+			p.lines = append(p.lines, i+1)
+		}
+	}
+
+	node, err := p.value()
+	if err == nil {
+		offset := p.next()
+		if _, extra := p.dec.Token(); extra != io.EOF {
+			line, column := p.position(offset)
+			err = fmt.Errorf("line %d, column %d: unexpected data after the document", line, column)
+		}
+	}
+	if err != nil {
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			line, column := p.position(syntax.Offset - 1)
+			err = fmt.Errorf("line %d, column %d: %w", line, column, err)
+		}
+		return nil, err
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Line: 1, Column: 1, Content: []*yaml.Node{node}}, nil
+}
+
+type jsonParser struct {
+	data  []byte
+	dec   *json.Decoder
+	lines []int // offsets at which lines after the first start
+}
+
+func (p *jsonParser) value() (*yaml.Node, error) {
+	node := &yaml.Node{}
+	node.Line, node.Column = p.position(p.next())
+	tok, err := p.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		node.Kind, node.Tag = yaml.SequenceNode, "!!seq"
+		if tok == '{' {
+			node.Kind, node.Tag = yaml.MappingNode, "!!map"
+		}
+		for p.dec.More() {
+			if node.Kind == yaml.MappingNode {
+				key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"}
+				key.Line, key.Column = p.position(p.next())
+				tok, err := p.dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key.Value = tok.(string)
+				node.Content = append(node.Content, key)
+			}
+			item, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, item)
+		}
+		if _, err := p.dec.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!str", tok
+	case json.Number:
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!int", tok.String()
+		if strings.ContainsAny(node.Value, ".eE") {
+			node.Tag = "!!float"
+		}
+	case bool:
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!bool", strconv.FormatBool(tok)
+	case nil:
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!null", "null"
+	}
+	return node, nil
+}
+
+// next returns the offset of the next token, skipping the separators the
+// decoder has not consumed yet.
+func (p *jsonParser) next() int64 {
+	offset := p.dec.InputOffset()
+	for offset < int64(len(p.data)) && strings.IndexByte(" \t\r\n,:", p.data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// position converts a byte offset to a 1-based line and column.
+func (p *jsonParser) position(offset int64) (int, int) {
+	line := sort.Search(len(p.lines), func(i int) bool { return int64(p.lines[i]) > offset })
+	start := 0
+	if line > 0 {
+		start = p.lines[line-1]
+	}
+	return line + 1, int(offset) - start + 1
+}
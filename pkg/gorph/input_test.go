@@ -0,0 +1,192 @@
+package gorph
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The same infrastructure in each input format
+const (
+	shopYAML = `entities:
+  - id: API
+    category: BACKEND
+    description: Shop API
+    status: healthy
+  - id: DB
+    category: DATABASE
+    description: Orders
+    status: healthy
+connections:
+  - from: API
+    to: DB
+    type: DB_Connection
+`
+	shopJSON = `{
+  "entities": [
+    {"id": "API", "category": "BACKEND", "description": "Shop API", "status": "healthy"},
+    {"id": "DB", "category": "DATABASE", "description": "Orders", "status": "healthy"}
+  ],
+  "connections": [{"from": "API", "to": "DB", "type": "DB_Connection"}]
+}
+`
+	shopTOML = `# Shop
+[[entities]]
+id = "API"
+category = "BACKEND"
+description = "Shop API"
+status = "healthy"
+
+[[entities]]
+id = "DB"
+category = "DATABASE"
+description = "Orders"
+status = "healthy"
+
+[[connections]]
+from = "API"
+to = "DB"
+type = "DB_Connection"
+`
+)
+
+func TestDetectInput(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+		want string
+	}{
+		{"yml extension", "shop.yml", shopYAML, InputYAML},
+		{"yaml extension", "shop.yaml", shopYAML, InputYAML},
+		{"json extension", "shop.json", shopJSON, InputJSON},
+		{"toml extension", "shop.toml", shopTOML, InputTOML},
+		{"upper-case extension", "SHOP.JSON", shopJSON, InputJSON},
+		// The extension wins over the content
+		{"yaml extension on JSON", "shop.yml", shopJSON, InputYAML},
+		{"json extension on YAML", "shop.json", shopYAML, InputJSON},
+
+		{"stdin YAML", "", shopYAML, InputYAML},
+		{"stdin JSON", "", shopJSON, InputJSON},
+		{"stdin TOML", "", shopTOML, InputTOML},
+		{"no extension JSON", "shop", shopJSON, InputJSON},
+		{"unknown extension TOML", "shop.conf", shopTOML, InputTOML},
+		{"empty", "", "", InputYAML},
+		{"only comments", "", "# nothing yet\n", InputYAML},
+
+		// Ambiguous content falls back to YAML
+		{"YAML flow mapping", "", "{entities: [], connections: []}", InputYAML},
+		{"JSON after comment", "", "# shop\n{\"entities\": []}", InputYAML},
+		{"TOML key after YAML", "", "entities: []\nname = x\n", InputYAML},
+		{"YAML value with equals sign", "", "description: a = b\n", InputYAML},
+		{"TOML key value", "", "\n  name = \"shop\"\n", InputTOML},
+		{"TOML table", "", "[meta]\nname = \"shop\"\n", InputTOML},
+		{"TOML quoted key", "", "\"entities\" = []\n", InputTOML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectInput(tt.file, []byte(tt.data)); got != tt.want {
+				t.Errorf("detectInput(%q) = %s, want %s", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+// summary lists the entities and connections of an infrastructure.
+func summary(infra *Infrastructure) []string {
+	var s []string
+	for _, e := range infra.Entities {
+		s = append(s, e.ID+" "+e.Category+" "+e.Description+" "+e.Status)
+	}
+	for _, c := range infra.Connections {
+		s = append(s, c.From+" -> "+c.To+" "+c.Type)
+	}
+	return s
+}
+
+func TestReadInfrastructure(t *testing.T) {
+	want := []string{"API BACKEND Shop API healthy", "DB DATABASE Orders healthy", "API -> DB DB_Connection"}
+	for _, data := range []string{shopYAML, shopJSON, shopTOML, "\ufeff" + shopJSON} {
+		infra, err := ReadInfrastructure(strings.NewReader(data), "<stdin>")
+		if err != nil {
+			t.Fatalf("ReadInfrastructure(%.10q...): %v", data, err)
+		}
+		if got := summary(infra); !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadInfrastructure(%.10q...) = %q, want %q", data, got, want)
+		}
+	}
+}
+
+func TestLoadInfrastructureFormats(t *testing.T) {
+	dir := t.TempDir()
+	want := []string{"API BACKEND Shop API healthy", "DB DATABASE Orders healthy", "API -> DB DB_Connection"}
+	files := map[string]string{
+		"shop.yml":  shopYAML,
+		"shop.json": shopJSON,
+		"shop.toml": shopTOML,
+		"shop":      shopJSON,
+		"shop.conf": shopTOML,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		infra, err := LoadInfrastructure(path)
+		if err != nil {
+			t.Errorf("LoadInfrastructure(%s): %v", name, err)
+			continue
+		}
+		if got := summary(infra); !reflect.DeepEqual(got, want) {
+			t.Errorf("LoadInfrastructure(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestLoadInfrastructureErrors(t *testing.T) {
+	tests := []struct {
+		file string
+		data string
+		want string
+	}{
+		{"shop.json", "{\n  \"entities\": [,]\n}", "parsing infrastructure JSON: line 2, column 16"},
+		{"shop.json", "{\"entities\": []}\n{}", "parsing infrastructure JSON: line 2, column 1: unexpected data after the document"},
+		{"shop.toml", "[[entities]]\nid = \n", "parsing infrastructure TOML"},
+		{"shop.yml", "entities: [\n", "parsing infrastructure YAML"},
+		// Content that is not valid JSON is read as YAML
+		{"shop", "{\n  \"entities\": [,]\n}", "parsing infrastructure YAML"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadInfrastructure(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPositions(t *testing.T) {
+	infra, err := ReadInfrastructure(strings.NewReader(`{
+  "entities": [
+    {"id": "API", "category": "BACKEND", "description": "Shop API"}
+  ],
+  "connections": []
+}`), "<stdin>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := Validate(infra)
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1:\n%v", len(errs), errs)
+	}
+	if got, want := errs[0].Error(), "<stdin>:3:5: Entity API: Status is required"; !strings.HasPrefix(got, want) {
+		t.Errorf("error = %q, want prefix %q", got, want)
+	}
+}
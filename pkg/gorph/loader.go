@@ -0,0 +1,201 @@
+package gorph
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadStyleConfig reads and parses a style configuration file.
+func LoadStyleConfig(path string) (*StyleConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading style config file: %w", err)
+	}
+	config, err := parseStyleConfig(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Node.IconDir != "" && !filepath.IsAbs(config.Node.IconDir) {
+		config.Node.IconDir = filepath.Join(filepath.Dir(path), config.Node.IconDir)
+	}
+	return config, nil
+}
+
+// ParseStyleConfig parses a style configuration from YAML. Settings that
+// do not match the style schema are rejected; unknown settings are ignored
+// and reported by Warnings.
+func ParseStyleConfig(data []byte) (*StyleConfig, error) {
+	return parseStyleConfig("", data)
+}
+
+func parseStyleConfig(file string, data []byte) (*StyleConfig, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parsing style config: %w", err)
+	}
+	errs, warnings := checkSchema(styleSchema, &root, file)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("parsing style config:\n%w", errs)
+	}
+
+	var config StyleConfig
+	if len(root.Content) > 0 {
+		if err := root.Decode(&config); err != nil {
+			return nil, fmt.Errorf("parsing style config: %w", err)
+		}
+	}
+	config.warnings = warnings
+	return &config, nil
+}
+
+// OverrideStyleConfig returns a copy of base with the settings of a YAML
+// style document applied on top. Nested settings are merged key by key, so
+// an override can change a single category color; lists are replaced.
+// Unknown settings are rejected.
+func OverrideStyleConfig(base *StyleConfig, data []byte) (*StyleConfig, error) {
+	// Check the override on its own, so errors point at its lines
+	var override yaml.Node
+	if err := yaml.Unmarshal(data, &override); err != nil {
+		return nil, fmt.Errorf("parsing style config: %w", err)
+	}
+	errs, warnings := checkSchema(styleSchema, &override, "")
+	if errs = append(errs, warnings...); len(errs) > 0 {
+		return nil, fmt.Errorf("parsing style config:\n%w", errs)
+	}
+	var merged yaml.Node
+	if err := merged.Encode(base); err != nil {
+		return nil, fmt.Errorf("encoding style config: %w", err)
+	}
+	if len(override.Content) > 0 {
+		if err := mergeStyleNode(&merged, override.Content[0], ""); err != nil {
+			return nil, err
+		}
+	}
+
+	mergedData, err := yaml.Marshal(&merged)
+	if err != nil {
+		return nil, fmt.Errorf("encoding style config: %w", err)
+	}
+	var config StyleConfig
+	if err := yaml.Unmarshal(mergedData, &config); err != nil {
+		return nil, fmt.Errorf("parsing style config: %w", err)
+	}
+	return &config, nil
+}
+
+// mergeStyleNode merges the mapping src into dst.
+func mergeStyleNode(dst, src *yaml.Node, path string) error {
+	if src.Kind != yaml.MappingNode {
+		if path == "" {
+			return fmt.Errorf("parsing style config: expected a mapping")
+		}
+		return fmt.Errorf("parsing style config: %s: expected a mapping", path)
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		keyPath := key.Value
+		if path != "" {
+			keyPath = path + "." + key.Value
+		}
+
+		found := false
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if dst.Content[j].Value != key.Value {
+				continue
+			}
+			found = true
+			if old := dst.Content[j+1]; old.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+				if err := mergeStyleNode(old, value, keyPath); err != nil {
+					return err
+				}
+			} else {
+				dst.Content[j+1] = value
+			}
+			break
+		}
+		if !found {
+			dst.Content = append(dst.Content, key, value)
+		}
+	}
+	return nil
+}
+
+// LoadInfrastructure reads and parses an infrastructure definition file in
+// YAML, JSON or TOML, chosen by its extension or else by its content, and
+// merges the files it includes, clearing Include. Validation errors
+// reported for the result carry positions in the file that declared the
+// offending value.
+func LoadInfrastructure(path string) (*Infrastructure, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading infrastructure file: %w", err)
+	}
+	infra, err := parseInfrastructure(path, data, detectInput(path, data))
+	if err != nil {
+		return nil, err
+	}
+	if err := resolveIncludes(infra, path); err != nil {
+		return nil, err
+	}
+	infra.resolveReferences()
+	return infra, nil
+}
+
+// ParseInfrastructure parses an infrastructure definition in YAML, JSON or
+// TOML, detected from its content. Includes are left unresolved, since
+// there is no file to resolve them against.
+func ParseInfrastructure(data []byte) (*Infrastructure, error) {
+	infra, err := parseInfrastructure("", data, detectInput("", data))
+	if err != nil {
+		return nil, err
+	}
+	infra.resolveReferences()
+	return infra, nil
+}
+
+func parseInfrastructure(file string, data []byte, format string) (*Infrastructure, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	root, err := decodeNode(format, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Values of the wrong type fail to decode; the schema explains why
+	schemaErrs, warnings := checkSchema(infrastructureSchema, root, file)
+	var infra Infrastructure
+	if len(root.Content) > 0 {
+		if err := root.Decode(&infra); err != nil {
+			if len(schemaErrs) > 0 {
+				return nil, schemaErrs
+			}
+			return nil, fmt.Errorf("parsing infrastructure %s: %w", strings.ToUpper(format), err)
+		}
+	}
+	infra.source = newSourceMap(file, root)
+	infra.schemaErrors = schemaErrs
+	infra.warnings = warnings
+	infra.qualifyEntities()
+
+	return &infra, nil
+}
+
+// MarshalInfrastructure serializes an infrastructure definition to YAML in
+// the layout used by the example files.
+func MarshalInfrastructure(infra *Infrastructure) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(infra); err != nil {
+		return nil, fmt.Errorf("encoding infrastructure YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encoding infrastructure YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
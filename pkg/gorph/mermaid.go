@@ -0,0 +1,193 @@
+package gorph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// mermaidReserved are words Mermaid flowcharts reject as node IDs.
+var mermaidReserved = []string{
+	"end", "graph", "flowchart", "subgraph", "direction", "style",
+	"class", "classDef", "click", "linkStyle", "call", "href",
+}
+
+// mermaidShapes maps resolved shapes to Mermaid node delimiters.
+var mermaidShapes = map[string][2]string{
+	"cylinder":      {"[(", ")]"},
+	"ellipse":       {"([", "])"},
+	"oval":          {"([", "])"},
+	"egg":           {"([", "])"},
+	"circle":        {"((", "))"},
+	"doublecircle":  {"(((", ")))"},
+	"diamond":       {"{", "}"},
+	"hexagon":       {"{{", "}}"},
+	"parallelogram": {"[/", "/]"},
+	"trapezium":     {"[/", "\\]"},
+	"component":     {"[[", "]]"},
+}
+
+var mermaidEscaper = strings.NewReplacer(
+	"&", "#amp;",
+	`"`, "#quot;",
+	"<", "#lt;",
+	">", "#gt;",
+	"|", "#124;",
+	"\n", "<br/>",
+)
+
+// Mermaid Generator producing flowchart source for Markdown renderers
+type MermaidGenerator struct {
+	style *StyleConfig
+}
+
+func NewMermaidGenerator(style *StyleConfig) *MermaidGenerator {
+	return &MermaidGenerator{style: style}
+}
+
+func (g *MermaidGenerator) Generate(infra *Infrastructure) string {
+	var sb strings.Builder
+	ids := newIDMapper(mermaidReserved...)
+
+	// Register entities first so IDs do not depend on connection order
+	for _, entity := range infra.Entities {
+		ids.ID(entity.ID)
+	}
+
+	direction := strings.ToUpper(g.style.Graph.Direction)
+	switch direction {
+	case "TB", "TD", "BT", "LR", "RL":
+	default:
+		direction = "TB"
+	}
+	sb.WriteString(fmt.Sprintf("flowchart %s\n", direction))
+
+	// Subgraphs per category, within subgraphs per namespace
+	g.generateGroups(&sb, "  ", ids, groupEntities(infra.Entities, g.style.CategoryOrder))
+
+	// Connections in source order; linkStyle refers to them by index
+	for _, conn := range infra.Connections {
+		arrow := "-->"
+		switch g.style.ConnectionStyles[conn.Type].Style {
+		case "dashed", "dotted":
+			arrow = "-.->"
+		case "bold":
+			arrow = "==>"
+		}
+		if conn.Type != "" {
+			arrow += fmt.Sprintf("|\"%s\"|", mermaidEscaper.Replace(conn.Type))
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s %s\n", ids.ID(conn.From), arrow, ids.ID(conn.To)))
+	}
+
+	g.generateStatusClasses(&sb, ids, infra.Entities)
+	g.generateLinkStyles(&sb, infra.Connections)
+
+	return sb.String()
+}
+
+// generateGroups writes a subgraph per category, within one per namespace.
+func (g *MermaidGenerator) generateGroups(sb *strings.Builder, indent string, ids *idMapper, group *namespaceGroup) {
+	for _, category := range group.Categories {
+		if g.style.Categories[category.Category].NoCluster {
+			for _, entity := range category.Entities {
+				g.generateNode(sb, indent, ids, entity)
+			}
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("%ssubgraph %s[\"%s\"]\n", indent,
+			ids.ID("cluster_"+categoryCluster(group.Name, category.Category)), mermaidEscaper.Replace(categoryDisplayName(g.style, category.Category))))
+		for _, entity := range category.Entities {
+			g.generateNode(sb, indent+"  ", ids, entity)
+		}
+		sb.WriteString(indent + "end\n")
+	}
+
+	for _, nested := range group.Namespaces {
+		if g.style.Namespaces[nested.Name].NoCluster {
+			g.generateGroups(sb, indent, ids, nested)
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%ssubgraph %s[\"%s\"]\n", indent,
+			ids.ID("cluster_"+namespaceCluster(nested.Name)), mermaidEscaper.Replace(namespaceDisplayName(g.style, nested.Name))))
+		g.generateGroups(sb, indent+"  ", ids, nested)
+		sb.WriteString(indent + "end\n")
+	}
+}
+
+func (g *MermaidGenerator) generateNode(sb *strings.Builder, indent string, ids *idMapper, entity Entity) {
+	label := fmt.Sprintf("<b>%s</b><br/>%s",
+		mermaidEscaper.Replace(entityLabel(g.style, entity.ID)),
+		mermaidEscaper.Replace(truncateRunes(entity.Description, g.style.Node.MaxDescriptionLength, g.style.Node.TruncationSuffix)))
+	for _, attr := range displayedAttributes(g.style, entity) {
+		label += fmt.Sprintf("<br/><i>%s</i>: %s", mermaidEscaper.Replace(attr.Key),
+			mermaidEscaper.Replace(truncateRunes(attr.Value, g.style.Node.MaxDescriptionLength, g.style.Node.TruncationSuffix)))
+	}
+
+	delims := [2]string{"[", "]"}
+	shape := resolveShape(g.style, entity.Shape)
+	if shaped, ok := mermaidShapes[shape.Shape]; ok {
+		delims = shaped
+	} else if shape.Rounded {
+		delims = [2]string{"(", ")"}
+	}
+
+	sb.WriteString(fmt.Sprintf("%s%s%s\"%s\"%s\n", indent, ids.ID(entity.ID), delims[0], label, delims[1]))
+}
+
+// generateStatusClasses emits one classDef per status color and assigns
+// entities to them. Statuses without a color use the "unknown" class.
+func (g *MermaidGenerator) generateStatusClasses(sb *strings.Builder, ids *idMapper, entities []Entity) {
+	members := make(map[string][]string)
+	for _, entity := range entities {
+		status := strings.ToLower(entity.Status)
+		if _, ok := g.style.StatusColors[status]; !ok {
+			status = "unknown"
+		}
+		members[status] = append(members[status], ids.ID(entity.ID))
+	}
+
+	statuses := make([]string, 0, len(members))
+	for status := range members {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	classes := newIDMapper(mermaidReserved...)
+	for _, status := range statuses {
+		class := classes.ID("status_" + status)
+		if color := g.style.StatusColors[status]; color != "" {
+			sb.WriteString(fmt.Sprintf("  classDef %s stroke:%s,stroke-width:3px\n", class, color))
+		} else {
+			sb.WriteString(fmt.Sprintf("  classDef %s stroke-width:3px\n", class))
+		}
+		sb.WriteString(fmt.Sprintf("  class %s %s\n", strings.Join(members[status], ","), class))
+	}
+}
+
+// generateLinkStyles colors each connection from its connection style.
+func (g *MermaidGenerator) generateLinkStyles(sb *strings.Builder, conns []Connection) {
+	for i, conn := range conns {
+		style, ok := g.style.ConnectionStyles[conn.Type]
+		if !ok {
+			continue
+		}
+
+		var props []string
+		if style.Color != "" {
+			props = append(props, "stroke:"+style.Color)
+		}
+		switch style.Style {
+		case "dashed":
+			props = append(props, "stroke-dasharray:6 4")
+		case "dotted":
+			props = append(props, "stroke-dasharray:2 3")
+		case "bold":
+			props = append(props, "stroke-width:3px")
+		}
+		if len(props) > 0 {
+			sb.WriteString(fmt.Sprintf("  linkStyle %d %s\n", i, strings.Join(props, ",")))
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package gorph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMermaidGolden(t *testing.T) {
+	for _, name := range examples {
+		t.Run(name, func(t *testing.T) {
+			checkGolden(t, name+".mmd", NewMermaidGenerator(DefaultStyle()).Generate(loadExample(t, name)))
+		})
+	}
+}
+
+func TestMermaidEscaping(t *testing.T) {
+	infra := &Infrastructure{
+		Entities: []Entity{
+			{ID: "end", Category: "BACKEND", Description: `say "hi" & <bye>`, Status: "healthy"},
+			{ID: "a-b", Category: "BACKEND", Description: "line\nbreak", Status: "mystery"},
+			{ID: "a_b", Category: "DATABASE", Description: "d", Status: "healthy"},
+		},
+		Connections: []Connection{
+			{From: "end", To: "a-b", Type: `x"y|z`},
+			{From: "a-b", To: "a_b", Type: "DB_Connection"},
+		},
+	}
+	out := NewMermaidGenerator(DefaultStyle()).Generate(infra)
+
+	for _, want := range []string{
+		// Reserved words and IDs that would collide are renamed
+		`n_end["<b>end</b><br/>say #quot;hi#quot; #amp; #lt;bye#gt;"]`,
+		`a_b["<b>a-b</b><br/>line<br/>break"]`,
+		`a_b_2["<b>a_b</b><br/>d"]`,
+		`n_end -->|"x#quot;y#124;z"| a_b`,
+		"a_b -->|\"DB_Connection\"| a_b_2",
+		"class a_b status_unknown",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "end[") || strings.Contains(line, `"say "hi"`) {
+			t.Errorf("unescaped line %q", line)
+		}
+	}
+}
+
+func TestMermaidDirection(t *testing.T) {
+	tests := []struct {
+		direction string
+		want      string
+	}{
+		{"LR", "flowchart LR\n"},
+		{"bt", "flowchart BT\n"},
+		{"", "flowchart TB\n"},
+		{"sideways", "flowchart TB\n"},
+	}
+	for _, tt := range tests {
+		style := DefaultStyle()
+		style.Graph.Direction = tt.direction
+		if out := NewMermaidGenerator(style).Generate(&Infrastructure{}); !strings.HasPrefix(out, tt.want) {
+			t.Errorf("direction %q: output starts %q, want %q", tt.direction, strings.SplitAfter(out, "\n")[0], tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package gorph contains the infrastructure model, loaders, validation and
+// diagram generators shared by the CLI and the WASM build.
+package gorph
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Infrastructure entity and connection definitions
+type Entity struct {
+	ID               string                 `json:"id" yaml:"id"`
+	Category         string                 `json:"category" yaml:"category"`
+	Description      string                 `json:"description" yaml:"description"`
+	Status           string                 `json:"status" yaml:"status"`
+	Owner            string                 `json:"owner" yaml:"owner,omitempty"`
+	Environment      string                 `json:"environment" yaml:"environment,omitempty"`
+	Tags             []string               `json:"tags" yaml:"tags,omitempty"`
+	Attributes       Attributes             `json:"attributes" yaml:"attributes,omitempty"`
+	DeploymentConfig map[string]interface{} `json:"deployment_config" yaml:"deployment_config,omitempty"`
+	Shape            string                 `json:"shape" yaml:"shape,omitempty"`
+	Icon             string                 `json:"icon" yaml:"icon,omitempty"`
+}
+
+type Connection struct {
+	From       string     `json:"from" yaml:"from"`
+	To         string     `json:"to" yaml:"to"`
+	Type       string     `json:"type" yaml:"type"`
+	Attributes Attributes `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+}
+
+type Infrastructure struct {
+	Name        string       `json:"name,omitempty" yaml:"name,omitempty"`
+	Description string       `json:"description,omitempty" yaml:"description,omitempty"`
+	Admins      []string     `json:"admins,omitempty" yaml:"admins,omitempty"`       // API teams, and users as user:<subject>, with full access
+	Namespace   string       `json:"namespace,omitempty" yaml:"namespace,omitempty"` // namespace the file's entities are declared in
+	Include     []string     `json:"include,omitempty" yaml:"include,omitempty"`     // files merged in by LoadInfrastructure
+	Entities    []Entity     `json:"entities" yaml:"entities"`
+	Connections []Connection `json:"connections" yaml:"connections"`
+
+	// source records declaration positions when the definition was parsed
+	// from YAML; it is nil for values built in code.
+	source *sourceMap
+	// schemaErrors are the schema violations found when parsing
+	schemaErrors ValidationErrors
+	// warnings are the unknown fields found when parsing
+	warnings ValidationErrors
+	// namespaces holds the namespace of each connection's file until the
+	// references of connections are resolved
+	namespaces []string
+	// included are the files merged in by LoadInfrastructure
+	included []string
+}
+
+// Attributes are free-form key/value pairs describing an entity or a
+// connection. Values are always strings, and are written quoted when they
+// would otherwise read as another type, so `port: 8081` is written back as
+// `port: "8081"`. List values in YAML, such as `functions: [a, b]`, are
+// flattened by joining their items with ", " so existing definitions keep
+// loading: afterwards the list cannot be told apart from the string "a, b",
+// and it is written back as that string. Only UpdateInfrastructureYAML
+// keeps an unchanged value as written.
+type Attributes map[string]string
+
+func (a *Attributes) UnmarshalYAML(node *yaml.Node) error {
+	var raw map[string]yaml.Node
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+
+	attrs := make(Attributes, len(raw))
+	for key, value := range raw {
+		switch value.Kind {
+		case yaml.SequenceNode:
+			var items []string
+			if err := value.Decode(&items); err != nil {
+				return err
+			}
+			attrs[key] = strings.Join(items, ", ")
+		default:
+			var s string
+			if err := value.Decode(&s); err != nil {
+				return err
+			}
+			attrs[key] = s
+		}
+	}
+	*a = attrs
+	return nil
+}
@@ -0,0 +1,23 @@
+package gorph
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAttributesFlattenLists(t *testing.T) {
+	var attrs Attributes
+	if err := yaml.Unmarshal([]byte("functions: [a, b]\nport: 8080\nname: \"a, b\"\n"), &attrs); err != nil {
+		t.Fatal(err)
+	}
+	want := Attributes{"functions": "a, b", "port": "8080", "name": "a, b"}
+	for key, value := range want {
+		if attrs[key] != value {
+			t.Errorf("%s = %q, want %q", key, attrs[key], value)
+		}
+	}
+	if len(attrs) != len(want) {
+		t.Errorf("got %d attributes, want %d", len(attrs), len(want))
+	}
+}
@@ -0,0 +1,150 @@
+package gorph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Entity IDs may be qualified by namespaces, such as "payments/Database",
+// so that files owned by different teams can declare the same names. A file
+// that sets a namespace declares its entities in it, and its connections
+// refer to entities relative to it: from the namespace outward to the root,
+// the first entity with the referenced name is used, so "Database" means
+// "payments/Database" in a "payments" file if that exists, and the root
+// "Database" otherwise. A leading "/" refers to the root explicitly.
+
+// splitEntityID splits a qualified ID into its namespace and name.
+func splitEntityID(id string) (namespace, name string) {
+	i := strings.LastIndex(id, "/")
+	if i < 0 {
+		return "", id
+	}
+	return id[:i], id[i+1:]
+}
+
+// entityNamespace returns the namespace an entity ID is declared in, empty
+// for the root.
+func entityNamespace(id string) string {
+	namespace, _ := splitEntityID(id)
+	return namespace
+}
+
+// qualifyEntities declares the entities of a parsed file in its namespace.
+// The namespace of each connection is kept until resolveReferences, when
+// the entities of every included file are known.
+func (infra *Infrastructure) qualifyEntities() {
+	namespace := infra.Namespace
+	infra.Namespace = ""
+	if namespace != "" {
+		for i := range infra.Entities {
+			if infra.Entities[i].ID != "" {
+				infra.Entities[i].ID = namespace + "/" + infra.Entities[i].ID
+			}
+		}
+	}
+	infra.namespaces = make([]string, len(infra.Connections))
+	for i := range infra.namespaces {
+		infra.namespaces[i] = namespace
+	}
+}
+
+// resolveReferences replaces the endpoints of connections by the fully
+// qualified IDs of the entities they refer to. References to no entity are
+// kept as written, for Validate to report.
+func (infra *Infrastructure) resolveReferences() {
+	ids := make(map[string]bool, len(infra.Entities))
+	for _, entity := range infra.Entities {
+		ids[entity.ID] = true
+	}
+	for i := range infra.Connections {
+		if i >= len(infra.namespaces) {
+			break
+		}
+		conn := &infra.Connections[i]
+		conn.From = resolveReference(conn.From, infra.namespaces[i], ids)
+		conn.To = resolveReference(conn.To, infra.namespaces[i], ids)
+	}
+	infra.namespaces = nil
+}
+
+func resolveReference(ref, namespace string, ids map[string]bool) string {
+	if strings.HasPrefix(ref, "/") {
+		return ref[1:]
+	}
+	for ns := namespace; ns != ""; ns = entityNamespace(ns) {
+		if id := ns + "/" + ref; ids[id] {
+			return id
+		}
+	}
+	return ref
+}
+
+// declareIn returns a copy of a definition whose entities are qualified by
+// namespace, with entity IDs and connection endpoints relative to it as in
+// a file declaring that namespace: the inverse of qualifyEntities and
+// resolveReferences. Entities outside the namespace are reported.
+func (infra *Infrastructure) declareIn(namespace string) (*Infrastructure, error) {
+	declared := *infra
+	declared.Namespace = namespace
+	declared.Entities = append([]Entity(nil), infra.Entities...)
+	declared.Connections = append([]Connection(nil), infra.Connections...)
+
+	var errs ValidationErrors
+	ids := make(map[string]bool, len(infra.Entities))
+	for i := range declared.Entities {
+		entity := &declared.Entities[i]
+		ids[entity.ID] = true
+		name, ok := strings.CutPrefix(entity.ID, namespace+"/")
+		if !ok {
+			errs = append(errs, ValidationError{
+				Field:   fmt.Sprintf("entities.%d.id", i),
+				Message: fmt.Sprintf("Entity %s: not in namespace %s of the file", entity.ID, namespace),
+			})
+		}
+		entity.ID = name
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	for i := range declared.Connections {
+		conn := &declared.Connections[i]
+		conn.From = relativeReference(conn.From, namespace, ids)
+		conn.To = relativeReference(conn.To, namespace, ids)
+	}
+	return &declared, nil
+}
+
+// relativeReference returns the shortest reference to an entity ID that
+// resolveReference resolves back to it from namespace.
+func relativeReference(id, namespace string, ids map[string]bool) string {
+	for ns := namespace; ns != ""; ns = entityNamespace(ns) {
+		if ref, ok := strings.CutPrefix(id, ns+"/"); ok && resolveReference(ref, namespace, ids) == id {
+			return ref
+		}
+	}
+	if resolveReference(id, namespace, ids) == id {
+		return id
+	}
+	return "/" + id
+}
+
+// entityLabel returns the name an entity is labeled with in diagrams: its
+// ID relative to the innermost namespace drawn as a cluster around it.
+func entityLabel(style *StyleConfig, id string) string {
+	for ns := entityNamespace(id); ns != ""; ns = entityNamespace(ns) {
+		if !style.Namespaces[ns].NoCluster {
+			return strings.TrimPrefix(id, ns+"/")
+		}
+	}
+	return id
+}
+
+// namespaceDisplayName returns the label of a namespace cluster: its
+// display name, or else its last name.
+func namespaceDisplayName(style *StyleConfig, namespace string) string {
+	if config := style.Namespaces[namespace]; config.DisplayName != "" {
+		return config.DisplayName
+	}
+	_, name := splitEntityID(namespace)
+	return name
+}
@@ -0,0 +1,80 @@
+package gorph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRelativeReference(t *testing.T) {
+	ids := map[string]bool{"DB": true, "Gateway": true, "payments/DB": true, "payments/ledger/DB": true}
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"payments/ledger/DB", "DB"},
+		{"payments/DB", "payments/DB"},
+		{"DB", "/DB"},
+		{"Gateway", "Gateway"},
+		{"Missing", "Missing"},
+	}
+	for _, tt := range tests {
+		got := relativeReference(tt.id, "payments/ledger", ids)
+		if got != tt.want {
+			t.Errorf("relativeReference(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+		if back := resolveReference(got, "payments/ledger", ids); back != tt.id {
+			t.Errorf("resolveReference(%q) = %q, want %q", got, back, tt.id)
+		}
+	}
+}
+
+func TestUpdateInfrastructureYAMLNamespace(t *testing.T) {
+	const original = `# Ledger team
+namespace: payments/ledger
+entities:
+  - id: DB
+    category: DATABASE
+    description: Ledger store
+    status: healthy
+  - id: Writer
+    category: BACKEND
+    description: Ledger writer
+    status: healthy
+connections:
+  - from: Writer
+    to: DB
+    type: DB_Connection
+`
+	infra, err := ParseInfrastructure([]byte(original))
+	if err != nil {
+		t.Fatal(err)
+	}
+	infra.Entities[1].Status = "degraded"
+	infra.Entities = append(infra.Entities, Entity{ID: "payments/ledger/Audit", Category: "BACKEND", Description: "Audit log", Status: "healthy"})
+	infra.Connections = append(infra.Connections, Connection{From: "payments/ledger/Audit", To: "payments/ledger/DB", Type: "DB_Connection"})
+
+	data, err := UpdateInfrastructureYAML([]byte(original), infra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"# Ledger team\nnamespace: payments/ledger\n",
+		"  - id: Writer\n    category: BACKEND\n    description: Ledger writer\n    status: degraded\n",
+		"  - id: Audit\n",
+		"  - from: Audit\n    to: DB\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "payments/ledger/") {
+		t.Errorf("output has qualified IDs:\n%s", got)
+	}
+
+	infra.Entities = append(infra.Entities, Entity{ID: "Elsewhere", Category: "BACKEND", Description: "d", Status: "healthy"})
+	_, err = UpdateInfrastructureYAML([]byte(original), infra)
+	if want := "Entity Elsewhere: not in namespace payments/ledger of the file"; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
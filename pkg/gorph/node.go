@@ -0,0 +1,133 @@
+package gorph
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// graphvizShapes are the node shapes that can be drawn around an entity's
+// table label. Shapes that already look like the default table (box,
+// rectangle, plaintext) are rendered as the plain table instead.
+var graphvizShapes = map[string]bool{
+	"ellipse": true, "oval": true, "circle": true, "doublecircle": true,
+	"cylinder": true, "diamond": true, "hexagon": true, "octagon": true,
+	"parallelogram": true, "trapezium": true, "house": true, "invhouse": true,
+	"triangle": true, "pentagon": true, "septagon": true, "egg": true,
+	"note": true, "tab": true, "folder": true, "box3d": true,
+	"component": true, "cds": true, "star": true, "square": true,
+}
+
+// nodeShape describes how an entity's shape is rendered: either as a
+// Graphviz node shape drawn around the table, or as table styling.
+type nodeShape struct {
+	// Shape is the Graphviz shape, empty for the default table.
+	Shape string
+	// Rounded draws the table with rounded corners.
+	Rounded bool
+}
+
+// resolveShape maps an entity shape, after applying node.shapes aliases,
+// to its rendering. Unknown shapes fall back to the default table.
+func resolveShape(style *StyleConfig, shape string) nodeShape {
+	shape = strings.ToLower(strings.TrimSpace(shape))
+	if mapped, ok := style.Node.Shapes[shape]; ok {
+		shape = strings.ToLower(mapped)
+	}
+
+	switch {
+	case shape == "rounded":
+		return nodeShape{Rounded: true}
+	case graphvizShapes[shape]:
+		return nodeShape{Shape: shape}
+	}
+	return nodeShape{}
+}
+
+// attributeRow is an entity attribute selected for display in its node.
+type attributeRow struct {
+	Key   string
+	Value string
+}
+
+// displayedAttributes returns the entity attributes listed in
+// node.show_attributes, in that order. A "*" entry shows every attribute
+// not listed explicitly, sorted by key.
+func displayedAttributes(style *StyleConfig, entity Entity) []attributeRow {
+	var rows []attributeRow
+	shown := make(map[string]bool)
+
+	for _, key := range style.Node.ShowAttributes {
+		if key == "*" {
+			var rest []string
+			for k := range entity.Attributes {
+				if !shown[k] {
+					rest = append(rest, k)
+				}
+			}
+			sort.Strings(rest)
+			for _, k := range rest {
+				shown[k] = true
+				rows = append(rows, attributeRow{Key: k, Value: entity.Attributes[k]})
+			}
+			continue
+		}
+
+		value, ok := entity.Attributes[key]
+		if !ok || shown[key] {
+			continue
+		}
+		shown[key] = true
+		rows = append(rows, attributeRow{Key: key, Value: value})
+	}
+	return rows
+}
+
+// iconExtensions are tried in order when an icon is given without one.
+var iconExtensions = []string{".png", ".svg", ".jpg", ".jpeg", ".gif"}
+
+// iconResolver maps entity icon names to image files in node.icon_dir.
+type iconResolver struct {
+	dir   string
+	cache map[string]string
+}
+
+func newIconResolver(dir string) *iconResolver {
+	return &iconResolver{dir: dir, cache: make(map[string]string)}
+}
+
+// Resolve returns the path of the image for icon, or "" when no icon
+// directory is configured or no matching file exists in it.
+func (r *iconResolver) Resolve(icon string) string {
+	if r.dir == "" || icon == "" {
+		return ""
+	}
+	if path, ok := r.cache[icon]; ok {
+		return path
+	}
+
+	candidates := []string{icon}
+	if filepath.Ext(icon) == "" {
+		candidates = nil
+		for _, ext := range iconExtensions {
+			candidates = append(candidates, icon+ext)
+		}
+	}
+
+	path := ""
+	for _, candidate := range candidates {
+		full := filepath.Join(r.dir, filepath.FromSlash(candidate))
+		// Icon names must not escape the icon directory
+		if rel, err := filepath.Rel(r.dir, full); err != nil || strings.HasPrefix(rel, "..") {
+			break
+		}
+		if info, err := os.Stat(full); err == nil && !info.IsDir() {
+			path = full
+			break
+		}
+	}
+
+	r.cache[icon] = path
+	return path
+}
@@ -0,0 +1,103 @@
+package gorph
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveShape(t *testing.T) {
+	style := &StyleConfig{Node: NodeConfig{Shapes: map[string]string{
+		"database": "Cylinder",
+		"service":  "rounded",
+		"bad":      "blob",
+	}}}
+	tests := []struct {
+		shape string
+		want  nodeShape
+	}{
+		{"", nodeShape{}},
+		{"box", nodeShape{}},
+		{"rectangle", nodeShape{}},
+		{"plaintext", nodeShape{}},
+		{"ellipse", nodeShape{Shape: "ellipse"}},
+		{" Hexagon ", nodeShape{Shape: "hexagon"}},
+		{"rounded", nodeShape{Rounded: true}},
+		{"database", nodeShape{Shape: "cylinder"}},
+		{"DATABASE", nodeShape{Shape: "cylinder"}},
+		{"service", nodeShape{Rounded: true}},
+		{"bad", nodeShape{}},
+		{"blob", nodeShape{}},
+		{`box" fontsize=99`, nodeShape{}},
+	}
+	for _, tt := range tests {
+		if got := resolveShape(style, tt.shape); got != tt.want {
+			t.Errorf("resolveShape(%q) = %+v, want %+v", tt.shape, got, tt.want)
+		}
+	}
+}
+
+func TestDisplayedAttributes(t *testing.T) {
+	entity := Entity{Attributes: Attributes{"port": "8080", "engine": "postgres", "region": "eu", "zone": "a"}}
+	tests := []struct {
+		name string
+		show []string
+		want []attributeRow
+	}{
+		{"none", nil, nil},
+		{"listed order", []string{"region", "port"}, []attributeRow{{"region", "eu"}, {"port", "8080"}}},
+		{"missing and repeated keys", []string{"missing", "port", "port"}, []attributeRow{{"port", "8080"}}},
+		{"all sorted", []string{"*"}, []attributeRow{{"engine", "postgres"}, {"port", "8080"}, {"region", "eu"}, {"zone", "a"}}},
+		{"listed before the rest", []string{"zone", "*"}, []attributeRow{{"zone", "a"}, {"engine", "postgres"}, {"port", "8080"}, {"region", "eu"}}},
+		{"rest before listed", []string{"*", "zone"}, []attributeRow{{"engine", "postgres"}, {"port", "8080"}, {"region", "eu"}, {"zone", "a"}}},
+	}
+	for _, tt := range tests {
+		style := &StyleConfig{Node: NodeConfig{ShowAttributes: tt.show}}
+		if got := displayedAttributes(style, entity); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: displayedAttributes() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIconResolver(t *testing.T) {
+	dir := filepath.Join("testdata", "icons")
+	tests := []struct {
+		icon string
+		want string
+	}{
+		{"", ""},
+		{"api", filepath.Join(dir, "api.png")},
+		{"api.png", filepath.Join(dir, "api.png")},
+		{"db", filepath.Join(dir, "db.svg")},
+		{"db.svg", filepath.Join(dir, "db.svg")},
+		{"db.png", ""},
+		{"aws/lambda", filepath.Join(dir, "aws", "lambda.png")},
+		{"missing", ""},
+		// Directories are not images
+		{"folder.png", ""},
+		// Icons outside the directory are not found, even if they exist
+		{"../escape", ""},
+		{"../escape.png", ""},
+		{"aws/../../escape", ""},
+		{"../icons/api", filepath.Join(dir, "api.png")},
+		{"/api", filepath.Join(dir, "api.png")},
+	}
+	if _, err := os.Stat(filepath.Join("testdata", "escape.png")); err != nil {
+		t.Fatal(err)
+	}
+	r := newIconResolver(dir)
+	for _, tt := range tests {
+		if got := r.Resolve(tt.icon); got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.icon, got, tt.want)
+		}
+		// Cached answers are the same
+		if got := r.Resolve(tt.icon); got != tt.want {
+			t.Errorf("cached Resolve(%q) = %q, want %q", tt.icon, got, tt.want)
+		}
+	}
+
+	if got := newIconResolver("").Resolve("api"); got != "" {
+		t.Errorf("Resolve without an icon directory = %q, want none", got)
+	}
+}
@@ -0,0 +1,98 @@
+package gorph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Output formats accepted by Render.
+const (
+	FormatDOT      = "dot"
+	FormatPNG      = "png"
+	FormatSVG      = "svg"
+	FormatPDF      = "pdf"
+	FormatMermaid  = "mermaid"
+	FormatPlantUML = "plantuml"
+	FormatC4       = "c4"
+	FormatDrawIO   = "drawio"
+)
+
+// Formats lists every output format.
+var Formats = []string{FormatDOT, FormatPNG, FormatSVG, FormatPDF, FormatMermaid, FormatPlantUML, FormatC4, FormatDrawIO}
+
+// Rendering engines for SVG output.
+const (
+	EngineGraphviz = "graphviz"
+	EngineNative   = "native"
+)
+
+// ErrUnknownFormat is returned by Render for formats not in Formats.
+var ErrUnknownFormat = errors.New("unknown output format")
+
+var contentTypes = map[string]string{
+	FormatDOT:      "text/vnd.graphviz",
+	FormatPNG:      "image/png",
+	FormatSVG:      "image/svg+xml",
+	FormatPDF:      "application/pdf",
+	FormatMermaid:  "text/vnd.mermaid",
+	FormatPlantUML: "text/plain; charset=utf-8",
+	FormatC4:       "text/plain; charset=utf-8",
+	FormatDrawIO:   "application/vnd.jgraph.mxfile",
+}
+
+// ContentType returns the MIME type of an output format.
+func ContentType(format string) string {
+	if contentType, ok := contentTypes[format]; ok {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// RenderOptions selects how Render produces a diagram.
+type RenderOptions struct {
+	// Engine renders svg output: EngineGraphviz (the default) or
+	// EngineNative, which needs no Graphviz
+	Engine string
+	// Graphviz limits the dot command used for png, pdf and svg
+	Graphviz GraphvizLimits
+}
+
+// Render produces a diagram of infra in one of Formats and returns it with
+// its MIME type. png, pdf and svg need the Graphviz dot command unless svg
+// is rendered natively.
+func Render(ctx context.Context, infra *Infrastructure, style *StyleConfig, format string, opts RenderOptions) ([]byte, string, error) {
+	var output []byte
+	switch format {
+	case FormatDOT:
+		output = []byte(NewDOTGenerator(style).Generate(infra))
+	case FormatSVG, FormatPNG, FormatPDF:
+		switch opts.Engine {
+		case "", EngineGraphviz:
+			dot := NewDOTGenerator(style).Generate(infra)
+			var err error
+			if output, err = RunGraphviz(ctx, dot, format, opts.Graphviz); err != nil {
+				return nil, "", err
+			}
+		case EngineNative:
+			if format != FormatSVG {
+				return nil, "", fmt.Errorf("the native engine only renders svg, not %s", format)
+			}
+			output = []byte(NewSVGGenerator(style).Generate(infra))
+		default:
+			return nil, "", fmt.Errorf("unknown engine %q: use %s or %s", opts.Engine, EngineGraphviz, EngineNative)
+		}
+	case FormatMermaid:
+		output = []byte(NewMermaidGenerator(style).Generate(infra))
+	case FormatPlantUML:
+		output = []byte(NewPlantUMLGenerator(style).Generate(infra))
+	case FormatC4:
+		output = []byte(NewC4PlantUMLGenerator(style).Generate(infra))
+	case FormatDrawIO:
+		output = []byte(NewDrawIOGenerator(style).Generate(infra))
+	default:
+		return nil, "", fmt.Errorf("%w %q: use %s", ErrUnknownFormat, format, strings.Join(Formats, ", "))
+	}
+	return output, ContentType(format), nil
+}
@@ -0,0 +1,86 @@
+package gorph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	infra := &Infrastructure{
+		Entities: []Entity{
+			{ID: "API", Category: "BACKEND", Description: "d", Status: "healthy"},
+			{ID: "DB", Category: "DATABASE", Description: "d", Status: "healthy"},
+		},
+		Connections: []Connection{{From: "API", To: "DB", Type: "DB_Connection"}},
+	}
+
+	tests := []struct {
+		format      string
+		engine      string
+		contentType string
+		contains    string
+	}{
+		{FormatDOT, "", "text/vnd.graphviz", "digraph"},
+		{FormatSVG, EngineNative, "image/svg+xml", "<svg"},
+		{FormatMermaid, "", "text/vnd.mermaid", "flowchart"},
+		{FormatPlantUML, "", "text/plain; charset=utf-8", "@startuml"},
+		{FormatC4, "", "text/plain; charset=utf-8", "C4_Container"},
+		{FormatDrawIO, "", "application/vnd.jgraph.mxfile", "<mxfile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out, contentType, err := Render(context.Background(), infra, DefaultStyle(), tt.format, RenderOptions{Engine: tt.engine})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if contentType != tt.contentType {
+				t.Errorf("content type = %q, want %q", contentType, tt.contentType)
+			}
+			if !strings.Contains(string(out), tt.contains) {
+				t.Errorf("output lacks %q:\n%s", tt.contains, out)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	infra := &Infrastructure{Entities: []Entity{{ID: "API", Category: "BACKEND", Description: "d", Status: "healthy"}}}
+
+	tests := []struct {
+		name   string
+		format string
+		engine string
+		err    error
+	}{
+		{"unknown format", "gif", "", ErrUnknownFormat},
+		{"no graphviz", FormatPNG, "", ErrGraphvizNotFound},
+		{"no graphviz for svg", FormatSVG, EngineGraphviz, ErrGraphvizNotFound},
+		{"native png", FormatPNG, EngineNative, nil},
+		{"unknown engine", FormatSVG, "cairo", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Render(context.Background(), infra, DefaultStyle(), tt.format, RenderOptions{Engine: tt.engine})
+			if err == nil {
+				t.Fatal("Render() succeeded, want an error")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestContentType(t *testing.T) {
+	for _, format := range Formats {
+		if ContentType(format) == "application/octet-stream" {
+			t.Errorf("format %s has no content type", format)
+		}
+	}
+	if got := ContentType("gif"); got != "application/octet-stream" {
+		t.Errorf("ContentType(gif) = %q, want application/octet-stream", got)
+	}
+}
@@ -0,0 +1,307 @@
+package gorph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// c4Elements maps categories to C4-PlantUML element macros. Categories not
+// listed, such as BACKEND or CI, are drawn as containers.
+var c4Elements = map[string]string{
+	"USER_FACING":    "Person",
+	"DATABASE":       "ContainerDb",
+	"REGISTRY":       "ContainerDb",
+	"CONFIG":         "ContainerDb",
+	"INTEGRATION":    "System_Ext",
+	"SCM":            "System_Ext",
+	"INFRASTRUCTURE": "System",
+	"ENVIRONMENT":    "System",
+}
+
+// plantumlReserved are element keywords and commands that PlantUML does not
+// accept as aliases.
+var plantumlReserved = []string{
+	"actor", "agent", "artifact", "boundary", "card", "circle", "cloud",
+	"collections", "component", "control", "database", "entity", "file",
+	"folder", "frame", "hexagon", "interface", "label", "node", "package",
+	"person", "queue", "rectangle", "stack", "storage", "usecase",
+	"as", "end", "hide", "show", "remove", "skinparam", "title", "note",
+	"legend", "together", "left", "right", "up", "down",
+}
+
+// plantumlEscaper makes text safe inside double-quoted PlantUML strings,
+// which have no escape for the quote character itself; it is written as
+// the Unicode escape <U+0022> instead. Backslashes and "<" are escaped the
+// same way, so that text such as `C:\new` or "<b>" is not read as a line
+// break or markup.
+var plantumlEscaper = strings.NewReplacer(
+	`"`, "<U+0022>",
+	`\`, "<U+005C>",
+	"<", "<U+003C>",
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// PlantUML Generator producing deployment or C4 diagrams
+type PlantUMLGenerator struct {
+	style *StyleConfig
+	c4    bool
+}
+
+// NewPlantUMLGenerator returns a generator for plain PlantUML deployment
+// diagrams with one package per category.
+func NewPlantUMLGenerator(style *StyleConfig) *PlantUMLGenerator {
+	return &PlantUMLGenerator{style: style}
+}
+
+// NewC4PlantUMLGenerator returns a generator for C4-PlantUML container
+// diagrams, mapping categories to C4 element types.
+func NewC4PlantUMLGenerator(style *StyleConfig) *PlantUMLGenerator {
+	return &PlantUMLGenerator{style: style, c4: true}
+}
+
+func (g *PlantUMLGenerator) Generate(infra *Infrastructure) string {
+	var sb strings.Builder
+	ids := newIDMapper(plantumlReserved...)
+	for _, entity := range infra.Entities {
+		ids.ID(entity.ID)
+	}
+
+	sb.WriteString("@startuml\n")
+	horizontal := strings.EqualFold(g.style.Graph.Direction, "LR") || strings.EqualFold(g.style.Graph.Direction, "RL")
+	if g.c4 {
+		sb.WriteString("!include <C4/C4_Container>\n")
+		if horizontal {
+			sb.WriteString("LAYOUT_LEFT_RIGHT()\n")
+		} else {
+			sb.WriteString("LAYOUT_TOP_DOWN()\n")
+		}
+		g.generateC4Tags(&sb, infra)
+	} else if horizontal {
+		sb.WriteString("left to right direction\n")
+	}
+	if font := g.style.Graph.FontFamily; font != "" && !g.c4 {
+		sb.WriteString(fmt.Sprintf("skinparam defaultFontName %s\n", font))
+	}
+	sb.WriteString("\n")
+
+	g.generateGroups(&sb, "", ids, groupEntities(infra.Entities, g.style.CategoryOrder))
+	sb.WriteString("\n")
+
+	for _, conn := range infra.Connections {
+		if g.c4 {
+			g.generateC4Rel(&sb, ids, conn)
+		} else {
+			g.generateRelation(&sb, ids, conn)
+		}
+	}
+
+	sb.WriteString("@enduml\n")
+	return sb.String()
+}
+
+// generateGroups writes a package or boundary per category, within one per
+// namespace.
+func (g *PlantUMLGenerator) generateGroups(sb *strings.Builder, indent string, ids *idMapper, group *namespaceGroup) {
+	for _, category := range group.Categories {
+		inner := indent
+		clustered := !g.style.Categories[category.Category].NoCluster
+		if clustered {
+			g.openCluster(sb, indent, ids.ID("cluster_"+categoryCluster(group.Name, category.Category)), categoryDisplayName(g.style, category.Category))
+			inner += "  "
+		}
+
+		for _, entity := range category.Entities {
+			if g.c4 {
+				g.generateC4Element(sb, inner, ids, entity)
+			} else {
+				g.generateComponent(sb, inner, ids, entity)
+			}
+		}
+
+		if clustered {
+			sb.WriteString(indent + "}\n")
+		}
+	}
+
+	for _, nested := range group.Namespaces {
+		if g.style.Namespaces[nested.Name].NoCluster {
+			g.generateGroups(sb, indent, ids, nested)
+			continue
+		}
+		g.openCluster(sb, indent, ids.ID("cluster_"+namespaceCluster(nested.Name)), namespaceDisplayName(g.style, nested.Name))
+		g.generateGroups(sb, indent+"  ", ids, nested)
+		sb.WriteString(indent + "}\n")
+	}
+}
+
+func (g *PlantUMLGenerator) openCluster(sb *strings.Builder, indent, alias, label string) {
+	label = plantumlEscaper.Replace(label)
+	if g.c4 {
+		sb.WriteString(fmt.Sprintf("%sBoundary(%s, \"%s\") {\n", indent, alias, label))
+	} else {
+		sb.WriteString(fmt.Sprintf("%spackage \"%s\" as %s {\n", indent, label, alias))
+	}
+}
+
+// generateComponent writes an entity as a deployment element, with its
+// owner and environment as stereotypes and its status as the line color.
+func (g *PlantUMLGenerator) generateComponent(sb *strings.Builder, indent string, ids *idMapper, entity Entity) {
+	element := "component"
+	switch resolveShape(g.style, entity.Shape).Shape {
+	case "cylinder":
+		element = "database"
+	case "cds":
+		element = "queue"
+	case "folder":
+		element = "folder"
+	case "note":
+		element = "file"
+	}
+
+	var stereotypes string
+	for _, value := range []string{entity.Owner, entity.Environment} {
+		if value != "" {
+			stereotypes += fmt.Sprintf(" <<%s>>", strings.NewReplacer("<", "", ">", "").Replace(value))
+		}
+	}
+
+	var color string
+	if c := statusColor(g.style, entity.Status); c != "" {
+		color = fmt.Sprintf(" #line:%s;line.bold", strings.TrimPrefix(c, "#"))
+	}
+
+	sb.WriteString(fmt.Sprintf("%s%s \"%s\" as %s%s%s\n",
+		indent, element, plantumlEscaper.Replace(g.entityLabel(entity)), ids.ID(entity.ID), stereotypes, color))
+}
+
+func (g *PlantUMLGenerator) entityLabel(entity Entity) string {
+	label := "**" + entityLabel(g.style, entity.ID) + "**\n" +
+		truncateRunes(entity.Description, g.style.Node.MaxDescriptionLength, g.style.Node.TruncationSuffix)
+	for _, attr := range displayedAttributes(g.style, entity) {
+		label += fmt.Sprintf("\n//%s//: %s", attr.Key,
+			truncateRunes(attr.Value, g.style.Node.MaxDescriptionLength, g.style.Node.TruncationSuffix))
+	}
+	return label
+}
+
+func (g *PlantUMLGenerator) generateRelation(sb *strings.Builder, ids *idMapper, conn Connection) {
+	var attrs []string
+	if style, ok := g.style.ConnectionStyles[conn.Type]; ok {
+		if style.Color != "" {
+			attrs = append(attrs, "#"+strings.TrimPrefix(style.Color, "#"))
+		}
+		switch style.Style {
+		case "dashed", "dotted", "bold":
+			attrs = append(attrs, style.Style)
+		}
+	}
+
+	arrow := "-->"
+	if len(attrs) > 0 {
+		arrow = fmt.Sprintf("-[%s]->", strings.Join(attrs, ","))
+	}
+
+	sb.WriteString(fmt.Sprintf("%s %s %s", ids.ID(conn.From), arrow, ids.ID(conn.To)))
+	if conn.Type != "" {
+		sb.WriteString(" : " + plantumlEscaper.Replace(conn.Type))
+	}
+	sb.WriteString("\n")
+}
+
+// generateC4Tags defines one element tag per status color and one
+// relationship tag per styled connection type in use.
+func (g *PlantUMLGenerator) generateC4Tags(sb *strings.Builder, infra *Infrastructure) {
+	statuses := make(map[string]bool)
+	for _, entity := range infra.Entities {
+		statuses[g.statusTag(entity.Status)] = true
+	}
+	var names []string
+	for status := range statuses {
+		names = append(names, status)
+	}
+	sort.Strings(names)
+	for _, status := range names {
+		if color := g.style.StatusColors[status]; color != "" {
+			sb.WriteString(fmt.Sprintf("AddElementTag(\"%s\", $borderColor=\"%s\")\n", status, color))
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, conn := range infra.Connections {
+		style, ok := g.style.ConnectionStyles[conn.Type]
+		if !ok || seen[conn.Type] {
+			continue
+		}
+		seen[conn.Type] = true
+
+		args := []string{fmt.Sprintf("\"%s\"", plantumlEscaper.Replace(conn.Type))}
+		if style.Color != "" {
+			args = append(args, fmt.Sprintf("$lineColor=\"%s\"", style.Color), fmt.Sprintf("$textColor=\"%s\"", style.Color))
+		}
+		switch style.Style {
+		case "dashed":
+			args = append(args, "$lineStyle=DashedLine()")
+		case "dotted":
+			args = append(args, "$lineStyle=DottedLine()")
+		case "bold":
+			args = append(args, "$lineStyle=BoldLine()")
+		}
+		sb.WriteString(fmt.Sprintf("AddRelTag(%s)\n", strings.Join(args, ", ")))
+	}
+}
+
+func (g *PlantUMLGenerator) statusTag(status string) string {
+	status = strings.ToLower(status)
+	if _, ok := g.style.StatusColors[status]; !ok {
+		return "unknown"
+	}
+	return status
+}
+
+func (g *PlantUMLGenerator) generateC4Element(sb *strings.Builder, indent string, ids *idMapper, entity Entity) {
+	macro, ok := c4Elements[entity.Category]
+	if !ok {
+		macro = "Container"
+		switch resolveShape(g.style, entity.Shape).Shape {
+		case "cylinder":
+			macro = "ContainerDb"
+		case "cds":
+			macro = "ContainerQueue"
+		}
+	}
+
+	descr := truncateRunes(entity.Description, g.style.Node.MaxDescriptionLength, g.style.Node.TruncationSuffix)
+	args := []string{
+		ids.ID(entity.ID),
+		fmt.Sprintf("\"%s\"", plantumlEscaper.Replace(entityLabel(g.style, entity.ID))),
+	}
+	if techn := technology(entity); techn != "" && strings.HasPrefix(macro, "Container") {
+		args = append(args, fmt.Sprintf("$techn=\"%s\"", plantumlEscaper.Replace(techn)))
+	}
+	if descr != "" {
+		args = append(args, fmt.Sprintf("$descr=\"%s\"", plantumlEscaper.Replace(descr)))
+	}
+	args = append(args, fmt.Sprintf("$tags=\"%s\"", g.statusTag(entity.Status)))
+
+	sb.WriteString(fmt.Sprintf("%s%s(%s)\n", indent, macro, strings.Join(args, ", ")))
+}
+
+func (g *PlantUMLGenerator) generateC4Rel(sb *strings.Builder, ids *idMapper, conn Connection) {
+	args := []string{ids.ID(conn.From), ids.ID(conn.To), fmt.Sprintf("\"%s\"", plantumlEscaper.Replace(conn.Type))}
+	if _, ok := g.style.ConnectionStyles[conn.Type]; ok {
+		args = append(args, fmt.Sprintf("$tags=\"%s\"", plantumlEscaper.Replace(conn.Type)))
+	}
+	sb.WriteString(fmt.Sprintf("Rel(%s)\n", strings.Join(args, ", ")))
+}
+
+// technology picks the attribute describing what an entity is built with.
+func technology(entity Entity) string {
+	for _, key := range []string{"technology", "framework", "language", "engine", "type"} {
+		if value := entity.Attributes[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package gorph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlantUMLGolden(t *testing.T) {
+	for _, name := range examples {
+		t.Run(name, func(t *testing.T) {
+			infra := loadExample(t, name)
+			checkGolden(t, name+".puml", NewPlantUMLGenerator(DefaultStyle()).Generate(infra))
+			checkGolden(t, name+".c4.puml", NewC4PlantUMLGenerator(DefaultStyle()).Generate(infra))
+		})
+	}
+}
+
+// escapingInfra has labels, stereotypes and IDs that PlantUML would read as
+// syntax if written as they are.
+var escapingInfra = &Infrastructure{
+	Entities: []Entity{
+		{ID: "package", Category: "BACKEND", Description: `say "hi" \ <b>bye</b>`, Status: "healthy",
+			Owner: `team "a" >> b`, Environment: "prod<<x>>"},
+		{ID: "a-b", Category: "DATABASE", Description: "line\nbreak\r\nand more", Status: "down"},
+		{ID: "a_b", Category: "USER_FACING", Description: "It's", Status: "healthy"},
+		{ID: "Ext", Category: "INTEGRATION", Description: "x, y", Status: "unknown"},
+	},
+	Connections: []Connection{
+		{From: "package", To: "a-b", Type: `reads "all" : x`},
+		{From: "a_b", To: "package", Type: "User_Interaction"},
+		{From: "package", To: "Ext", Type: ""},
+	},
+}
+
+func TestPlantUMLEscapingGolden(t *testing.T) {
+	checkGolden(t, "escaping.puml", NewPlantUMLGenerator(DefaultStyle()).Generate(escapingInfra))
+	checkGolden(t, "escaping.c4.puml", NewC4PlantUMLGenerator(DefaultStyle()).Generate(escapingInfra))
+}
+
+func TestPlantUMLEscaping(t *testing.T) {
+	infra := &Infrastructure{
+		Entities: []Entity{
+			{ID: "node", Category: "BACKEND", Description: `say "hi"`, Status: "healthy"},
+			{ID: "Store", Category: "DATABASE", Description: "Data", Status: "healthy"},
+		},
+		Connections: []Connection{{From: "node", To: "Store", Type: `x"y`}},
+	}
+	out := NewPlantUMLGenerator(DefaultStyle()).Generate(infra)
+
+	for _, want := range []string{
+		`component "**node**\nsay <U+0022>hi<U+0022>" as n_node`,
+		"n_node --> Store : x<U+0022>y",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestC4Elements(t *testing.T) {
+	infra := &Infrastructure{Entities: []Entity{
+		{ID: "User", Category: "USER_FACING", Description: "d", Status: "healthy"},
+		{ID: "API", Category: "BACKEND", Description: "d", Status: "healthy"},
+		{ID: "Registry", Category: "REGISTRY", Description: "d", Status: "healthy"},
+		{ID: "Git", Category: "SCM", Description: "d", Status: "healthy"},
+		{ID: "Cluster", Category: "ENVIRONMENT", Description: "d", Status: "healthy"},
+	}}
+	out := NewC4PlantUMLGenerator(DefaultStyle()).Generate(infra)
+
+	for _, want := range []string{
+		`Person(User, "User"`,
+		`Container(API, "API"`,
+		`ContainerDb(Registry, "Registry"`,
+		`System_Ext(Git, "Git"`,
+		`System(Cluster, "Cluster"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package gorph
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Helpers shared by the DOT generator and the native exporters, so every
+// output format labels, colors and truncates entities the same way.
+
+// categoryDisplayName returns the configured display name of a category.
+func categoryDisplayName(style *StyleConfig, category string) string {
+	if config, exists := style.Categories[category]; exists && config.DisplayName != "" {
+		return config.DisplayName
+	}
+	// Fallback to title case transformation
+	return strings.Title(strings.ToLower(category))
+}
+
+// generateTooltip builds the hover text of an entity from the tooltip
+// settings.
+func generateTooltip(style *StyleConfig, entity Entity) string {
+	var parts []string
+
+	parts = append(parts, fmt.Sprintf("%s: %s", entity.ID, entity.Description))
+
+	if style.Tooltip.IncludeStatus {
+		parts = append(parts, fmt.Sprintf("Status: %s", entity.Status))
+	}
+
+	if style.Tooltip.IncludeOwner {
+		parts = append(parts, fmt.Sprintf("Owner: %s", entity.Owner))
+	}
+
+	if style.Tooltip.IncludeEnvironment && entity.Environment != "" {
+		parts = append(parts, fmt.Sprintf("Environment: %s", entity.Environment))
+	}
+
+	if style.Tooltip.IncludeTags && len(entity.Tags) > 0 {
+		parts = append(parts, fmt.Sprintf("Tags: %v", entity.Tags))
+	}
+
+	if style.Tooltip.IncludeDeployment && len(entity.DeploymentConfig) > 0 {
+		deploymentYAML, _ := yaml.Marshal(entity.DeploymentConfig)
+		parts = append(parts, fmt.Sprintf("Deployment:\n%s", string(deploymentYAML)))
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// statusColor returns the color of a status, falling back to "unknown".
+func statusColor(style *StyleConfig, status string) string {
+	if color, exists := style.StatusColors[strings.ToLower(status)]; exists {
+		return color
+	}
+	return style.StatusColors["unknown"]
+}
+
+// truncateRunes shortens s to max characters and appends suffix when it
+// was cut.
+func truncateRunes(s string, max int, suffix string) string {
+	runes := []rune(s)
+	if len(runes) > max {
+		return string(runes[:max]) + suffix
+	}
+	return s
+}
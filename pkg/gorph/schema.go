@@ -0,0 +1,237 @@
+package gorph
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// jsonSchema is the subset of JSON Schema (draft 2020-12) that the schemas
+// generated from the model use, and that checkSchema enforces.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	AnyOf                []*jsonSchema          `json:"anyOf,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	MinLength            int                    `json:"minLength,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	MinItems             int                    `json:"minItems,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	PatternProperties    map[string]*jsonSchema `json:"patternProperties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"` // false or *jsonSchema
+	Required             []string               `json:"required,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// fieldDoc annotates a model field in the generated schemas.
+type fieldDoc struct {
+	description string
+	required    bool
+	// values are the known values, offered by editors but not enforced,
+	// since custom values are rendered with the default style
+	values  []string
+	pattern string
+}
+
+// Entity IDs are names, optionally qualified by namespaces; references to
+// entities may start with "/" to refer to the root namespace.
+const (
+	namePattern      = "[A-Za-z][A-Za-z0-9_-]*"
+	entityIDPattern  = "^" + namePattern + "(/" + namePattern + ")*$"
+	referencePattern = "^/?" + namePattern + "(/" + namePattern + ")*$"
+)
+
+// schemaDocs annotates fields by "Type.key".
+var schemaDocs = map[string]fieldDoc{
+	"Infrastructure.name":        {description: "Human-readable name"},
+	"Infrastructure.description": {description: "What the infrastructure does"},
+	"Infrastructure.admins":      {description: "API teams, and users written as user:<subject>, with full access to the infrastructure"},
+	"Infrastructure.namespace":   {description: "Namespace the entities of this file are declared in, e.g. payments; connections refer to entities relative to it", pattern: entityIDPattern},
+	"Infrastructure.include":     {description: "Files whose entities and connections are merged into this one: paths or glob patterns, relative to this file"},
+	"Infrastructure.entities":    {description: "Components of the infrastructure; a file with includes may have none of its own"},
+	"Infrastructure.connections": {description: "Relationships between entities"},
+
+	"Entity.id":                {description: "Unique identifier within the file's namespace, referenced by connections", required: true, pattern: entityIDPattern},
+	"Entity.category":          {description: "Category the entity is grouped by", required: true},
+	"Entity.description":       {description: "Human-readable description", required: true},
+	"Entity.status":            {description: "Operational status, shown as the status bar color", required: true},
+	"Entity.owner":             {description: "Team or person responsible"},
+	"Entity.environment":       {description: "Deployment environment, e.g. production"},
+	"Entity.tags":              {description: "Tags for grouping and filtering"},
+	"Entity.attributes":        {description: "Free-form key/value pairs; list values are joined with \", \""},
+	"Entity.deployment_config": {description: "Deployment configuration such as replicas, image or env vars"},
+	"Entity.shape":             {description: "Shape name mapped by the style's node.shapes, or a Graphviz shape"},
+	"Entity.icon":              {description: "Image file, looked up in the style's node.icon_dir"},
+
+	"Connection.from":       {description: "ID of the source entity, relative to the file's namespace", required: true, pattern: referencePattern},
+	"Connection.to":         {description: "ID of the target entity, relative to the file's namespace", required: true, pattern: referencePattern},
+	"Connection.type":       {description: "Connection type, styled by the style's connection_styles", required: true},
+	"Connection.attributes": {description: "Free-form key/value pairs"},
+
+	"StyleConfig.namespaces":            {description: "Cluster styles of entity namespaces, keyed by namespace such as payments/ledger"},
+	"GraphConfig.direction":             {description: "Layout direction", values: []string{"LR", "TB", "RL", "BT"}},
+	"ConnectionStyle.style":             {description: "Graphviz edge style", values: []string{"solid", "dashed", "dotted", "bold", "invis"}},
+	"CategoryConfig.cluster_style":      {description: "Graphviz cluster style, e.g. \"rounded,dashed\""},
+	"CategoryConfig.label_position":     {description: "Cluster label position", values: []string{"top", "top-left", "top-center", "top-right", "bottom", "bottom-left", "bottom-center", "bottom-right"}},
+	"CategoryConfig.no_cluster":         {description: "Render the category's entities without a surrounding box"},
+	"NodeConfig.shapes":                 {description: "Maps entity shape names to Graphviz shapes, e.g. database: cylinder"},
+	"NodeConfig.icon_dir":               {description: "Directory searched for entity icons, relative to the style file"},
+	"NodeConfig.icon_size":              {description: "Icon cell size in points; 0 uses the image size"},
+	"NodeConfig.show_attributes":        {description: "Attribute keys rendered as extra rows; \"*\" shows all"},
+	"NodeConfig.max_description_length": {description: "Descriptions longer than this are truncated"},
+}
+
+// knownValues returns the values editors should offer for a field, taken
+// from the built-in style.
+func knownValues(key string) []string {
+	style := DefaultStyle()
+	switch key {
+	case "Entity.category":
+		return style.CategoryOrder
+	case "Entity.status":
+		return []string{"healthy", "degraded", "down", "unknown"}
+	case "Connection.type":
+		types := make([]string, 0, len(style.ConnectionStyles))
+		for name := range style.ConnectionStyles {
+			types = append(types, name)
+		}
+		sort.Strings(types)
+		return types
+	}
+	return schemaDocs[key].values
+}
+
+// Types whose YAML form differs from their Go type.
+var schemaOverrides = map[reflect.Type]func() *jsonSchema{
+	reflect.TypeOf(Attributes{}): func() *jsonSchema {
+		scalar := []*jsonSchema{{Type: "string"}, {Type: "number"}, {Type: "boolean"}}
+		return &jsonSchema{
+			Type:                 "object",
+			AdditionalProperties: &jsonSchema{AnyOf: append(scalar, &jsonSchema{Type: "array", Items: &jsonSchema{AnyOf: scalar}})},
+		}
+	},
+}
+
+// schemaGenerator builds a JSON Schema from model types, with one $defs
+// entry per struct type.
+type schemaGenerator struct {
+	defs map[string]*jsonSchema
+	// extensible lists the types that also accept "x-" extension keys
+	extensible map[reflect.Type]bool
+}
+
+func generateSchema(t reflect.Type, title, description string, extensible ...reflect.Type) *jsonSchema {
+	g := &schemaGenerator{defs: make(map[string]*jsonSchema), extensible: make(map[reflect.Type]bool)}
+	for _, e := range extensible {
+		g.extensible[e] = true
+	}
+	root := g.structSchema(t)
+	root.Schema = "https://json-schema.org/draft/2020-12/schema"
+	root.Title = title
+	root.Description = description
+	root.Defs = g.defs
+	return root
+}
+
+func (g *schemaGenerator) typeSchema(t reflect.Type) *jsonSchema {
+	if override, ok := schemaOverrides[t]; ok {
+		return override()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return &jsonSchema{Type: "object"}
+		}
+		return &jsonSchema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // guards against recursion
+			g.defs[t.Name()] = g.structSchema(t)
+		}
+		return &jsonSchema{Ref: "#/$defs/" + t.Name()}
+	}
+	return &jsonSchema{}
+}
+
+// structSchema describes a struct by its yaml field names. Unknown keys are
+// rejected, so that misspelled keys are reported instead of ignored.
+func (g *schemaGenerator) structSchema(t reflect.Type) *jsonSchema {
+	s := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema), AdditionalProperties: false}
+	if g.extensible[t] {
+		s.PatternProperties = map[string]*jsonSchema{"^x-": {}}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		key := t.Name() + "." + name
+		doc := schemaDocs[key]
+		property := g.typeSchema(field.Type)
+		if doc.required && property.Type == "string" {
+			property.MinLength = 1
+		}
+		if doc.required && property.Type == "array" {
+			property.MinItems = 1
+		}
+		property.Pattern = doc.pattern
+		if values := knownValues(key); len(values) > 0 {
+			property = &jsonSchema{AnyOf: []*jsonSchema{{Enum: values}, property}}
+		}
+		property.Description = doc.description
+
+		s.Properties[name] = property
+		if doc.required {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+var (
+	infrastructureSchema = generateSchema(reflect.TypeOf(Infrastructure{}),
+		"Gorph infrastructure",
+		"Entities and connections of an infrastructure diagram. Keys starting with \"x-\" are ignored.",
+		reflect.TypeOf(Infrastructure{}), reflect.TypeOf(Entity{}), reflect.TypeOf(Connection{}))
+	styleSchema = generateSchema(reflect.TypeOf(StyleConfig{}),
+		"Gorph style",
+		"Visual styling of Gorph diagrams.")
+)
+
+// InfrastructureSchema returns the JSON Schema of infrastructure definition
+// files, for editors and other tools. Parsing checks definitions against
+// it.
+func InfrastructureSchema() []byte {
+	return marshalSchema(infrastructureSchema)
+}
+
+// StyleSchema returns the JSON Schema of style configuration files.
+func StyleSchema() []byte {
+	return marshalSchema(styleSchema)
+}
+
+func marshalSchema(s *jsonSchema) []byte {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return append(data, '\n')
+}
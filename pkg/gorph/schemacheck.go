@@ -0,0 +1,261 @@
+package gorph
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// checkSchema checks a parsed document against a schema and returns every
+// problem at the position of the offending node. Paths use the dotted form
+// of ValidationError.Field. Unknown fields, which were ignored before
+// documents were checked, are returned as warnings rather than errors.
+func checkSchema(s *jsonSchema, root *yaml.Node, file string) (errs, warnings ValidationErrors) {
+	if root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			return nil, nil
+		}
+		root = root.Content[0]
+	}
+	c := &schemaChecker{root: s, file: file}
+	c.check(s, root, "")
+	return c.errs, c.warnings
+}
+
+type schemaChecker struct {
+	root     *jsonSchema
+	file     string
+	errs     ValidationErrors
+	warnings ValidationErrors
+}
+
+func (c *schemaChecker) report(node *yaml.Node, path, format string, args ...interface{}) {
+	c.errs = append(c.errs, c.problem(node, path, format, args...))
+}
+
+func (c *schemaChecker) warn(node *yaml.Node, path, format string, args ...interface{}) {
+	c.warnings = append(c.warnings, c.problem(node, path, format, args...))
+}
+
+func (c *schemaChecker) problem(node *yaml.Node, path, format string, args ...interface{}) ValidationError {
+	subject := path
+	if subject == "" {
+		subject = "document"
+	}
+	return ValidationError{
+		Pos:     Position{File: c.file, Line: node.Line, Column: node.Column},
+		Field:   path,
+		Message: subject + ": " + fmt.Sprintf(format, args...),
+	}
+}
+
+func (c *schemaChecker) check(s *jsonSchema, node *yaml.Node, path string) {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if s.Ref != "" {
+		s = c.root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+	}
+
+	kind := nodeType(node)
+	if len(s.AnyOf) > 0 {
+		var last *schemaChecker
+		var types []string
+		for _, alternative := range s.AnyOf {
+			sub := &schemaChecker{root: c.root, file: c.file}
+			sub.check(alternative, node, path)
+			if len(sub.errs) == 0 {
+				c.warnings = append(c.warnings, sub.warnings...)
+				return
+			}
+			last = sub
+			if alternative.Type != "" && alternative.Type != kind {
+				types = append(types, article(alternative.Type))
+			}
+		}
+		if len(types) == len(s.AnyOf) {
+			c.report(node, path, "expected %s or %s, got %s", strings.Join(types[:len(types)-1], ", "), types[len(types)-1], article(kind))
+			return
+		}
+		// Otherwise report the last alternative, which the generated
+		// schemas make the most general
+		c.errs = append(c.errs, last.errs...)
+		c.warnings = append(c.warnings, last.warnings...)
+		return
+	}
+
+	if s.Type != "" && s.Type != kind && !(s.Type == "number" && kind == "integer") {
+		c.report(node, path, "expected %s, got %s", article(s.Type), article(kind))
+		return
+	}
+	if len(s.Enum) > 0 && (node.Kind != yaml.ScalarNode || !contains(s.Enum, node.Value)) {
+		c.report(node, path, "must be one of %s", strings.Join(s.Enum, ", "))
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if kind != "string" {
+			break
+		}
+		if s.MinLength > 0 && len([]rune(node.Value)) < s.MinLength {
+			c.report(node, path, "must not be empty")
+		}
+		if s.Pattern != "" && !compilePattern(s.Pattern).MatchString(node.Value) {
+			c.report(node, path, "%q does not match %s", node.Value, s.Pattern)
+		}
+	case yaml.SequenceNode:
+		if len(node.Content) < s.MinItems {
+			c.report(node, path, "must have at least %d item(s)", s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range node.Content {
+				c.check(s.Items, item, joinPath(path, strconv.Itoa(i)))
+			}
+		}
+	case yaml.MappingNode:
+		c.checkMapping(s, node, path)
+	}
+}
+
+func (c *schemaChecker) checkMapping(s *jsonSchema, node *yaml.Node, path string) {
+	present := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == "<<" {
+			// Merge keys are resolved by the YAML decoder
+			continue
+		}
+		present[key.Value] = true
+		keyPath := joinPath(path, key.Value)
+
+		if property, ok := s.Properties[key.Value]; ok {
+			c.check(property, value, keyPath)
+			continue
+		}
+		matched := false
+		for pattern, property := range s.PatternProperties {
+			if compilePattern(pattern).MatchString(key.Value) {
+				c.check(property, value, keyPath)
+				matched = true
+			}
+		}
+		switch additional := s.AdditionalProperties.(type) {
+		case bool:
+			if !additional && !matched {
+				c.warn(key, keyPath, "unknown field%s", suggestField(key.Value, s.Properties))
+			}
+		case *jsonSchema:
+			if !matched {
+				c.check(additional, value, keyPath)
+			}
+		}
+	}
+	for _, name := range s.Required {
+		if !present[name] {
+			c.report(node, joinPath(path, name), "required field is missing")
+		}
+	}
+}
+
+// nodeType returns the JSON type of a YAML node. Timestamps and binary
+// values are strings in JSON.
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+	return "string"
+}
+
+func article(jsonType string) string {
+	switch jsonType {
+	case "array", "integer", "object":
+		return "an " + jsonType
+	case "null":
+		return "null"
+	}
+	return "a " + jsonType
+}
+
+// suggestField names the known field closest to a misspelled one.
+func suggestField(name string, properties map[string]*jsonSchema) string {
+	best, bestDistance := "", 3
+	names := make([]string, 0, len(properties))
+	for known := range properties {
+		names = append(names, known)
+	}
+	sort.Strings(names)
+	for _, known := range names {
+		if d := editDistance(strings.ToLower(name), known); d < bestDistance {
+			best, bestDistance = known, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var patterns sync.Map // pattern -> *regexp.Regexp
+
+func compilePattern(pattern string) *regexp.Regexp {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(pattern)
+	patterns.Store(pattern, re)
+	return re
+}
@@ -0,0 +1,102 @@
+package gorph
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnknownFieldsWarn(t *testing.T) {
+	infra, err := ParseInfrastructure([]byte(`bogus: 1
+x-anchors: {}
+entities:
+  - id: API
+    catgory: BACKEND
+    category: BACKEND
+    description: Service
+    status: healthy
+connections: []
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := Validate(infra); len(errs) > 0 {
+		t.Errorf("Validate() = %v, want no errors", errs)
+	}
+
+	want := []string{
+		"1:1: bogus: unknown field",
+		"5:5: entities.0.catgory: unknown field (did you mean \"category\"?)",
+	}
+	got := infra.Warnings().Strings()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Warnings() = %q, want %q", got, want)
+	}
+}
+
+func TestUnknownFieldsWarnInIncludes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.yml": `include: [team.yml]
+entities:
+  - id: API
+    category: BACKEND
+    description: Service
+    status: healthy
+connections: []
+`,
+		"team.yml": `entities:
+  - id: DB
+    category: DATABASE
+    description: Store
+    status: healthy
+    owners: team
+connections: []
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	infra, err := LoadInfrastructure(filepath.Join(dir, "main.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnings := infra.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("Warnings() = %v, want one", warnings)
+	}
+	if got, want := warnings[0].Field, "entities.1.owners"; got != want {
+		t.Errorf("field = %q, want %q", got, want)
+	}
+	if got, want := warnings[0].Pos.String(), filepath.Join(dir, "team.yml")+":6:5"; got != want {
+		t.Errorf("position = %q, want %q", got, want)
+	}
+}
+
+func TestStyleUnknownSettings(t *testing.T) {
+	const style = `graph:
+  direction: TB
+  colour: red
+`
+	config, err := ParseStyleConfig([]byte(style))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Graph.Direction != "TB" {
+		t.Errorf("direction = %q, want TB", config.Graph.Direction)
+	}
+	if got := config.Warnings().Strings(); len(got) != 1 || !strings.HasPrefix(got[0], "3:3: graph.colour: unknown field") {
+		t.Errorf("Warnings() = %q, want graph.colour", got)
+	}
+
+	// Overrides are checked strictly
+	if _, err := OverrideStyleConfig(DefaultStyle(), []byte(style)); err == nil {
+		t.Error("OverrideStyleConfig() accepted an unknown setting")
+	}
+	if _, err := ParseStyleConfig([]byte("graph:\n  direction: [TB]\n")); err == nil {
+		t.Error("ParseStyleConfig() accepted a value of the wrong type")
+	}
+}
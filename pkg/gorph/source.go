@@ -0,0 +1,115 @@
+package gorph
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position identifies a location in an infrastructure source file.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// IsValid reports whether the position carries line information.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	switch {
+	case p.File != "" && p.IsValid():
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	case p.File != "":
+		return p.File
+	case p.IsValid():
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return ""
+}
+
+// sourceMap records where each entity, connection and field of an
+// infrastructure definition was declared, keyed by a dotted path such as
+// "entities.3.id" or "connections.0". Positions of merged included files
+// carry their own file name.
+type sourceMap struct {
+	file      string
+	positions map[string]Position
+	// indices maps the paths of merged entities and connections, such as
+	// "connections.5", to their index in the file that declared them
+	indices map[string]int
+}
+
+func newSourceMap(file string, root *yaml.Node) *sourceMap {
+	sm := &sourceMap{file: file, positions: make(map[string]Position)}
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	sm.record("", root)
+	if root.Kind != yaml.MappingNode {
+		return sm
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		if key != "entities" && key != "connections" && key != "include" {
+			continue
+		}
+		sm.record(key, value)
+		if value.Kind != yaml.SequenceNode {
+			continue
+		}
+		for j, item := range value.Content {
+			itemPath := key + "." + strconv.Itoa(j)
+			sm.record(itemPath, item)
+			if item.Kind != yaml.MappingNode {
+				continue
+			}
+			for k := 0; k+1 < len(item.Content); k += 2 {
+				sm.record(itemPath+"."+item.Content[k].Value, item.Content[k+1])
+			}
+		}
+	}
+	return sm
+}
+
+func (sm *sourceMap) record(path string, node *yaml.Node) {
+	sm.positions[path] = Position{File: sm.file, Line: node.Line, Column: node.Column}
+}
+
+// lookup returns the position of path, falling back to the closest
+// enclosing node when the exact path was not declared in the source.
+func (sm *sourceMap) lookup(path string) Position {
+	if sm == nil {
+		return Position{}
+	}
+	for {
+		if pos, ok := sm.positions[path]; ok {
+			return pos
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	if pos, ok := sm.positions[""]; ok {
+		return pos
+	}
+	return Position{File: sm.file}
+}
+
+// index returns the index that the entity or connection at path, the i-th
+// of its list, has in the file that declared it.
+func (sm *sourceMap) index(path string, i int) int {
+	if sm != nil {
+		if local, ok := sm.indices[path]; ok {
+			return local
+		}
+	}
+	return i
+}
@@ -0,0 +1,155 @@
+package gorph
+
+// Style configuration structures
+type GraphConfig struct {
+	Direction  string `yaml:"direction"`
+	FontFamily string `yaml:"font_family"`
+	NodeShape  string `yaml:"node_shape"`
+}
+
+type ConnectionStyle struct {
+	Style string `yaml:"style"`
+	Color string `yaml:"color"`
+}
+
+type CategoryConfig struct {
+	DisplayName string `yaml:"display_name"`
+	// ClusterStyle is the Graphviz cluster style, e.g. "rounded,dashed"
+	ClusterStyle string `yaml:"cluster_style"`
+	FillColor    string `yaml:"fill_color"`
+	BorderColor  string `yaml:"border_color"`
+	BorderWidth  int    `yaml:"border_width"`
+	FontName     string `yaml:"font_name"`
+	FontSize     int    `yaml:"font_size"`
+	FontColor    string `yaml:"font_color"`
+	// LabelPosition places the cluster label: top or bottom, optionally
+	// followed by left, center or right (e.g. "top-left")
+	LabelPosition string `yaml:"label_position"`
+	// NoCluster renders the category's entities without a surrounding box
+	NoCluster bool `yaml:"no_cluster"`
+}
+
+type NodeConfig struct {
+	MaxDescriptionLength int    `yaml:"max_description_length"`
+	TruncationSuffix     string `yaml:"truncation_suffix"`
+	BorderWidth          int    `yaml:"border_width"`
+	CellBorder           int    `yaml:"cell_border"`
+	CellSpacing          int    `yaml:"cell_spacing"`
+	StatusBarHeight      int    `yaml:"status_bar_height"`
+
+	// Shapes maps entity shape names to Graphviz shapes, e.g. database: cylinder
+	Shapes map[string]string `yaml:"shapes"`
+	// IconDir is searched for entity icons; relative paths are resolved
+	// against the style file's directory
+	IconDir string `yaml:"icon_dir"`
+	// IconSize is the icon cell size in points; 0 uses the image size
+	IconSize int `yaml:"icon_size"`
+	// ShowAttributes lists attribute keys rendered as extra rows; "*" shows all
+	ShowAttributes []string `yaml:"show_attributes"`
+}
+
+type TooltipConfig struct {
+	IncludeStatus      bool `yaml:"include_status"`
+	IncludeOwner       bool `yaml:"include_owner"`
+	IncludeEnvironment bool `yaml:"include_environment"`
+	IncludeTags        bool `yaml:"include_tags"`
+	IncludeDeployment  bool `yaml:"include_deployment"`
+}
+
+type StyleConfig struct {
+	Graph            GraphConfig                `yaml:"graph"`
+	StatusColors     map[string]string          `yaml:"status_colors"`
+	ConnectionStyles map[string]ConnectionStyle `yaml:"connection_styles"`
+	Categories       map[string]CategoryConfig  `yaml:"categories"`
+	CategoryOrder    []string                   `yaml:"category_order"`
+	// Namespaces styles the clusters of entity namespaces, keyed by
+	// namespace such as "payments/ledger"
+	Namespaces map[string]CategoryConfig `yaml:"namespaces"`
+	Node       NodeConfig                `yaml:"node"`
+	Tooltip    TooltipConfig             `yaml:"tooltip"`
+
+	// warnings are the unknown settings found when parsing
+	warnings ValidationErrors
+}
+
+// Warnings returns the unknown settings found when the style was parsed.
+// They are ignored, as they were before style files were checked against
+// the schema.
+func (s *StyleConfig) Warnings() ValidationErrors {
+	return s.warnings
+}
+
+// DefaultStyle returns the built-in style, matching the style.yml shipped
+// with the repository. It is used when no style file is available, e.g. in
+// the WASM build.
+func DefaultStyle() *StyleConfig {
+	return &StyleConfig{
+		Graph: GraphConfig{
+			Direction:  "LR",
+			FontFamily: "Helvetica",
+			NodeShape:  "plaintext",
+		},
+		StatusColors: map[string]string{
+			"healthy":  "green",
+			"degraded": "yellow",
+			"down":     "red",
+			"unknown":  "lightgray",
+		},
+		ConnectionStyles: map[string]ConnectionStyle{
+			"API_Call":         {Style: "dashed", Color: "orange"},
+			"Internal_API":     {Style: "dotted", Color: "gray"},
+			"DB_Connection":    {Color: "blue"},
+			"Service_Call":     {Color: "black"},
+			"HTTP_Request":     {Color: "black"},
+			"User_Interaction": {Style: "bold", Color: "purple"},
+			"Triggers_Build":   {Color: "darkgreen"},
+			"Pushes_Image":     {Color: "blue"},
+			"Updates_Config":   {Color: "orange"},
+			"Watches_Config":   {Color: "red"},
+			"Deploys_To":       {Color: "purple"},
+			"Deploys":          {Color: "purple"},
+			"Hosts":            {Color: "brown"},
+		},
+		Categories: map[string]CategoryConfig{
+			"USER_FACING":    {DisplayName: "User Facing"},
+			"FRONTEND":       {DisplayName: "Frontend"},
+			"BACKEND":        {DisplayName: "Backend"},
+			"DATABASE":       {DisplayName: "Database"},
+			"NETWORK":        {DisplayName: "Network"},
+			"INTEGRATION":    {DisplayName: "Integration"},
+			"INFRASTRUCTURE": {DisplayName: "Infrastructure"},
+			"INTERNAL":       {DisplayName: "Internal"},
+			"CI":             {DisplayName: "CI/CD"},
+			"REGISTRY":       {DisplayName: "Registry"},
+			"CONFIG":         {DisplayName: "Configuration"},
+			"CD":             {DisplayName: "Deployment"},
+			"ENVIRONMENT":    {DisplayName: "Environment"},
+			"SCM":            {DisplayName: "Source Control"},
+		},
+		CategoryOrder: []string{
+			"USER_FACING", "FRONTEND", "NETWORK", "BACKEND", "INTEGRATION",
+			"DATABASE", "INFRASTRUCTURE", "INTERNAL", "SCM", "CI",
+			"REGISTRY", "CONFIG", "CD", "ENVIRONMENT",
+		},
+		Node: NodeConfig{
+			MaxDescriptionLength: 24,
+			TruncationSuffix:     "...",
+			BorderWidth:          1,
+			CellBorder:           0,
+			CellSpacing:          0,
+			StatusBarHeight:      8,
+			Shapes: map[string]string{
+				"database": "cylinder",
+				"queue":    "cds",
+			},
+			IconSize: 32,
+		},
+		Tooltip: TooltipConfig{
+			IncludeStatus:      true,
+			IncludeOwner:       true,
+			IncludeEnvironment: true,
+			IncludeTags:        true,
+			IncludeDeployment:  true,
+		},
+	}
+}
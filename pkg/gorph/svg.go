@@ -0,0 +1,442 @@
+package gorph
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gorph/v2/pkg/layout"
+)
+
+// Metrics used to size SVG nodes. Text width is estimated from the average
+// glyph width of a sans-serif font, as no font data is available.
+const (
+	svgFontSize      = 14.0
+	svgSmallFontSize = 12.0
+	svgRowHeight     = 20.0
+	svgCellPadding   = 8.0
+	svgMinNodeWidth  = 80.0
+	svgCharWidth     = 0.56
+)
+
+// SVG Generator drawing diagrams natively, without Graphviz
+type SVGGenerator struct {
+	style *StyleConfig
+	icons *iconResolver
+}
+
+func NewSVGGenerator(style *StyleConfig) *SVGGenerator {
+	return &SVGGenerator{style: style, icons: newIconResolver(style.Node.IconDir)}
+}
+
+// svgNode is an entity with the rows of its table label.
+type svgNode struct {
+	entity     Entity
+	shape      nodeShape
+	icon       string
+	desc       string
+	attrs      []attributeRow
+	tableW     float64
+	tableH     float64
+	width      float64
+	height     float64
+	statusFill string
+}
+
+func (g *SVGGenerator) Generate(infra *Infrastructure) string {
+	root := groupEntities(infra.Entities, g.style.CategoryOrder)
+
+	graph := layout.Graph{Direction: g.style.Graph.Direction, Parents: clusterParents(g.style, root)}
+	var nodes []*svgNode
+	var clusters []svgCluster
+	root.walk(func(group *namespaceGroup) {
+		if group.Name != "" && !g.style.Namespaces[group.Name].NoCluster {
+			clusters = append(clusters, svgCluster{
+				name:   namespaceCluster(group.Name),
+				label:  namespaceDisplayName(g.style, group.Name),
+				config: g.style.Namespaces[group.Name],
+			})
+		}
+		for _, category := range group.Categories {
+			cluster := categoryCluster(group.Name, category.Category)
+			graph.Groups = append(graph.Groups, cluster)
+			clusters = append(clusters, svgCluster{
+				name:   cluster,
+				label:  categoryDisplayName(g.style, category.Category),
+				config: g.style.Categories[category.Category],
+			})
+			for _, entity := range category.Entities {
+				n := g.measureNode(entity)
+				nodes = append(nodes, n)
+				graph.Nodes = append(graph.Nodes, layout.Node{
+					ID:     entity.ID,
+					Width:  n.width,
+					Height: n.height,
+					Group:  cluster,
+				})
+			}
+		}
+	})
+	for _, conn := range infra.Connections {
+		graph.Edges = append(graph.Edges, layout.Edge{From: conn.From, To: conn.To})
+	}
+
+	res := layout.Layered(graph, layout.Options{})
+
+	var sb strings.Builder
+	fontFamily := g.style.Graph.FontFamily
+	if fontFamily == "" {
+		fontFamily = "Helvetica"
+	}
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	sb.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s" font-family="%s" font-size="%s">`+"\n",
+		num(res.Width), num(res.Height), num(res.Width), num(res.Height), escapeXML(fontFamily), num(svgFontSize)))
+
+	markers := g.edgeMarkers(infra.Connections)
+	sb.WriteString("  <defs>\n")
+	for _, color := range markers.colors {
+		sb.WriteString(fmt.Sprintf(`    <marker id="%s" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="%s"/></marker>`+"\n",
+			markers.ids[color], escapeXML(color)))
+	}
+	sb.WriteString("  </defs>\n")
+	sb.WriteString(`  <rect width="100%" height="100%" fill="white"/>` + "\n")
+
+	sb.WriteString(`  <g class="clusters">` + "\n")
+	for _, cluster := range clusters {
+		if box, ok := res.Groups[cluster.name]; ok {
+			g.writeCluster(&sb, cluster, box)
+		}
+	}
+	sb.WriteString("  </g>\n")
+
+	sb.WriteString(`  <g class="edges">` + "\n")
+	for i, conn := range infra.Connections {
+		if len(res.Edges[i]) > 1 {
+			g.writeEdge(&sb, i, conn, res.Edges[i], markers)
+		}
+	}
+	sb.WriteString("  </g>\n")
+
+	sb.WriteString(`  <g class="nodes">` + "\n")
+	for i, n := range nodes {
+		g.writeNode(&sb, n, res.Nodes[i])
+	}
+	sb.WriteString("  </g>\n")
+
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// measureNode computes the table rows and overall size of an entity node.
+func (g *SVGGenerator) measureNode(entity Entity) *svgNode {
+	n := &svgNode{
+		entity:     entity,
+		shape:      resolveShape(g.style, entity.Shape),
+		icon:       g.icons.Resolve(entity.Icon),
+		desc:       truncateRunes(entity.Description, g.style.Node.MaxDescriptionLength, g.style.Node.TruncationSuffix),
+		attrs:      displayedAttributes(g.style, entity),
+		statusFill: statusColor(g.style, entity.Status),
+	}
+
+	width := math.Max(textWidth(entityLabel(g.style, entity.ID), svgFontSize)*1.1, textWidth(n.desc, svgFontSize))
+	for i, attr := range n.attrs {
+		n.attrs[i].Value = truncateRunes(attr.Value, g.style.Node.MaxDescriptionLength, g.style.Node.TruncationSuffix)
+		width = math.Max(width, textWidth(attr.Key+": "+n.attrs[i].Value, svgSmallFontSize))
+	}
+	n.tableW = math.Max(width+2*svgCellPadding, svgMinNodeWidth)
+	n.tableH = svgRowHeight*float64(2+len(n.attrs)) + float64(g.style.Node.StatusBarHeight) + svgCellPadding/2
+	if n.icon != "" {
+		n.tableH += g.iconSize() + svgCellPadding/2
+	}
+
+	// Shapes drawn around the table need room for their outline
+	n.width, n.height = n.tableW, n.tableH
+	switch n.shape.Shape {
+	case "":
+	case "cylinder":
+		n.height += 2 * svgCylinderCap
+	case "ellipse", "oval", "circle", "doublecircle", "egg":
+		n.width *= 1.42
+		n.height *= 1.42
+		if n.shape.Shape != "ellipse" && n.shape.Shape != "oval" && n.shape.Shape != "egg" {
+			n.width = math.Max(n.width, n.height)
+			n.height = n.width
+		}
+	default:
+		n.width += 2 * svgCellPadding
+		n.height += 2 * svgCellPadding
+	}
+	return n
+}
+
+const svgCylinderCap = 8.0
+
+func (g *SVGGenerator) iconSize() float64 {
+	if g.style.Node.IconSize > 0 {
+		return float64(g.style.Node.IconSize)
+	}
+	return 32
+}
+
+func (g *SVGGenerator) writeNode(sb *strings.Builder, n *svgNode, box layout.Rect) {
+	border := g.style.Node.BorderWidth
+	center := box.Center()
+
+	sb.WriteString(fmt.Sprintf(`    <g class="node" id="%s">`+"\n", escapeXML("node-"+n.entity.ID)))
+	sb.WriteString(fmt.Sprintf("      <title>%s</title>\n", escapeXML(generateTooltip(g.style, n.entity))))
+
+	// Outline: either the entity shape or the table border
+	switch n.shape.Shape {
+	case "":
+		rx := 0.0
+		if n.shape.Rounded {
+			rx = 8
+		}
+		sb.WriteString(fmt.Sprintf(`      <rect x="%s" y="%s" width="%s" height="%s" rx="%s" fill="white" stroke="black" stroke-width="%d"/>`+"\n",
+			num(box.X), num(box.Y), num(box.Width), num(box.Height), num(rx), border))
+	case "ellipse", "oval", "circle", "doublecircle", "egg":
+		sb.WriteString(fmt.Sprintf(`      <ellipse cx="%s" cy="%s" rx="%s" ry="%s" fill="white" stroke="black"/>`+"\n",
+			num(center.X), num(center.Y), num(box.Width/2), num(box.Height/2)))
+	case "cylinder":
+		top, bottom := box.Y+svgCylinderCap, box.Y+box.Height-svgCylinderCap
+		rx := box.Width / 2
+		sb.WriteString(fmt.Sprintf(`      <path d="M%s,%s a%s,%s 0 0,0 %s,0 v%s a%s,%s 0 0,1 -%s,0 z M%s,%s a%s,%s 0 0,0 %s,0 a%s,%s 0 0,0 -%s,0" fill="white" stroke="black"/>`+"\n",
+			num(box.X), num(top), num(rx), num(svgCylinderCap), num(box.Width), num(bottom-top),
+			num(rx), num(svgCylinderCap), num(box.Width),
+			num(box.X), num(top), num(rx), num(svgCylinderCap), num(box.Width),
+			num(rx), num(svgCylinderCap), num(box.Width)))
+	default:
+		sb.WriteString(fmt.Sprintf(`      <rect x="%s" y="%s" width="%s" height="%s" fill="white" stroke="black"/>`+"\n",
+			num(box.X), num(box.Y), num(box.Width), num(box.Height)))
+	}
+
+	// Table rows, centered in the node
+	x := center.X - n.tableW/2
+	y := center.Y - n.tableH/2
+	if n.icon != "" {
+		size := g.iconSize()
+		if href := imageDataURI(n.icon); href != "" {
+			sb.WriteString(fmt.Sprintf(`      <image x="%s" y="%s" width="%s" height="%s" href="%s"/>`+"\n",
+				num(center.X-size/2), num(y+svgCellPadding/2), num(size), num(size), href))
+		}
+		y += size + svgCellPadding/2
+	}
+
+	sb.WriteString(fmt.Sprintf(`      <text x="%s" y="%s" text-anchor="middle" font-weight="bold">%s</text>`+"\n",
+		num(center.X), num(y+svgRowHeight*0.75), escapeXML(entityLabel(g.style, n.entity.ID))))
+	y += svgRowHeight
+	sb.WriteString(fmt.Sprintf(`      <text x="%s" y="%s" text-anchor="middle">%s</text>`+"\n",
+		num(center.X), num(y+svgRowHeight*0.75), escapeXML(n.desc)))
+	y += svgRowHeight
+	for _, attr := range n.attrs {
+		sb.WriteString(fmt.Sprintf(`      <text x="%s" y="%s" font-size="%s"><tspan font-style="italic">%s</tspan>: %s</text>`+"\n",
+			num(x+svgCellPadding), num(y+svgRowHeight*0.75), num(svgSmallFontSize), escapeXML(attr.Key), escapeXML(attr.Value)))
+		y += svgRowHeight
+	}
+
+	if height := g.style.Node.StatusBarHeight; height > 0 {
+		sb.WriteString(fmt.Sprintf(`      <rect class="status" x="%s" y="%s" width="%s" height="%d" fill="%s"/>`+"\n",
+			num(x+float64(border)), num(y), num(n.tableW-2*float64(border)), height, escapeXML(n.statusFill)))
+	}
+	sb.WriteString("    </g>\n")
+}
+
+// svgCluster is the box of a category or namespace, drawn outer boxes
+// first.
+type svgCluster struct {
+	name   string
+	label  string
+	config CategoryConfig
+}
+
+func (g *SVGGenerator) writeCluster(sb *strings.Builder, cluster svgCluster, box layout.Rect) {
+	config := cluster.config
+	if config.NoCluster {
+		return
+	}
+
+	fill := "none"
+	if config.FillColor != "" {
+		fill = config.FillColor
+	}
+	stroke := "black"
+	if config.BorderColor != "" {
+		stroke = config.BorderColor
+	}
+	width := 1
+	if config.BorderWidth > 0 {
+		width = config.BorderWidth
+	}
+
+	var extra string
+	rx := 0.0
+	for _, part := range strings.Split(config.ClusterStyle, ",") {
+		switch strings.TrimSpace(part) {
+		case "rounded":
+			rx = 8
+		case "dashed":
+			extra += ` stroke-dasharray="6,4"`
+		case "dotted":
+			extra += ` stroke-dasharray="2,3"`
+		case "bold":
+			width *= 2
+		case "invis":
+			stroke = "none"
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf(`    <g class="cluster" id="%s">`+"\n", escapeXML("cluster-"+cluster.name)))
+	sb.WriteString(fmt.Sprintf(`      <rect x="%s" y="%s" width="%s" height="%s" rx="%s" fill="%s" stroke="%s" stroke-width="%d"%s/>`+"\n",
+		num(box.X), num(box.Y), num(box.Width), num(box.Height), num(rx), escapeXML(fill), escapeXML(stroke), width, extra))
+
+	// Label position defaults to top-center like Graphviz
+	loc, just := labelPosition(config.LabelPosition)
+	labelX, anchor := box.X+box.Width/2, "middle"
+	switch just {
+	case "l":
+		labelX, anchor = box.X+svgCellPadding, "start"
+	case "r":
+		labelX, anchor = box.X+box.Width-svgCellPadding, "end"
+	}
+	labelY := box.Y + svgRowHeight
+	if loc == "b" {
+		labelY = box.Y + box.Height - svgCellPadding
+	}
+
+	var fontAttrs string
+	if config.FontName != "" {
+		fontAttrs += fmt.Sprintf(` font-family="%s"`, escapeXML(config.FontName))
+	}
+	if config.FontSize > 0 {
+		fontAttrs += fmt.Sprintf(` font-size="%d"`, config.FontSize)
+	}
+	if config.FontColor != "" {
+		fontAttrs += fmt.Sprintf(` fill="%s"`, escapeXML(config.FontColor))
+	}
+	sb.WriteString(fmt.Sprintf(`      <text x="%s" y="%s" text-anchor="%s"%s>%s</text>`+"\n",
+		num(labelX), num(labelY), anchor, fontAttrs, escapeXML(cluster.label)))
+	sb.WriteString("    </g>\n")
+}
+
+// svgMarkers are the arrowhead markers, one per edge color.
+type svgMarkers struct {
+	colors []string
+	ids    map[string]string
+}
+
+func (g *SVGGenerator) edgeMarkers(conns []Connection) svgMarkers {
+	m := svgMarkers{ids: make(map[string]string)}
+	for _, conn := range conns {
+		color := g.edgeColor(conn.Type)
+		if _, ok := m.ids[color]; !ok {
+			m.ids[color] = fmt.Sprintf("arrow-%d", len(m.colors))
+			m.colors = append(m.colors, color)
+		}
+	}
+	return m
+}
+
+func (g *SVGGenerator) edgeColor(connType string) string {
+	if style := g.style.ConnectionStyles[connType]; style.Color != "" {
+		return style.Color
+	}
+	return "black"
+}
+
+func (g *SVGGenerator) writeEdge(sb *strings.Builder, index int, conn Connection, points []layout.Point, markers svgMarkers) {
+	color := g.edgeColor(conn.Type)
+	width := "1"
+	var dash string
+	switch g.style.ConnectionStyles[conn.Type].Style {
+	case "dashed":
+		dash = ` stroke-dasharray="6,4"`
+	case "dotted":
+		dash = ` stroke-dasharray="2,3"`
+	case "bold":
+		width = "2.5"
+	}
+
+	sb.WriteString(fmt.Sprintf(`    <g class="edge" id="%s">`+"\n", escapeXML(fmt.Sprintf("edge-%d", index))))
+	sb.WriteString(fmt.Sprintf("      <title>%s</title>\n", escapeXML(conn.From+" -> "+conn.To)))
+	sb.WriteString(fmt.Sprintf(`      <path d="%s" fill="none" stroke="%s" stroke-width="%s"%s marker-end="url(#%s)"/>`+"\n",
+		smoothPath(points), escapeXML(color), width, dash, markers.ids[color]))
+
+	if conn.Type != "" {
+		mid := pathMidpoint(points)
+		sb.WriteString(fmt.Sprintf(`      <text x="%s" y="%s" text-anchor="middle" font-size="%s">%s</text>`+"\n",
+			num(mid.X), num(mid.Y-4), num(svgSmallFontSize), escapeXML(conn.Type)))
+	}
+	sb.WriteString("    </g>\n")
+}
+
+// smoothPath draws a polyline through points, rounding interior bends with
+// quadratic curves through the segment midpoints.
+func smoothPath(points []layout.Point) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("M%s,%s", num(points[0].X), num(points[0].Y)))
+	if len(points) == 2 {
+		sb.WriteString(fmt.Sprintf(" L%s,%s", num(points[1].X), num(points[1].Y)))
+		return sb.String()
+	}
+	for i := 1; i < len(points)-1; i++ {
+		next := points[i+1]
+		if i < len(points)-2 {
+			next = layout.Point{X: (points[i].X + points[i+1].X) / 2, Y: (points[i].Y + points[i+1].Y) / 2}
+		}
+		sb.WriteString(fmt.Sprintf(" Q%s,%s %s,%s", num(points[i].X), num(points[i].Y), num(next.X), num(next.Y)))
+	}
+	return sb.String()
+}
+
+// pathMidpoint returns the point halfway along a polyline.
+func pathMidpoint(points []layout.Point) layout.Point {
+	total := 0.0
+	for i := 1; i < len(points); i++ {
+		total += distance(points[i-1], points[i])
+	}
+	remaining := total / 2
+	for i := 1; i < len(points); i++ {
+		d := distance(points[i-1], points[i])
+		if d >= remaining && d > 0 {
+			t := remaining / d
+			return layout.Point{
+				X: points[i-1].X + (points[i].X-points[i-1].X)*t,
+				Y: points[i-1].Y + (points[i].Y-points[i-1].Y)*t,
+			}
+		}
+		remaining -= d
+	}
+	return points[len(points)-1]
+}
+
+// imageDataURI embeds an icon file so the SVG is self-contained.
+func imageDataURI(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	mimeType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// textWidth estimates the rendered width of s at the given font size.
+func textWidth(s string, fontSize float64) float64 {
+	return float64(len([]rune(s))) * fontSize * svgCharWidth
+}
+
+func distance(a, b layout.Point) float64 {
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
+
+// num formats a coordinate with at most one decimal place.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
@@ -0,0 +1,81 @@
+package gorph
+
+import (
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// examples are the definitions in example_input, rendered to the golden
+// files in testdata.
+var examples = []string{"simple", "webapp", "microservices", "data-pipeline", "deploy", "infra"}
+
+func loadExample(t *testing.T, name string) *Infrastructure {
+	t.Helper()
+	infra, err := LoadInfrastructure(filepath.Join("..", "..", "example_input", name+".yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return infra
+}
+
+// checkXML fails unless out is well-formed XML.
+func checkXML(t *testing.T, out string) {
+	t.Helper()
+	dec := xml.NewDecoder(strings.NewReader(out))
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("malformed XML: %v\n%s", err, out)
+		}
+	}
+}
+
+func TestSVGGolden(t *testing.T) {
+	for _, name := range examples {
+		t.Run(name, func(t *testing.T) {
+			out := NewSVGGenerator(DefaultStyle()).Generate(loadExample(t, name))
+			checkXML(t, out)
+			checkGolden(t, name+".svg", out)
+		})
+	}
+}
+
+// TestSVGCyclesAndComponents draws a cycle, a self-loop and an entity
+// connected to nothing, none of which the examples have.
+func TestSVGCyclesAndComponents(t *testing.T) {
+	infra := &Infrastructure{
+		Entities: []Entity{
+			{ID: "API", Category: "BACKEND", Description: "api", Status: "healthy"},
+			{ID: "Worker", Category: "BACKEND", Description: "worker", Status: "degraded"},
+			{ID: "Queue", Category: "DATABASE", Description: "queue", Status: "healthy"},
+			{ID: "Cron", Category: "BACKEND", Description: "cron", Status: "healthy"},
+			{ID: "Audit", Category: "DATABASE", Description: "audit", Status: "down"},
+		},
+		Connections: []Connection{
+			{From: "API", To: "Queue", Type: "API_CALL"},
+			{From: "Queue", To: "Worker", Type: "API_CALL"},
+			{From: "Worker", To: "API", Type: "API_CALL"},
+			{From: "Worker", To: "Worker", Type: "API_CALL"},
+			{From: "Cron", To: "Missing", Type: "API_CALL"},
+		},
+	}
+	out := NewSVGGenerator(DefaultStyle()).Generate(infra)
+	checkXML(t, out)
+	checkGolden(t, "cycles.svg", out)
+
+	// The edge to an unknown entity is left out; every entity is drawn
+	if got := strings.Count(out, `<g class="edge"`); got != 4 {
+		t.Errorf("drew %d edges, want 4", got)
+	}
+	for _, e := range infra.Entities {
+		if !strings.Contains(out, ">"+e.ID+"<") {
+			t.Errorf("entity %s is not drawn", e.ID)
+		}
+	}
+}
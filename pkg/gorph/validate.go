@@ -0,0 +1,201 @@
+package gorph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IsValidName validates that a name follows basic naming rules
+// - Must start with a letter (a-z, A-Z)
+// - Can contain letters, numbers, underscores, and dashes
+// Entity IDs, namespaces and infrastructure IDs are made of names.
+func IsValidName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+
+	// Must start with a letter
+	if !((name[0] >= 'a' && name[0] <= 'z') || (name[0] >= 'A' && name[0] <= 'Z')) {
+		return false
+	}
+
+	// Check remaining characters
+	for i := 1; i < len(name); i++ {
+		char := name[i]
+		if !((char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') ||
+			char == '_' ||
+			char == '-') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsValidEntityID validates an entity ID: a name, optionally qualified by
+// the namespaces it is declared in, such as "payments/Database".
+// IDs outside these rules are still quoted safely by the generators.
+func IsValidEntityID(id string) bool {
+	for _, name := range strings.Split(id, "/") {
+		if !IsValidName(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// idRules describes valid entity IDs in validation messages.
+const idRules = "IDs must start with a letter and contain only letters, numbers, underscores, and dashes, optionally after namespaces such as payments/."
+
+// ValidationError describes a single problem found in an infrastructure
+// definition, with the source position it was declared at when known.
+// Field is the path of the offending value, e.g. "entities.3.id".
+type ValidationError struct {
+	Pos     Position
+	Field   string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if pos := e.Pos.String(); pos != "" {
+		return pos + ": " + e.Message
+	}
+	return e.Message
+}
+
+// ValidationErrors is the list of problems returned by Validate.
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	return strings.Join(errs.Strings(), "\n")
+}
+
+// Strings returns the formatted message of every error.
+func (errs ValidationErrors) Strings() []string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return messages
+}
+
+// Validate checks an infrastructure definition for structural errors and
+// returns every problem found. An empty result means the input is valid.
+func Validate(infra *Infrastructure) ValidationErrors {
+	var errors ValidationErrors
+	report := func(path string, format string, args ...interface{}) {
+		errors = append(errors, ValidationError{
+			Pos:     infra.source.lookup(path),
+			Field:   path,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	for i, pattern := range infra.Include {
+		report(fmt.Sprintf("include.%d", i), "Include %s: includes are only resolved when loading a file", pattern)
+	}
+
+	if len(infra.Entities) == 0 {
+		report("entities", "Infrastructure must have at least one entity")
+	}
+
+	// Check for duplicate entity IDs, which may be declared in different
+	// included files
+	entityIds := make(map[string]int)
+	for i, entity := range infra.Entities {
+		path := fmt.Sprintf("entities.%d", i)
+		if entity.ID == "" {
+			report(path, "Entity %d: ID is required", infra.source.index(path, i))
+			continue
+		}
+
+		// Validate ID format
+		if !IsValidEntityID(entity.ID) {
+			report(path+".id", "Entity %s: ID contains invalid characters. %s", entity.ID, idRules)
+		}
+
+		if first, ok := entityIds[entity.ID]; ok {
+			if pos := infra.source.lookup(fmt.Sprintf("entities.%d.id", first)).String(); pos != "" {
+				report(path+".id", "Duplicate entity ID: %s (first declared at %s)", entity.ID, pos)
+			} else {
+				report(path+".id", "Duplicate entity ID: %s", entity.ID)
+			}
+		} else {
+			entityIds[entity.ID] = i
+		}
+
+		if entity.Category == "" {
+			report(path+".category", "Entity %s: Category is required", entity.ID)
+		}
+
+		if entity.Description == "" {
+			report(path+".description", "Entity %s: Description is required", entity.ID)
+		}
+
+		if entity.Status == "" {
+			report(path+".status", "Entity %s: Status is required", entity.ID)
+		}
+	}
+
+	// Validate connections
+	for i, conn := range infra.Connections {
+		path := fmt.Sprintf("connections.%d", i)
+		n := infra.source.index(path, i) // numbered within the declaring file
+		if conn.From == "" {
+			report(path+".from", "Connection %d: From is required", n)
+		} else {
+			if !IsValidEntityID(conn.From) {
+				report(path+".from", "Connection %d: From entity ID '%s' contains invalid characters. %s", n, conn.From, idRules)
+			}
+			if _, ok := entityIds[conn.From]; !ok {
+				report(path+".from", "Connection %d: From entity '%s' does not exist", n, conn.From)
+			}
+		}
+
+		if conn.To == "" {
+			report(path+".to", "Connection %d: To is required", n)
+		} else {
+			if !IsValidEntityID(conn.To) {
+				report(path+".to", "Connection %d: To entity ID '%s' contains invalid characters. %s", n, conn.To, idRules)
+			}
+			if _, ok := entityIds[conn.To]; !ok {
+				report(path+".to", "Connection %d: To entity '%s' does not exist", n, conn.To)
+			}
+		}
+
+		if conn.Type == "" {
+			report(path+".type", "Connection %d: Type is required", n)
+		}
+	}
+
+	// Add the schema violations found while parsing, unless the checks
+	// above already reported the same field
+	for _, err := range infra.schemaErrors {
+		if !reportedAt(errors, err.Field) {
+			errors = append(errors, err)
+		}
+	}
+
+	return errors
+}
+
+// Warnings returns the unknown fields found when the definition was parsed.
+// They do not make it invalid: they are ignored, as they were before
+// definitions were checked against the schema, but usually are misspelled
+// keys.
+func (infra *Infrastructure) Warnings() ValidationErrors {
+	return infra.warnings
+}
+
+// reportedAt reports whether errs include an error for field or one of the
+// values containing it.
+func reportedAt(errs ValidationErrors, field string) bool {
+	for _, err := range errs {
+		if err.Field == field || strings.HasPrefix(field, err.Field+".") {
+			return true
+		}
+	}
+	return false
+}
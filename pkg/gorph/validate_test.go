@@ -0,0 +1,98 @@
+package gorph
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const invalidInfrastructure = `entities:
+  - id: API
+    category: BACKEND
+    description: Service
+    status: healthy
+  - id: bad id
+    category: BACKEND
+    description: Broken
+  - id: API
+    category: DATABASE
+    description: Duplicate
+    status: healthy
+connections:
+  - from: API
+    to: Missing
+    type: calls
+  - from: API
+    to: API
+`
+
+func TestValidatePositions(t *testing.T) {
+	infra, err := ParseInfrastructure([]byte(invalidInfrastructure))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := Validate(infra)
+
+	want := []struct {
+		field   string
+		pos     string
+		message string
+	}{
+		{"entities.1.id", "6:9", "Entity bad id: ID contains invalid characters"},
+		{"entities.1.status", "6:5", "Entity bad id: Status is required"},
+		{"entities.2.id", "9:9", "Duplicate entity ID: API (first declared at 2:9)"},
+		{"connections.0.to", "15:9", "Connection 0: To entity 'Missing' does not exist"},
+		{"connections.1.type", "17:5", "Connection 1: Type is required"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d:\n%v", len(errs), len(want), errs)
+	}
+	for i, w := range want {
+		got := errs[i]
+		if got.Field != w.field || got.Pos.String() != w.pos || !strings.HasPrefix(got.Message, w.message) {
+			t.Errorf("error %d = %s %q at %s, want %s %q at %s", i, got.Field, got.Message, got.Pos, w.field, w.message, w.pos)
+		}
+	}
+}
+
+func TestValidatePositionsInFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "infra.yml")
+	if err := os.WriteFile(path, []byte(invalidInfrastructure), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	infra, err := LoadInfrastructure(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := Validate(infra)
+	if len(errs) == 0 {
+		t.Fatal("no errors reported")
+	}
+	if got, want := errs[0].Error(), path+":6:9: Entity bad id:"; !strings.HasPrefix(got, want) {
+		t.Errorf("first error = %q, want prefix %q", got, want)
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	infra, err := ParseInfrastructure([]byte(`entities:
+  - id: API
+    category: BACKEND
+    description: Service
+    status: healthy
+  - id: DB
+    category: DATABASE
+    description: Store
+    status: healthy
+connections:
+  - from: API
+    to: DB
+    type: DB_Connection
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := Validate(infra); len(errs) > 0 {
+		t.Errorf("unexpected errors:\n%v", errs)
+	}
+}
@@ -0,0 +1,451 @@
+package gorph
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UpdateInfrastructureYAML serializes infra like MarshalInfrastructure, but
+// edits the original document in place so that comments, key order, blank
+// lines and the layout of unchanged values are kept.
+//
+// Definitions loaded from a file have their includes merged and their
+// entities qualified by the file's namespace. If infra sets neither, the
+// includes and namespace of the original are kept, and entity IDs and
+// connection endpoints are written relative to the namespace again.
+//
+// The original is merged with the new content as yaml.v3 nodes, then the
+// difference between the re-encoded original and the merged document is
+// applied to the original text line by line.
+func UpdateInfrastructureYAML(original []byte, infra *Infrastructure) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(original, &doc); err != nil {
+		return nil, fmt.Errorf("parsing infrastructure YAML: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return MarshalInfrastructure(infra)
+	}
+	if i := mappingKeyIndex(doc.Content[0].Content, "include"); i >= 0 && infra.Include == nil {
+		withIncludes := *infra
+		if err := doc.Content[0].Content[i+1].Decode(&withIncludes.Include); err != nil {
+			return nil, fmt.Errorf("parsing infrastructure YAML: %w", err)
+		}
+		infra = &withIncludes
+	}
+	if i := mappingKeyIndex(doc.Content[0].Content, "namespace"); i >= 0 && infra.Namespace == "" {
+		var namespace string
+		if err := doc.Content[0].Content[i+1].Decode(&namespace); err != nil {
+			return nil, fmt.Errorf("parsing infrastructure YAML: %w", err)
+		}
+		if namespace != "" {
+			declared, err := infra.declareIn(namespace)
+			if err != nil {
+				return nil, err
+			}
+			infra = declared
+		}
+	}
+
+	fresh, err := MarshalInfrastructure(infra)
+	if err != nil {
+		return nil, err
+	}
+	var src yaml.Node
+	if err := yaml.Unmarshal(fresh, &src); err != nil {
+		return nil, fmt.Errorf("parsing infrastructure YAML: %w", err)
+	}
+
+	indent := detectIndent(original)
+	base, err := encodeNode(&doc, indent)
+	if err != nil {
+		return nil, err
+	}
+	mergeNode(doc.Content[0], src.Content[0], "root")
+	merged, err := encodeNode(&doc, indent)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Equal(base, merged) {
+		return original, nil
+	}
+
+	// Keep the text patch only if it means the same as the merged document
+	patched := patchLines(original, base, merged)
+	if patched != nil && sameYAML(patched, merged) {
+		return patched, nil
+	}
+	return merged, nil
+}
+
+func encodeNode(node *yaml.Node, indent int) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(node); err != nil {
+		return nil, fmt.Errorf("encoding infrastructure YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encoding infrastructure YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// detectIndent returns the smallest indentation used in a document, so
+// inserted lines line up with the existing ones.
+func detectIndent(data []byte) int {
+	indent := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if n > 0 && n < len(line) && (indent == 0 || n < indent) {
+			indent = n
+		}
+	}
+	if indent < 2 {
+		return 2
+	}
+	return indent
+}
+
+func sameYAML(a, b []byte) bool {
+	var va, vb interface{}
+	if yaml.Unmarshal(a, &va) != nil || yaml.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// yamlKeys lists the YAML keys of the model structs. Keys a struct does not
+// declare are left alone when merging, so hand-written extras survive.
+var yamlKeys = map[string]map[string]bool{
+	"root":       structYAMLKeys(Infrastructure{}),
+	"entity":     structYAMLKeys(Entity{}),
+	"connection": structYAMLKeys(Connection{}),
+}
+
+func structYAMLKeys(v interface{}) map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// mergeNode updates dst to hold the value of src. Level names the model
+// type dst holds: "root", "entities", "entity", "connections",
+// "connection", or "" for free-form values.
+func mergeNode(dst, src *yaml.Node, level string) {
+	switch {
+	case dst.Kind == yaml.ScalarNode && src.Kind == yaml.ScalarNode && dst.Value == src.Value:
+		return
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.ScalarNode && joinedScalars(dst) == src.Value:
+		// A list-valued attribute that was read as "a, b"
+		return
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		mergeMapping(dst, src, level)
+		return
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode:
+		mergeSequence(dst, src, level)
+		return
+	}
+
+	// Replace the value but keep the comments attached to it, and keep
+	// the quoting of strings
+	style := src.Style
+	if dst.Kind == yaml.ScalarNode && src.Kind == yaml.ScalarNode && src.Tag == "!!str" &&
+		dst.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		style = dst.Style
+	}
+	dst.Kind, dst.Tag, dst.Value, dst.Content, dst.Style, dst.Alias = src.Kind, src.Tag, src.Value, src.Content, style, nil
+}
+
+func joinedScalars(seq *yaml.Node) string {
+	items := make([]string, len(seq.Content))
+	for i, item := range seq.Content {
+		if item.Kind != yaml.ScalarNode {
+			return "\x00"
+		}
+		items[i] = item.Value
+	}
+	return strings.Join(items, ", ")
+}
+
+func mergeMapping(dst, src *yaml.Node, level string) {
+	known := yamlKeys[level]
+	srcIndex := make(map[string]int)
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		srcIndex[src.Content[i].Value] = i
+	}
+
+	// Update or drop the existing keys in place
+	var content []*yaml.Node
+	present := make(map[string]bool)
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		key, value := dst.Content[i], dst.Content[i+1]
+		j, ok := srcIndex[key.Value]
+		if !ok {
+			if known == nil || known[key.Value] {
+				continue
+			}
+		} else {
+			mergeNode(value, src.Content[j+1], childLevel(level, key.Value))
+		}
+		content = append(content, key, value)
+		present[key.Value] = true
+	}
+
+	// Insert new keys after the key that precedes them in src
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key := src.Content[i].Value
+		if present[key] {
+			continue
+		}
+		at := 0
+		for j := i - 2; j >= 0; j -= 2 {
+			if pos := mappingKeyIndex(content, src.Content[j].Value); pos >= 0 {
+				at = pos + 2
+				break
+			}
+		}
+		content = append(content[:at], append([]*yaml.Node{src.Content[i], src.Content[i+1]}, content[at:]...)...)
+		present[key] = true
+	}
+	dst.Content = content
+}
+
+func mappingKeyIndex(content []*yaml.Node, key string) int {
+	for i := 0; i+1 < len(content); i += 2 {
+		if content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func childLevel(level, key string) string {
+	if level == "root" && (key == "entities" || key == "connections") {
+		return key
+	}
+	return ""
+}
+
+// mergeSequence reorders dst to follow src, reusing the dst items that
+// represent the same entity, connection or scalar.
+func mergeSequence(dst, src *yaml.Node, level string) {
+	itemLevel := ""
+	switch level {
+	case "entities":
+		itemLevel = "entity"
+	case "connections":
+		itemLevel = "connection"
+	}
+
+	used := make([]bool, len(dst.Content))
+	content := make([]*yaml.Node, 0, len(src.Content))
+	for i, item := range src.Content {
+		id := itemIdentity(item, itemLevel)
+		match := -1
+		for j, candidate := range dst.Content {
+			if used[j] {
+				continue
+			}
+			if (id != "" && itemIdentity(candidate, itemLevel) == id) || (id == "" && j == i) {
+				match = j
+				break
+			}
+		}
+		if match < 0 {
+			content = append(content, item)
+			continue
+		}
+		used[match] = true
+		mergeNode(dst.Content[match], item, itemLevel)
+		content = append(content, dst.Content[match])
+	}
+	dst.Content = content
+}
+
+// itemIdentity returns the key matching sequence items across versions.
+// Connections are identified by their endpoints and type, as several
+// connections of different types may join the same entities.
+func itemIdentity(item *yaml.Node, level string) string {
+	switch {
+	case item.Kind == yaml.ScalarNode:
+		return "=" + item.Value
+	case item.Kind != yaml.MappingNode:
+		return ""
+	case level == "entity":
+		return "id=" + mappingValue(item, "id")
+	case level == "connection":
+		return "edge=" + mappingValue(item, "from") + "\x00" + mappingValue(item, "to") + "\x00" + mappingValue(item, "type")
+	}
+	return ""
+}
+
+func mappingValue(node *yaml.Node, key string) string {
+	if i := mappingKeyIndex(node.Content, key); i >= 0 {
+		return node.Content[i+1].Value
+	}
+	return ""
+}
+
+// patchLines applies the line changes from base to merged onto original,
+// where base is original re-encoded without changes. Lines of original
+// that the encoder drops, such as blank lines, are kept. It returns nil
+// when the documents are too large to compare.
+func patchLines(original, base, merged []byte) []byte {
+	origLines := splitLines(original)
+	baseLines := splitLines(base)
+	mergedLines := splitLines(merged)
+	if len(origLines)*len(baseLines) > maxDiffCells || len(baseLines)*len(mergedLines) > maxDiffCells {
+		return nil
+	}
+
+	// Map every base line to the original line it was encoded from
+	origOf := make([]int, len(baseLines))
+	for i := range origOf {
+		origOf[i] = -1
+	}
+	for _, op := range diffLines(baseLines, origLines, normalizeLine) {
+		if op.kind == diffEqual {
+			origOf[op.a] = op.b
+		}
+	}
+
+	var out []string
+	var pending []string
+	next := 0
+	gap := func(to int) []string {
+		if to < next {
+			return nil
+		}
+		lines := origLines[next:to]
+		next = to + 1
+		return lines
+	}
+	for _, op := range diffLines(baseLines, mergedLines, nil) {
+		switch op.kind {
+		case diffEqual:
+			if origOf[op.a] < 0 {
+				// Blank lines come from the original gaps instead
+				if strings.TrimSpace(mergedLines[op.b]) != "" {
+					out = append(out, mergedLines[op.b])
+				}
+				continue
+			}
+			// After a deletion keep one of the separating gaps, not both
+			lines := gap(origOf[op.a])
+			if pending != nil {
+				lines = pending
+				pending = nil
+			}
+			out = append(out, lines...)
+			out = append(out, origLines[origOf[op.a]])
+		case diffDelete:
+			if origOf[op.a] >= 0 {
+				lines := gap(origOf[op.a])
+				if pending == nil {
+					pending = append([]string{}, lines...)
+				}
+			}
+		case diffInsert:
+			out = append(out, pending...)
+			pending = nil
+			out = append(out, mergedLines[op.b])
+		}
+	}
+	if next < len(origLines) {
+		out = append(out, origLines[next:]...)
+	}
+	return []byte(strings.Join(out, ""))
+}
+
+// maxDiffCells bounds the size of the LCS table used by diffLines.
+const maxDiffCells = 16 << 20
+
+func splitLines(data []byte) []string {
+	return strings.SplitAfter(string(data), "\n")
+}
+
+// normalizeLine ignores differences in whitespace, which the encoder
+// changes around comments.
+func normalizeLine(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp is one step of an edit script; a and b index the two inputs.
+type diffOp struct {
+	kind diffKind
+	a, b int
+}
+
+// diffLines returns an edit script turning a into b from their longest
+// common subsequence. Lines are compared after applying norm, if set.
+func diffLines(a, b []string, norm func(string) string) []diffOp {
+	if norm == nil {
+		norm = func(s string) string { return s }
+	}
+	na := make([]string, len(a))
+	for i, line := range a {
+		na[i] = norm(line)
+	}
+	nb := make([]string, len(b))
+	for i, line := range b {
+		nb[i] = norm(line)
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	width := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if na[i] == nb[j] && na[i] != "" {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else if lcs[(i+1)*width+j] >= lcs[i*width+j+1] {
+				lcs[i*width+j] = lcs[(i+1)*width+j]
+			} else {
+				lcs[i*width+j] = lcs[i*width+j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case na[i] == nb[j] && na[i] != "":
+			ops = append(ops, diffOp{diffEqual, i, j})
+			i++
+			j++
+		case lcs[(i+1)*width+j] >= lcs[i*width+j+1]:
+			ops = append(ops, diffOp{diffDelete, i, j})
+			i++
+		default:
+			ops = append(ops, diffOp{diffInsert, i, j})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{diffDelete, i, j})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{diffInsert, i, j})
+	}
+	return ops
+}
@@ -0,0 +1,243 @@
+package gorph
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const editedYAML = `# Production services
+entities:
+  # The public API
+  - id: API
+    description: Serves requests   # shown in the node
+    category: BACKEND
+    status: healthy
+
+  - category: DATABASE
+    id: DB
+    description: Main store
+    status: healthy
+    attributes:
+      engine: postgres  # the only engine we run
+
+connections:
+  # Reads go through the cache
+  - from: API
+    to: DB
+    type: DB_CONNECTION
+  # Replication feed
+  - from: API
+    to: DB
+    type: EVENT_STREAM
+`
+
+// edit decodes editedYAML, applies change and writes it back over the
+// original text.
+func edit(t *testing.T, change func(*Infrastructure)) string {
+	t.Helper()
+	var infra Infrastructure
+	if err := yaml.Unmarshal([]byte(editedYAML), &infra); err != nil {
+		t.Fatal(err)
+	}
+	change(&infra)
+	out, err := UpdateInfrastructureYAML([]byte(editedYAML), &infra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestUpdateInfrastructureYAMLUnchanged(t *testing.T) {
+	if got := edit(t, func(*Infrastructure) {}); got != editedYAML {
+		t.Errorf("unchanged definition was rewritten:\n%s", got)
+	}
+}
+
+func TestUpdateInfrastructureYAML(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*Infrastructure)
+		want   string
+	}{
+		{
+			name:   "changed value keeps comments and key order",
+			change: func(infra *Infrastructure) { infra.Entities[1].Description = "Primary store" },
+			want: `# Production services
+entities:
+  # The public API
+  - id: API
+    description: Serves requests   # shown in the node
+    category: BACKEND
+    status: healthy
+
+  - category: DATABASE
+    id: DB
+    description: Primary store
+    status: healthy
+    attributes:
+      engine: postgres  # the only engine we run
+
+connections:
+  # Reads go through the cache
+  - from: API
+    to: DB
+    type: DB_CONNECTION
+  # Replication feed
+  - from: API
+    to: DB
+    type: EVENT_STREAM
+`,
+		},
+		{
+			name: "new key goes after the key preceding it",
+			change: func(infra *Infrastructure) {
+				infra.Entities[0].Owner = "payments"
+			},
+			want: `# Production services
+entities:
+  # The public API
+  - id: API
+    description: Serves requests   # shown in the node
+    category: BACKEND
+    status: healthy
+    owner: payments
+
+  - category: DATABASE
+    id: DB
+    description: Main store
+    status: healthy
+    attributes:
+      engine: postgres  # the only engine we run
+
+connections:
+  # Reads go through the cache
+  - from: API
+    to: DB
+    type: DB_CONNECTION
+  # Replication feed
+  - from: API
+    to: DB
+    type: EVENT_STREAM
+`,
+		},
+		{
+			name: "removed connection takes its comment along",
+			change: func(infra *Infrastructure) {
+				infra.Connections = infra.Connections[1:]
+			},
+			want: `# Production services
+entities:
+  # The public API
+  - id: API
+    description: Serves requests   # shown in the node
+    category: BACKEND
+    status: healthy
+
+  - category: DATABASE
+    id: DB
+    description: Main store
+    status: healthy
+    attributes:
+      engine: postgres  # the only engine we run
+
+connections:
+  # Replication feed
+  - from: API
+    to: DB
+    type: EVENT_STREAM
+`,
+		},
+		{
+			name: "reordered connections between the same entities keep their comments",
+			change: func(infra *Infrastructure) {
+				infra.Connections[0], infra.Connections[1] = infra.Connections[1], infra.Connections[0]
+			},
+			want: `# Production services
+entities:
+  # The public API
+  - id: API
+    description: Serves requests   # shown in the node
+    category: BACKEND
+    status: healthy
+
+  - category: DATABASE
+    id: DB
+    description: Main store
+    status: healthy
+    attributes:
+      engine: postgres  # the only engine we run
+
+connections:
+  # Replication feed
+  - from: API
+    to: DB
+    type: EVENT_STREAM
+  # Reads go through the cache
+  - from: API
+    to: DB
+    type: DB_CONNECTION
+`,
+		},
+		{
+			// Moved lines are encoded again, so only their layout changes
+			name: "reordered entities keep their comments",
+			change: func(infra *Infrastructure) {
+				infra.Entities[0], infra.Entities[1] = infra.Entities[1], infra.Entities[0]
+			},
+			want: `# Production services
+entities:
+  - category: DATABASE
+    id: DB
+    description: Main store
+    status: healthy
+    attributes:
+      engine: postgres  # the only engine we run
+  # The public API
+  - id: API
+    description: Serves requests # shown in the node
+    category: BACKEND
+    status: healthy
+
+connections:
+  # Reads go through the cache
+  - from: API
+    to: DB
+    type: DB_CONNECTION
+  # Replication feed
+  - from: API
+    to: DB
+    type: EVENT_STREAM
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := edit(t, tt.change); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestItemIdentity(t *testing.T) {
+	parse := func(s string) *yaml.Node {
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(s), &doc); err != nil {
+			t.Fatal(err)
+		}
+		return doc.Content[0]
+	}
+	a := itemIdentity(parse("{from: A, to: B, type: API_CALL}"), "connection")
+	b := itemIdentity(parse("{type: API_CALL, to: B, from: A, attributes: {x: y}}"), "connection")
+	c := itemIdentity(parse("{from: A, to: B, type: EVENT_STREAM}"), "connection")
+	if a != b {
+		t.Errorf("same connection has identities %q and %q", a, b)
+	}
+	if a == c {
+		t.Errorf("connections of different types share the identity %q", a)
+	}
+	if got := itemIdentity(parse("{id: A, category: X}"), "entity"); got != "id=A" {
+		t.Errorf("entity identity = %q", got)
+	}
+}
@@ -0,0 +1,791 @@
+// Package layout computes layered (Sugiyama-style) graph layouts for the
+// native renderers, so diagrams can be drawn without Graphviz.
+//
+// The layout runs the classic phases: cycle removal, longest-path ranking,
+// dummy nodes for long edges, barycentric crossing minimization and
+// coordinate assignment. Nodes that share a group are kept in a common band
+// across all ranks so group boxes never overlap. Groups may be nested in
+// other groups, whose boxes surround theirs.
+package layout
+
+import (
+	"math"
+	"sort"
+)
+
+// Point is a position in the final drawing.
+type Point struct {
+	X, Y float64
+}
+
+// Rect is an axis-aligned box given by its top-left corner and size.
+type Rect struct {
+	X, Y          float64
+	Width, Height float64
+}
+
+// Center returns the center point of the rectangle.
+func (r Rect) Center() Point {
+	return Point{X: r.X + r.Width/2, Y: r.Y + r.Height/2}
+}
+
+// Node is a box to be placed. Width and Height are in drawing units.
+type Node struct {
+	ID     string
+	Width  float64
+	Height float64
+	// Group places the node in a cluster; empty for ungrouped nodes.
+	Group string
+}
+
+// Edge is a directed connection between two node IDs.
+type Edge struct {
+	From, To string
+}
+
+// Graph is the input to Layered.
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+	// Groups lists group names in the order their bands are laid out.
+	// Groups used by nodes but not listed follow in order of appearance.
+	Groups []string
+	// Parents maps a nested group to the group enclosing it. Groups in the
+	// same parent should be listed next to each other, so that their bands
+	// are adjacent.
+	Parents map[string]string
+	// Direction is the rank direction: TB, BT, LR or RL (default TB).
+	Direction string
+}
+
+// Options tunes spacing. Zero values select the defaults.
+type Options struct {
+	NodeSep          float64
+	RankSep          float64
+	GroupPadding     float64
+	GroupLabelHeight float64
+	Margin           float64
+	Iterations       int
+}
+
+// Result holds the computed positions, aligned by index with the input.
+type Result struct {
+	Width, Height float64
+	// Nodes are the node boxes, in input order.
+	Nodes []Rect
+	// Edges are polylines from source border to target border, in input
+	// order. Edges with unknown endpoints have no points.
+	Edges [][]Point
+	// Groups maps each non-empty group to its bounding box including
+	// padding and label space. The box of an enclosing group surrounds
+	// the boxes of its nested groups.
+	Groups map[string]Rect
+}
+
+func (o Options) withDefaults() Options {
+	if o.NodeSep == 0 {
+		o.NodeSep = 24
+	}
+	if o.RankSep == 0 {
+		o.RankSep = 60
+	}
+	if o.GroupPadding == 0 {
+		o.GroupPadding = 12
+	}
+	if o.GroupLabelHeight == 0 {
+		o.GroupLabelHeight = 20
+	}
+	if o.Margin == 0 {
+		o.Margin = 16
+	}
+	if o.Iterations == 0 {
+		o.Iterations = 12
+	}
+	return o
+}
+
+// vertex is a real node or a dummy node of a long edge in the layered graph.
+type vertex struct {
+	node  int // index into Graph.Nodes, -1 for dummies
+	rank  int
+	lane  int
+	order int     // position within its rank
+	rs    float64 // extent along the rank axis
+	cs    float64 // extent along the cross axis
+	cross float64 // center on the cross axis
+	preds []int
+	succs []int
+}
+
+// Layered lays out g and returns the positions of nodes, edges and groups.
+func Layered(g Graph, opts Options) *Result {
+	opts = opts.withDefaults()
+	horizontal := g.Direction == "LR" || g.Direction == "RL"
+
+	l := &layering{opts: opts}
+	l.build(g, horizontal)
+	l.removeCycles()
+	l.assignRanks()
+	l.insertDummies()
+	l.minimizeCrossings()
+	l.assignCoordinates()
+
+	return l.result(g, horizontal)
+}
+
+type layering struct {
+	opts     Options
+	vertices []vertex
+	lanes    int
+	// laneGroups names the group of each lane
+	laneGroups []string
+	parents    map[string]string
+	// edges in the acyclic graph between real nodes, with their input index
+	edges    []layerEdge
+	ranks    [][]int
+	rankPos  []float64
+	laneSpan [][2]float64
+}
+
+type layerEdge struct {
+	input    int
+	from, to int
+	reversed bool
+	chain    []int // vertices from source to target, including dummies
+}
+
+func (l *layering) build(g Graph, horizontal bool) {
+	laneIndex := make(map[string]int)
+	for _, group := range g.Groups {
+		if _, ok := laneIndex[group]; !ok {
+			laneIndex[group] = len(laneIndex)
+		}
+	}
+
+	index := make(map[string]int, len(g.Nodes))
+	for i, n := range g.Nodes {
+		if _, dup := index[n.ID]; dup {
+			continue
+		}
+		index[n.ID] = len(l.vertices)
+		lane, ok := laneIndex[n.Group]
+		if !ok {
+			lane = len(laneIndex)
+			laneIndex[n.Group] = lane
+		}
+		rs, cs := n.Height, n.Width
+		if horizontal {
+			rs, cs = n.Width, n.Height
+		}
+		l.vertices = append(l.vertices, vertex{node: i, lane: lane, rs: rs, cs: cs})
+	}
+	l.lanes = len(laneIndex)
+	l.laneGroups = make([]string, l.lanes)
+	for group, lane := range laneIndex {
+		l.laneGroups[lane] = group
+	}
+	l.parents = g.Parents
+
+	for i, e := range g.Edges {
+		from, okFrom := index[e.From]
+		to, okTo := index[e.To]
+		if !okFrom || !okTo || from == to {
+			continue
+		}
+		l.edges = append(l.edges, layerEdge{input: i, from: from, to: to})
+	}
+}
+
+// removeCycles reverses DFS back edges so the graph becomes acyclic.
+func (l *layering) removeCycles() {
+	out := make([][]int, len(l.vertices))
+	for i, e := range l.edges {
+		out[e.from] = append(out[e.from], i)
+	}
+
+	const (
+		unvisited = iota
+		active
+		done
+	)
+	state := make([]int, len(l.vertices))
+	var visit func(v int)
+	visit = func(v int) {
+		state[v] = active
+		for _, ei := range out[v] {
+			w := l.edges[ei].to
+			switch state[w] {
+			case unvisited:
+				visit(w)
+			case active:
+				l.edges[ei].reversed = true
+			}
+		}
+		state[v] = done
+	}
+	for v := range l.vertices {
+		if state[v] == unvisited {
+			visit(v)
+		}
+	}
+
+	for i := range l.edges {
+		if l.edges[i].reversed {
+			l.edges[i].from, l.edges[i].to = l.edges[i].to, l.edges[i].from
+		}
+	}
+}
+
+// assignRanks places every node on the longest path from a source, then
+// pulls source nodes down next to their successors.
+func (l *layering) assignRanks() {
+	n := len(l.vertices)
+	indeg := make([]int, n)
+	out := make([][]int, n)
+	in := make([][]int, n)
+	for _, e := range l.edges {
+		out[e.from] = append(out[e.from], e.to)
+		in[e.to] = append(in[e.to], e.from)
+		indeg[e.to]++
+	}
+
+	var topo []int
+	var queue []int
+	for v := 0; v < n; v++ {
+		if indeg[v] == 0 {
+			queue = append(queue, v)
+		}
+	}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		topo = append(topo, v)
+		for _, w := range out[v] {
+			if l.vertices[v].rank+1 > l.vertices[w].rank {
+				l.vertices[w].rank = l.vertices[v].rank + 1
+			}
+			indeg[w]--
+			if indeg[w] == 0 {
+				queue = append(queue, w)
+			}
+		}
+	}
+
+	for i := len(topo) - 1; i >= 0; i-- {
+		v := topo[i]
+		if len(in[v]) > 0 || len(out[v]) == 0 {
+			continue
+		}
+		lowest := math.MaxInt
+		for _, w := range out[v] {
+			if l.vertices[w].rank < lowest {
+				lowest = l.vertices[w].rank
+			}
+		}
+		l.vertices[v].rank = lowest - 1
+	}
+}
+
+// insertDummies splits edges spanning several ranks into unit-length
+// segments through dummy vertices, which travel in the source's lane.
+func (l *layering) insertDummies() {
+	linked := make(map[[2]int]bool)
+	link := func(a, b int) {
+		if linked[[2]int{a, b}] {
+			return
+		}
+		linked[[2]int{a, b}] = true
+		l.vertices[a].succs = append(l.vertices[a].succs, b)
+		l.vertices[b].preds = append(l.vertices[b].preds, a)
+	}
+
+	for i := range l.edges {
+		e := &l.edges[i]
+		chain := []int{e.from}
+		prev := e.from
+		for r := l.vertices[e.from].rank + 1; r < l.vertices[e.to].rank; r++ {
+			d := len(l.vertices)
+			l.vertices = append(l.vertices, vertex{
+				node: -1,
+				rank: r,
+				lane: l.vertices[e.from].lane,
+				cs:   l.opts.NodeSep / 2,
+			})
+			link(prev, d)
+			chain = append(chain, d)
+			prev = d
+		}
+		link(prev, e.to)
+		e.chain = append(chain, e.to)
+	}
+
+	maxRank := 0
+	for _, v := range l.vertices {
+		if v.rank > maxRank {
+			maxRank = v.rank
+		}
+	}
+	l.ranks = make([][]int, maxRank+1)
+	for i, v := range l.vertices {
+		l.ranks[v.rank] = append(l.ranks[v.rank], i)
+	}
+	for _, rank := range l.ranks {
+		sort.SliceStable(rank, func(a, b int) bool {
+			return l.vertices[rank[a]].lane < l.vertices[rank[b]].lane
+		})
+		l.renumber(rank)
+	}
+}
+
+func (l *layering) renumber(rank []int) {
+	for i, v := range rank {
+		l.vertices[v].order = i
+	}
+}
+
+// minimizeCrossings reorders each rank by the barycenter of its neighbors,
+// sweeping down and up, and keeps the ordering with the fewest crossings.
+// Vertices never leave their lane.
+func (l *layering) minimizeCrossings() {
+	best := l.snapshot()
+	bestCrossings := l.crossings()
+
+	for iter := 0; iter < l.opts.Iterations && bestCrossings > 0; iter++ {
+		if iter%2 == 0 {
+			for r := 1; r < len(l.ranks); r++ {
+				l.reorder(l.ranks[r], true)
+			}
+		} else {
+			for r := len(l.ranks) - 2; r >= 0; r-- {
+				l.reorder(l.ranks[r], false)
+			}
+		}
+
+		if c := l.crossings(); c < bestCrossings {
+			bestCrossings = c
+			best = l.snapshot()
+		}
+	}
+
+	l.ranks = best
+	for _, rank := range l.ranks {
+		l.renumber(rank)
+	}
+}
+
+func (l *layering) reorder(rank []int, usePreds bool) {
+	bary := make(map[int]float64, len(rank))
+	for _, v := range rank {
+		neighbors := l.vertices[v].succs
+		if usePreds {
+			neighbors = l.vertices[v].preds
+		}
+		if len(neighbors) == 0 {
+			bary[v] = float64(l.vertices[v].order)
+			continue
+		}
+		sum := 0.0
+		for _, w := range neighbors {
+			sum += float64(l.vertices[w].order)
+		}
+		bary[v] = sum / float64(len(neighbors))
+	}
+
+	sort.SliceStable(rank, func(a, b int) bool {
+		va, vb := l.vertices[rank[a]], l.vertices[rank[b]]
+		if va.lane != vb.lane {
+			return va.lane < vb.lane
+		}
+		return bary[rank[a]] < bary[rank[b]]
+	})
+	l.renumber(rank)
+}
+
+func (l *layering) snapshot() [][]int {
+	ranks := make([][]int, len(l.ranks))
+	for i, rank := range l.ranks {
+		ranks[i] = append([]int(nil), rank...)
+	}
+	return ranks
+}
+
+// crossings counts edge crossings between all pairs of adjacent ranks.
+func (l *layering) crossings() int {
+	total := 0
+	for _, rank := range l.ranks {
+		var segs [][2]int
+		for _, v := range rank {
+			for _, w := range l.vertices[v].succs {
+				segs = append(segs, [2]int{l.vertices[v].order, l.vertices[w].order})
+			}
+		}
+		for i := 0; i < len(segs); i++ {
+			for j := i + 1; j < len(segs); j++ {
+				a, b := segs[i], segs[j]
+				if (a[0] < b[0] && a[1] > b[1]) || (a[0] > b[0] && a[1] < b[1]) {
+					total++
+				}
+			}
+		}
+	}
+	return total
+}
+
+// assignCoordinates sizes the lanes, positions the ranks, then moves
+// vertices toward the mean of their neighbors within lane bounds.
+func (l *layering) assignCoordinates() {
+	sep := l.opts.NodeSep
+
+	// Each lane is as wide as its widest rank
+	widths := make([]float64, l.lanes)
+	for _, rank := range l.ranks {
+		used := make([]float64, l.lanes)
+		count := make([]int, l.lanes)
+		for _, v := range rank {
+			vx := l.vertices[v]
+			used[vx.lane] += vx.cs
+			count[vx.lane]++
+		}
+		for lane := range used {
+			if count[lane] > 1 {
+				used[lane] += sep * float64(count[lane]-1)
+			}
+			if used[lane] > widths[lane] {
+				widths[lane] = used[lane]
+			}
+		}
+	}
+
+	laneGap := 2*l.opts.GroupPadding + l.opts.GroupLabelHeight + sep
+	// Each enclosing group that begins or ends between two lanes needs room
+	// for its padding and label
+	nestGap := l.opts.GroupPadding + l.opts.GroupLabelHeight
+	l.laneSpan = make([][2]float64, l.lanes)
+	offset := 0.0
+	prev := -1
+	for lane, w := range widths {
+		if w == 0 {
+			l.laneSpan[lane] = [2]float64{offset, offset}
+			continue
+		}
+		if prev >= 0 {
+			offset += nestGap * float64(l.nestingChange(prev, lane))
+		}
+		l.laneSpan[lane] = [2]float64{offset, offset + w}
+		offset += w + laneGap
+		prev = lane
+	}
+
+	// Pack each rank's lane segment centered in its lane
+	for _, rank := range l.ranks {
+		for _, seg := range l.laneSegments(rank) {
+			lane := l.vertices[seg[0]].lane
+			used := -sep
+			for _, v := range seg {
+				used += l.vertices[v].cs + sep
+			}
+			pos := l.laneSpan[lane][0] + (widths[lane]-used)/2
+			for _, v := range seg {
+				l.vertices[v].cross = pos + l.vertices[v].cs/2
+				pos += l.vertices[v].cs + sep
+			}
+		}
+	}
+
+	for iter := 0; iter < l.opts.Iterations; iter++ {
+		down := iter%2 == 0
+		for i := range l.ranks {
+			r := i
+			if !down {
+				r = len(l.ranks) - 1 - i
+			}
+			for _, seg := range l.laneSegments(l.ranks[r]) {
+				l.align(seg, down)
+			}
+		}
+	}
+
+	// Rank positions are the centers of each rank along the rank axis
+	l.rankPos = make([]float64, len(l.ranks))
+	pos := 0.0
+	for r, rank := range l.ranks {
+		thickness := 0.0
+		for _, v := range rank {
+			if l.vertices[v].rs > thickness {
+				thickness = l.vertices[v].rs
+			}
+		}
+		l.rankPos[r] = pos + thickness/2
+		pos += thickness + l.opts.RankSep
+	}
+}
+
+// nestingChange counts the groups enclosing one of two lanes but not the
+// other.
+func (l *layering) nestingChange(a, b int) int {
+	ancestorsA, ancestorsB := l.ancestors(l.laneGroups[a]), l.ancestors(l.laneGroups[b])
+	n := 0
+	for group := range ancestorsA {
+		if !ancestorsB[group] {
+			n++
+		}
+	}
+	for group := range ancestorsB {
+		if !ancestorsA[group] {
+			n++
+		}
+	}
+	return n
+}
+
+// ancestors returns the groups enclosing group.
+func (l *layering) ancestors(group string) map[string]bool {
+	ancestors := make(map[string]bool)
+	for {
+		parent, ok := l.parents[group]
+		if !ok || ancestors[parent] || parent == group {
+			return ancestors
+		}
+		ancestors[parent] = true
+		group = parent
+	}
+}
+
+// laneSegments splits a rank into runs of vertices sharing a lane.
+func (l *layering) laneSegments(rank []int) [][]int {
+	var segs [][]int
+	for i, v := range rank {
+		if i == 0 || l.vertices[rank[i-1]].lane != l.vertices[v].lane {
+			segs = append(segs, nil)
+		}
+		segs[len(segs)-1] = append(segs[len(segs)-1], v)
+	}
+	return segs
+}
+
+// align moves the vertices of one lane segment toward the mean cross
+// position of their neighbors, preserving order, spacing and lane bounds.
+func (l *layering) align(seg []int, usePreds bool) {
+	sep := l.opts.NodeSep
+	span := l.laneSpan[l.vertices[seg[0]].lane]
+
+	desired := make([]float64, len(seg))
+	for i, v := range seg {
+		vx := l.vertices[v]
+		neighbors := vx.succs
+		if usePreds {
+			neighbors = vx.preds
+		}
+		desired[i] = vx.cross
+		if len(neighbors) > 0 {
+			sum := 0.0
+			for _, w := range neighbors {
+				sum += l.vertices[w].cross
+			}
+			desired[i] = sum / float64(len(neighbors))
+		}
+	}
+
+	limit := span[0]
+	for i, v := range seg {
+		half := l.vertices[v].cs / 2
+		desired[i] = math.Max(desired[i], limit+half)
+		limit = desired[i] + half + sep
+	}
+	limit = span[1]
+	for i := len(seg) - 1; i >= 0; i-- {
+		half := l.vertices[seg[i]].cs / 2
+		desired[i] = math.Min(desired[i], limit-half)
+		limit = desired[i] - half - sep
+	}
+	limit = span[0]
+	for i, v := range seg {
+		half := l.vertices[v].cs / 2
+		desired[i] = math.Max(desired[i], limit+half)
+		limit = desired[i] + half + sep
+		l.vertices[v].cross = desired[i]
+	}
+}
+
+func (l *layering) result(g Graph, horizontal bool) *Result {
+	res := &Result{
+		Nodes:  make([]Rect, len(g.Nodes)),
+		Edges:  make([][]Point, len(g.Edges)),
+		Groups: make(map[string]Rect),
+	}
+
+	totalRank := 0.0
+	if n := len(l.rankPos); n > 0 {
+		totalRank = l.rankPos[n-1] * 2
+	}
+	mirror := g.Direction == "BT" || g.Direction == "RL"
+
+	center := func(v vertex) Point {
+		rank := l.rankPos[v.rank]
+		if mirror {
+			rank = totalRank - rank
+		}
+		if horizontal {
+			return Point{X: rank, Y: v.cross}
+		}
+		return Point{X: v.cross, Y: rank}
+	}
+
+	placed := make(map[string]Rect)
+	for _, v := range l.vertices {
+		if v.node < 0 {
+			continue
+		}
+		n := g.Nodes[v.node]
+		c := center(v)
+		placed[n.ID] = Rect{X: c.X - n.Width/2, Y: c.Y - n.Height/2, Width: n.Width, Height: n.Height}
+	}
+	for i, n := range g.Nodes {
+		res.Nodes[i] = placed[n.ID]
+	}
+
+	for _, e := range l.edges {
+		points := make([]Point, len(e.chain))
+		for i, v := range e.chain {
+			points[i] = center(l.vertices[v])
+		}
+		if e.reversed {
+			for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+				points[i], points[j] = points[j], points[i]
+			}
+		}
+		edge := g.Edges[e.input]
+		points[0] = clip(placed[edge.From], points[0], points[1])
+		last := len(points) - 1
+		points[last] = clip(placed[edge.To], points[last], points[last-1])
+		res.Edges[e.input] = points
+	}
+
+	// Self loops leave and re-enter the node on its right side
+	for i, e := range g.Edges {
+		r, ok := placed[e.From]
+		if !ok || e.From != e.To {
+			continue
+		}
+		right := r.X + r.Width
+		top, bottom := r.Y+r.Height/4, r.Y+r.Height*3/4
+		loop := l.opts.NodeSep
+		res.Edges[i] = []Point{{right, top}, {right + loop, top}, {right + loop, bottom}, {right, bottom}}
+	}
+
+	for _, n := range g.Nodes {
+		if n.Group == "" {
+			continue
+		}
+		r := placed[n.ID]
+		pad := l.opts.GroupPadding
+		box := Rect{X: r.X - pad, Y: r.Y - pad - l.opts.GroupLabelHeight, Width: r.Width + 2*pad, Height: r.Height + 2*pad + l.opts.GroupLabelHeight}
+		if existing, ok := res.Groups[n.Group]; ok {
+			box = union(existing, box)
+		}
+		res.Groups[n.Group] = box
+	}
+
+	// Enclosing groups surround their nested groups, innermost first so
+	// that every box is complete before it is enclosed
+	nested := make([]string, 0, len(l.parents))
+	depth := make(map[string]int, len(l.parents))
+	for group := range l.parents {
+		nested = append(nested, group)
+		depth[group] = len(l.ancestors(group))
+	}
+	sort.Slice(nested, func(i, j int) bool {
+		if depth[nested[i]] != depth[nested[j]] {
+			return depth[nested[i]] > depth[nested[j]]
+		}
+		return nested[i] < nested[j]
+	})
+	for _, group := range nested {
+		r, ok := res.Groups[group]
+		parent := l.parents[group]
+		if !ok || parent == group {
+			continue
+		}
+		pad := l.opts.GroupPadding
+		box := Rect{X: r.X - pad, Y: r.Y - pad - l.opts.GroupLabelHeight, Width: r.Width + 2*pad, Height: r.Height + 2*pad + l.opts.GroupLabelHeight}
+		if existing, ok := res.Groups[parent]; ok {
+			box = union(existing, box)
+		}
+		res.Groups[parent] = box
+	}
+
+	res.translate(l.opts.Margin)
+	return res
+}
+
+// translate shifts everything so the drawing starts at the margin, and sets
+// the overall size.
+func (res *Result) translate(margin float64) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	extend := func(r Rect) {
+		minX, minY = math.Min(minX, r.X), math.Min(minY, r.Y)
+		maxX, maxY = math.Max(maxX, r.X+r.Width), math.Max(maxY, r.Y+r.Height)
+	}
+	for _, r := range res.Nodes {
+		extend(r)
+	}
+	for _, r := range res.Groups {
+		extend(r)
+	}
+	for _, points := range res.Edges {
+		for _, p := range points {
+			extend(Rect{X: p.X, Y: p.Y})
+		}
+	}
+	if math.IsInf(minX, 1) {
+		return
+	}
+
+	dx, dy := margin-minX, margin-minY
+	for i := range res.Nodes {
+		res.Nodes[i].X += dx
+		res.Nodes[i].Y += dy
+	}
+	for name, r := range res.Groups {
+		r.X += dx
+		r.Y += dy
+		res.Groups[name] = r
+	}
+	for _, points := range res.Edges {
+		for i := range points {
+			points[i].X += dx
+			points[i].Y += dy
+		}
+	}
+	res.Width = maxX - minX + 2*margin
+	res.Height = maxY - minY + 2*margin
+}
+
+func union(a, b Rect) Rect {
+	x, y := math.Min(a.X, b.X), math.Min(a.Y, b.Y)
+	return Rect{
+		X:      x,
+		Y:      y,
+		Width:  math.Max(a.X+a.Width, b.X+b.Width) - x,
+		Height: math.Max(a.Y+a.Height, b.Y+b.Height) - y,
+	}
+}
+
+// clip returns where the segment from inside (the center of r) toward
+// outside crosses the border of r.
+func clip(r Rect, inside, outside Point) Point {
+	dx, dy := outside.X-inside.X, outside.Y-inside.Y
+	if dx == 0 && dy == 0 {
+		return inside
+	}
+	tx, ty := math.Inf(1), math.Inf(1)
+	if dx != 0 {
+		tx = (r.Width / 2) / math.Abs(dx)
+	}
+	if dy != 0 {
+		ty = (r.Height / 2) / math.Abs(dy)
+	}
+	t := math.Min(math.Min(tx, ty), 1)
+	return Point{X: inside.X + dx*t, Y: inside.Y + dy*t}
+}
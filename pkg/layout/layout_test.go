@@ -0,0 +1,294 @@
+package layout
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// graph builds a graph from edges written "A>B", with nodes sized by the
+// length of their IDs.
+func graph(direction string, edges ...string) Graph {
+	g := Graph{Direction: direction}
+	seen := make(map[string]bool)
+	for _, e := range edges {
+		from, to, _ := strings.Cut(e, ">")
+		for _, id := range []string{from, to} {
+			if id != "" && id != "?" && !seen[id] {
+				seen[id] = true
+				g.Nodes = append(g.Nodes, Node{ID: id, Width: 40 + 10*float64(len(id)), Height: 30})
+			}
+		}
+		if to != "" {
+			g.Edges = append(g.Edges, Edge{From: from, To: to})
+		}
+	}
+	return g
+}
+
+// grouped puts nodes in groups, given as "group:node,node".
+func grouped(g Graph, groups ...string) Graph {
+	for _, spec := range groups {
+		group, nodes, _ := strings.Cut(spec, ":")
+		g.Groups = append(g.Groups, group)
+		for _, id := range strings.Split(nodes, ",") {
+			for i := range g.Nodes {
+				if g.Nodes[i].ID == id {
+					g.Nodes[i].Group = group
+				}
+			}
+		}
+	}
+	return g
+}
+
+var layouts = []struct {
+	name  string
+	graph Graph
+}{
+	{"empty", Graph{}},
+	{"chain", graph("", "A>B", "B>C")},
+	{"fan", graph("", "A>B", "A>C", "A>D", "B>E", "D>E")},
+	{"long-edge", graph("", "A>B", "B>C", "C>D", "A>D")},
+	{"crossing", graph("", "A>D", "B>C", "A>C", "B>D")},
+	{"cycle", graph("", "A>B", "B>C", "C>A")},
+	{"two-cycle", graph("", "A>B", "B>A")},
+	{"self-loop", graph("", "A>A", "A>B")},
+	{"disconnected", graph("", "A>B", "C>D", "E>", "F>")},
+	{"unknown-endpoint", graph("", "A>B", "A>?", "?>B")},
+	{"lr", graph("LR", "A>B", "B>C", "A>C")},
+	{"bt", graph("BT", "A>B", "B>C", "A>C")},
+	{"rl-cycle", graph("RL", "A>B", "B>C", "C>A")},
+	{"groups", grouped(graph("", "Web>API", "API>DB", "API>Cache", "Worker>DB", "Lone>"),
+		"frontend:Web", "backend:API,Worker", "data:DB,Cache")},
+	{"groups-lr", grouped(graph("LR", "Web>API", "API>DB", "Worker>DB", "DB>Web"),
+		"frontend:Web", "backend:API,Worker", "data:DB")},
+	{"nested-groups", func() Graph {
+		g := grouped(graph("", "Web>API", "API>DB", "API>Queue", "Queue>Worker", "Worker>DB"),
+			"shop/frontend:Web", "shop/backend:API,Worker", "shop/data:DB", "ops:Queue")
+		g.Parents = map[string]string{"shop/frontend": "shop", "shop/backend": "shop", "shop/data": "shop"}
+		return g
+	}()},
+}
+
+// dump writes a layout as text, one box or polyline per line.
+func dump(g Graph, res *Result) string {
+	var sb strings.Builder
+	p := func(v float64) string { return fmt.Sprintf("%.1f", v) }
+	box := func(r Rect) string { return p(r.X) + "," + p(r.Y) + " " + p(r.Width) + "x" + p(r.Height) }
+	fmt.Fprintf(&sb, "size %sx%s\n", p(res.Width), p(res.Height))
+	for i, n := range g.Nodes {
+		fmt.Fprintf(&sb, "node %s %s\n", n.ID, box(res.Nodes[i]))
+	}
+	groups := make([]string, 0, len(res.Groups))
+	for name := range res.Groups {
+		groups = append(groups, name)
+	}
+	sort.Strings(groups)
+	for _, name := range groups {
+		fmt.Fprintf(&sb, "group %s %s\n", name, box(res.Groups[name]))
+	}
+	for i, e := range g.Edges {
+		fmt.Fprintf(&sb, "edge %s>%s", e.From, e.To)
+		for _, pt := range res.Edges[i] {
+			fmt.Fprintf(&sb, " %s,%s", p(pt.X), p(pt.Y))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("layout differs from %s (run go test -update to accept it):\n%s", path, got)
+	}
+}
+
+func TestLayeredGolden(t *testing.T) {
+	for _, tt := range layouts {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGolden(t, tt.name+".golden", dump(tt.graph, Layered(tt.graph, Options{})))
+		})
+	}
+}
+
+func TestLayeredDeterministic(t *testing.T) {
+	for _, tt := range layouts {
+		want := dump(tt.graph, Layered(tt.graph, Options{}))
+		for i := 0; i < 5; i++ {
+			if got := dump(tt.graph, Layered(tt.graph, Options{})); got != want {
+				t.Fatalf("%s: layout changed between runs:\n%s\nthen\n%s", tt.name, want, got)
+			}
+		}
+	}
+}
+
+// TestLayeredInvariants checks properties every layout must have,
+// independent of the exact positions in the golden files.
+func TestLayeredInvariants(t *testing.T) {
+	const eps = 1e-6
+	inside := func(inner, outer Rect) bool {
+		return inner.X >= outer.X-eps && inner.Y >= outer.Y-eps &&
+			inner.X+inner.Width <= outer.X+outer.Width+eps && inner.Y+inner.Height <= outer.Y+outer.Height+eps
+	}
+	overlap := func(a, b Rect) bool {
+		return a.X < b.X+b.Width-eps && b.X < a.X+a.Width-eps && a.Y < b.Y+b.Height-eps && b.Y < a.Y+a.Height-eps
+	}
+	onBorder := func(r Rect, p Point) bool {
+		onX := math.Abs(p.X-r.X) < eps || math.Abs(p.X-r.X-r.Width) < eps
+		onY := math.Abs(p.Y-r.Y) < eps || math.Abs(p.Y-r.Y-r.Height) < eps
+		return inside(Rect{X: p.X, Y: p.Y}, r) && (onX || onY)
+	}
+
+	for _, tt := range layouts {
+		t.Run(tt.name, func(t *testing.T) {
+			g := tt.graph
+			res := Layered(g, Options{})
+			bounds := Rect{Width: res.Width, Height: res.Height}
+			index := make(map[string]int)
+			for i, n := range g.Nodes {
+				index[n.ID] = i
+			}
+
+			for i, n := range g.Nodes {
+				r := res.Nodes[i]
+				if r.Width != n.Width || r.Height != n.Height {
+					t.Errorf("node %s is %vx%v, want %vx%v", n.ID, r.Width, r.Height, n.Width, n.Height)
+				}
+				if !inside(r, bounds) {
+					t.Errorf("node %s at %+v is outside the drawing %+v", n.ID, r, bounds)
+				}
+				for j := i + 1; j < len(g.Nodes); j++ {
+					if overlap(r, res.Nodes[j]) {
+						t.Errorf("nodes %s and %s overlap", n.ID, g.Nodes[j].ID)
+					}
+				}
+				if n.Group != "" && !inside(r, res.Groups[n.Group]) {
+					t.Errorf("node %s is outside its group %s", n.ID, n.Group)
+				}
+			}
+
+			for name, r := range res.Groups {
+				if parent, ok := g.Parents[name]; ok && !inside(r, res.Groups[parent]) {
+					t.Errorf("group %s is outside its parent %s", name, parent)
+				}
+				for other, o := range res.Groups {
+					related := other == name || g.Parents[name] == other || g.Parents[other] == name
+					if !related && overlap(r, o) {
+						t.Errorf("groups %s and %s overlap", name, other)
+					}
+				}
+			}
+
+			for i, e := range g.Edges {
+				points := res.Edges[i]
+				from, okFrom := index[e.From]
+				to, okTo := index[e.To]
+				if !okFrom || !okTo {
+					if len(points) != 0 {
+						t.Errorf("edge %s>%s to an unknown node has points %v", e.From, e.To, points)
+					}
+					continue
+				}
+				if len(points) < 2 {
+					t.Errorf("edge %s>%s has %d points", e.From, e.To, len(points))
+					continue
+				}
+				if !onBorder(res.Nodes[from], points[0]) || !onBorder(res.Nodes[to], points[len(points)-1]) {
+					t.Errorf("edge %s>%s runs from %v to %v, not between the node borders", e.From, e.To, points[0], points[len(points)-1])
+				}
+			}
+		})
+	}
+}
+
+// TestLayeredRanks checks that edges of acyclic graphs point along the
+// rank direction, and that cycles are drawn with the other edges doing so.
+func TestLayeredRanks(t *testing.T) {
+	tests := []struct {
+		graph    Graph
+		backward int // edges pointing against the direction
+	}{
+		{graph("", "A>B", "B>C", "A>C"), 0},
+		{graph("BT", "A>B", "B>C", "A>C"), 0},
+		{graph("LR", "A>B", "B>C", "A>C"), 0},
+		{graph("RL", "A>B", "B>C", "A>C"), 0},
+		{graph("", "A>B", "B>C", "C>A"), 1},
+		{graph("", "A>B", "B>A", "C>D", "D>C"), 2},
+	}
+	for _, tt := range tests {
+		res := Layered(tt.graph, Options{})
+		backward := 0
+		for i, e := range tt.graph.Edges {
+			a, b := res.Nodes[0].Center(), res.Nodes[0].Center()
+			for j, n := range tt.graph.Nodes {
+				if n.ID == e.From {
+					a = res.Nodes[j].Center()
+				}
+				if n.ID == e.To {
+					b = res.Nodes[j].Center()
+				}
+			}
+			var along float64
+			switch tt.graph.Direction {
+			case "BT":
+				along = a.Y - b.Y
+			case "LR":
+				along = b.X - a.X
+			case "RL":
+				along = a.X - b.X
+			default:
+				along = b.Y - a.Y
+			}
+			if along < 0 {
+				backward++
+			}
+			if along == 0 {
+				t.Errorf("%s: edge %d connects nodes of the same rank", tt.graph.Direction, i)
+			}
+		}
+		if backward != tt.backward {
+			t.Errorf("%s %v: %d edges point backward, want %d", tt.graph.Direction, tt.graph.Edges, backward, tt.backward)
+		}
+	}
+}
+
+func TestClip(t *testing.T) {
+	r := Rect{X: 0, Y: 0, Width: 40, Height: 20}
+	tests := []struct {
+		outside Point
+		want    Point
+	}{
+		{Point{20, 100}, Point{20, 20}},
+		{Point{100, 10}, Point{40, 10}},
+		{Point{0, -10}, Point{10, 0}},
+		{Point{20, 10}, Point{20, 10}},
+	}
+	for _, tt := range tests {
+		if got := clip(r, r.Center(), tt.outside); got != tt.want {
+			t.Errorf("clip(toward %v) = %v, want %v", tt.outside, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Package protoconv converts between the YAML model in pkg/gorph and the
+// protobuf messages of the gorph.v1 API.
+//
+// Converting a parsed definition to a message and back yields the same
+// model, and marshaling it reads back the same, except for:
+//
+//   - attribute lists, which the model already holds as one string
+//     joined with ", " (see gorph.Attributes);
+//   - attribute values that read as numbers or booleans, such as
+//     `port: 8081`, which are written quoted as the strings they are held
+//     as (`port: "8081"`);
+//   - deployment_config timestamps, which become strings in their
+//     original layout;
+//   - deployment_config floats without a fraction, such as 3.0, which
+//     become the integer 3;
+//   - deployment_config map keys other than strings, which become strings;
+//   - namespace and include, which messages do not carry: entity IDs are
+//     stored qualified by their namespace, and includes are not followed.
+//
+// Integers beyond ±2^53 and non-finite numbers in deployment_config are
+// rejected rather than changed.
+package protoconv
+
+import (
+	"fmt"
+	"strings"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/gorph"
+)
+
+const (
+	categoryPrefix       = "CATEGORY_"
+	statusPrefix         = "STATUS_"
+	connectionTypePrefix = "CONNECTION_TYPE_"
+)
+
+// connectionTypeNames are the spellings used in YAML files and style.yml.
+var connectionTypeNames = map[pb.ConnectionType]string{
+	pb.ConnectionType_CONNECTION_TYPE_HTTP_REQUEST:     "HTTP_Request",
+	pb.ConnectionType_CONNECTION_TYPE_API_CALL:         "API_Call",
+	pb.ConnectionType_CONNECTION_TYPE_DB_CONNECTION:    "DB_Connection",
+	pb.ConnectionType_CONNECTION_TYPE_SERVICE_CALL:     "Service_Call",
+	pb.ConnectionType_CONNECTION_TYPE_USER_INTERACTION: "User_Interaction",
+	pb.ConnectionType_CONNECTION_TYPE_INTERNAL_API:     "Internal_API",
+	pb.ConnectionType_CONNECTION_TYPE_DEPLOYS:          "Deploys",
+	pb.ConnectionType_CONNECTION_TYPE_HOSTS:            "Hosts",
+	pb.ConnectionType_CONNECTION_TYPE_TRIGGERS_BUILD:   "Triggers_Build",
+	pb.ConnectionType_CONNECTION_TYPE_PUSHES_IMAGE:     "Pushes_Image",
+	pb.ConnectionType_CONNECTION_TYPE_UPDATES_CONFIG:   "Updates_Config",
+	pb.ConnectionType_CONNECTION_TYPE_WATCHES_CONFIG:   "Watches_Config",
+	pb.ConnectionType_CONNECTION_TYPE_DEPLOYS_TO:       "Deploys_To",
+}
+
+// CategoryToProto maps a YAML category such as "BACKEND" to its enum value,
+// ignoring case. It reports false for categories without an enum value,
+// which map to CATEGORY_UNSPECIFIED. An empty category maps to
+// CATEGORY_UNSPECIFIED.
+func CategoryToProto(category string) (pb.Category, bool) {
+	if category == "" {
+		return pb.Category_CATEGORY_UNSPECIFIED, true
+	}
+	value, ok := pb.Category_value[categoryPrefix+strings.ToUpper(category)]
+	return pb.Category(value), ok
+}
+
+// CategoryFromProto maps a category enum value to its YAML spelling.
+func CategoryFromProto(category pb.Category) string {
+	if category == pb.Category_CATEGORY_UNSPECIFIED {
+		return ""
+	}
+	return strings.TrimPrefix(category.String(), categoryPrefix)
+}
+
+// StatusToProto maps a YAML status such as "healthy" to its enum value,
+// like CategoryToProto.
+func StatusToProto(status string) (pb.Status, bool) {
+	if status == "" {
+		return pb.Status_STATUS_UNSPECIFIED, true
+	}
+	value, ok := pb.Status_value[statusPrefix+strings.ToUpper(status)]
+	return pb.Status(value), ok
+}
+
+// StatusFromProto maps a status enum value to its YAML spelling.
+func StatusFromProto(status pb.Status) string {
+	if status == pb.Status_STATUS_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(status.String(), statusPrefix))
+}
+
+// ConnectionTypeToProto maps a YAML connection type such as "API_Call" to
+// its enum value, like CategoryToProto.
+func ConnectionTypeToProto(connType string) (pb.ConnectionType, bool) {
+	if connType == "" {
+		return pb.ConnectionType_CONNECTION_TYPE_UNSPECIFIED, true
+	}
+	value, ok := pb.ConnectionType_value[connectionTypePrefix+strings.ToUpper(connType)]
+	return pb.ConnectionType(value), ok
+}
+
+// ConnectionTypeFromProto maps a connection type enum value to the spelling
+// used in YAML files and style.yml.
+func ConnectionTypeFromProto(connType pb.ConnectionType) string {
+	if name, ok := connectionTypeNames[connType]; ok {
+		return name
+	}
+	if connType == pb.ConnectionType_CONNECTION_TYPE_UNSPECIFIED {
+		return ""
+	}
+	return strings.TrimPrefix(connType.String(), connectionTypePrefix)
+}
+
+// YAML values are free strings, so a value may have no enum value, like a
+// custom "Queue" category, or be spelled differently from the enum's YAML
+// spelling, like "backend". Such values are kept verbatim in the message's
+// *_name field next to the enum (CATEGORY_UNSPECIFIED for values without
+// one), and converting back returns the original string. A name that no
+// longer spells the enum value, because a client changed only the enum, is
+// ignored.
+
+// enumToProto converts a YAML value, returning the enum value and the name
+// to keep alongside it.
+func enumToProto[E comparable](value string, toProto func(string) (E, bool), fromProto func(E) string) (E, string) {
+	e, _ := toProto(value)
+	if fromProto(e) == value {
+		return e, ""
+	}
+	return e, value
+}
+
+// enumFromProto returns the YAML value of an enum and its kept name.
+func enumFromProto[E comparable](e E, name string, toProto func(string) (E, bool), fromProto func(E) string) string {
+	if name != "" {
+		if named, _ := toProto(name); named == e {
+			return name
+		}
+	}
+	return fromProto(e)
+}
+
+// EntityToProto converts a YAML entity to its protobuf message. It fails
+// only for deployment configs that a Struct cannot represent exactly.
+func EntityToProto(entity gorph.Entity) (*pb.Entity, error) {
+	msg := &pb.Entity{
+		Id:          entity.ID,
+		Description: entity.Description,
+		Owner:       entity.Owner,
+		Environment: entity.Environment,
+		Tags:        entity.Tags,
+		Attributes:  entity.Attributes,
+		Shape:       entity.Shape,
+		Icon:        entity.Icon,
+	}
+	msg.Category, msg.CategoryName = enumToProto(entity.Category, CategoryToProto, CategoryFromProto)
+	msg.Status, msg.StatusName = enumToProto(entity.Status, StatusToProto, StatusFromProto)
+	if entity.DeploymentConfig != nil {
+		config, err := DeploymentConfigToProto(entity.DeploymentConfig)
+		if err != nil {
+			return nil, fmt.Errorf("entity %s: deployment_config: %w", entity.ID, err)
+		}
+		msg.DeploymentConfig = config
+	}
+	return msg, nil
+}
+
+// EntityFromProto converts an entity message to the YAML model.
+func EntityFromProto(msg *pb.Entity) gorph.Entity {
+	entity := gorph.Entity{
+		ID:          msg.GetId(),
+		Category:    enumFromProto(msg.GetCategory(), msg.GetCategoryName(), CategoryToProto, CategoryFromProto),
+		Description: msg.GetDescription(),
+		Status:      enumFromProto(msg.GetStatus(), msg.GetStatusName(), StatusToProto, StatusFromProto),
+		Owner:       msg.GetOwner(),
+		Environment: msg.GetEnvironment(),
+		Tags:        msg.GetTags(),
+		Attributes:  msg.GetAttributes(),
+		Shape:       msg.GetShape(),
+		Icon:        msg.GetIcon(),
+	}
+	if msg.GetDeploymentConfig() != nil {
+		entity.DeploymentConfig = DeploymentConfigFromProto(msg.GetDeploymentConfig())
+	}
+	return entity
+}
+
+// ConnectionToProto converts a YAML connection to its protobuf message.
+func ConnectionToProto(conn gorph.Connection) *pb.Connection {
+	msg := &pb.Connection{From: conn.From, To: conn.To, Attributes: conn.Attributes}
+	msg.Type, msg.TypeName = enumToProto(conn.Type, ConnectionTypeToProto, ConnectionTypeFromProto)
+	return msg
+}
+
+// ConnectionFromProto converts a connection message to the YAML model.
+func ConnectionFromProto(msg *pb.Connection) gorph.Connection {
+	return gorph.Connection{
+		From:       msg.GetFrom(),
+		To:         msg.GetTo(),
+		Type:       enumFromProto(msg.GetType(), msg.GetTypeName(), ConnectionTypeToProto, ConnectionTypeFromProto),
+		Attributes: msg.GetAttributes(),
+	}
+}
+
+// InfrastructureToProto converts a YAML definition. Fields that YAML does
+// not carry, such as the ID, are left empty for the caller to fill in.
+func InfrastructureToProto(infra *gorph.Infrastructure) (*pb.Infrastructure, error) {
+	msg := &pb.Infrastructure{Name: infra.Name, Description: infra.Description, Admins: infra.Admins}
+	for _, entity := range infra.Entities {
+		e, err := EntityToProto(entity)
+		if err != nil {
+			return nil, err
+		}
+		msg.Entities = append(msg.Entities, e)
+	}
+	for _, conn := range infra.Connections {
+		msg.Connections = append(msg.Connections, ConnectionToProto(conn))
+	}
+	return msg, nil
+}
+
+// InfrastructureFromProto converts an infrastructure message to the YAML
+// model used by the validator and the generators.
+func InfrastructureFromProto(msg *pb.Infrastructure) *gorph.Infrastructure {
+	infra := &gorph.Infrastructure{Name: msg.GetName(), Description: msg.GetDescription(), Admins: msg.GetAdmins()}
+	for _, entity := range msg.GetEntities() {
+		infra.Entities = append(infra.Entities, EntityFromProto(entity))
+	}
+	for _, conn := range msg.GetConnections() {
+		infra.Connections = append(infra.Connections, ConnectionFromProto(conn))
+	}
+	return infra
+}
@@ -0,0 +1,281 @@
+package protoconv
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/gorph"
+)
+
+func TestEntityRoundTrip(t *testing.T) {
+	tests := []struct {
+		name         string
+		category     string
+		status       string
+		wantCategory pb.Category
+		wantStatus   pb.Status
+		wantNames    [2]string
+	}{
+		{"enum values", "BACKEND", "healthy", pb.Category_CATEGORY_BACKEND, pb.Status_STATUS_HEALTHY, [2]string{}},
+		{"other case", "Backend", "Healthy", pb.Category_CATEGORY_BACKEND, pb.Status_STATUS_HEALTHY, [2]string{"Backend", "Healthy"}},
+		{"custom names", "QUEUE", "retired", pb.Category_CATEGORY_UNSPECIFIED, pb.Status_STATUS_UNSPECIFIED, [2]string{"QUEUE", "retired"}},
+		{"empty", "", "", pb.Category_CATEGORY_UNSPECIFIED, pb.Status_STATUS_UNSPECIFIED, [2]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity := gorph.Entity{ID: "API", Category: tt.category, Status: tt.status, Description: "d"}
+			msg, err := EntityToProto(entity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg.GetCategory() != tt.wantCategory || msg.GetStatus() != tt.wantStatus {
+				t.Errorf("enums = %v, %v, want %v, %v", msg.GetCategory(), msg.GetStatus(), tt.wantCategory, tt.wantStatus)
+			}
+			if names := [2]string{msg.GetCategoryName(), msg.GetStatusName()}; names != tt.wantNames {
+				t.Errorf("names = %q, want %q", names, tt.wantNames)
+			}
+			if got := EntityFromProto(msg); !reflect.DeepEqual(got, entity) {
+				t.Errorf("round trip = %+v, want %+v", got, entity)
+			}
+		})
+	}
+}
+
+func TestConnectionRoundTrip(t *testing.T) {
+	tests := []struct {
+		connType string
+		want     pb.ConnectionType
+		wantName string
+	}{
+		{"API_Call", pb.ConnectionType_CONNECTION_TYPE_API_CALL, ""},
+		{"api_call", pb.ConnectionType_CONNECTION_TYPE_API_CALL, "api_call"},
+		{"Deploys_To", pb.ConnectionType_CONNECTION_TYPE_DEPLOYS_TO, ""},
+		{"Publishes", pb.ConnectionType_CONNECTION_TYPE_UNSPECIFIED, "Publishes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.connType, func(t *testing.T) {
+			conn := gorph.Connection{From: "API", To: "Bus", Type: tt.connType, Attributes: gorph.Attributes{"port": "8081"}}
+			msg := ConnectionToProto(conn)
+			if msg.GetType() != tt.want || msg.GetTypeName() != tt.wantName {
+				t.Errorf("type = %v, %q, want %v, %q", msg.GetType(), msg.GetTypeName(), tt.want, tt.wantName)
+			}
+			if got := ConnectionFromProto(msg); !reflect.DeepEqual(got, conn) {
+				t.Errorf("round trip = %+v, want %+v", got, conn)
+			}
+		})
+	}
+}
+
+func TestDeploymentConfigRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{
+			name:   "scalars",
+			config: map[string]interface{}{"replicas": 3, "ratio": 0.5, "enabled": true, "image": "app:v1", "none": nil},
+			want:   map[string]interface{}{"replicas": 3, "ratio": 0.5, "enabled": true, "image": "app:v1", "none": nil},
+		},
+		{
+			name:   "integer types",
+			config: map[string]interface{}{"a": int64(-7), "b": uint8(8), "c": uint64(1 << 53), "d": -(1 << 53)},
+			want:   map[string]interface{}{"a": -7, "b": 8, "c": 1 << 53, "d": -(1 << 53)},
+		},
+		{
+			name:   "whole float",
+			config: map[string]interface{}{"cpu": 3.0},
+			want:   map[string]interface{}{"cpu": 3},
+		},
+		{
+			name: "nested",
+			config: map[string]interface{}{
+				"env":       []interface{}{map[string]interface{}{"name": "PORT", "value": "8080"}},
+				"resources": map[string]interface{}{"limits": map[string]interface{}{"memory": "1Gi", "cpu": 2}},
+			},
+			want: map[string]interface{}{
+				"env":       []interface{}{map[string]interface{}{"name": "PORT", "value": "8080"}},
+				"resources": map[string]interface{}{"limits": map[string]interface{}{"memory": "1Gi", "cpu": 2}},
+			},
+		},
+		{
+			name:   "non-string keys",
+			config: map[string]interface{}{"ports": map[interface{}]interface{}{80: "http", true: "yes"}},
+			want:   map[string]interface{}{"ports": map[string]interface{}{"80": "http", "true": "yes"}},
+		},
+		{
+			name: "timestamps",
+			config: map[string]interface{}{
+				"date":     time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+				"datetime": time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+				"zoned":    time.Date(2024, 1, 2, 15, 4, 5, 0, time.FixedZone("", 2*60*60)),
+			},
+			want: map[string]interface{}{
+				"date":     "2024-01-02",
+				"datetime": "2024-01-02T15:04:05Z",
+				"zoned":    "2024-01-02T15:04:05+02:00",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := DeploymentConfigToProto(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := DeploymentConfigFromProto(s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("round trip = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeploymentConfigRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		want   string
+	}{
+		{"int too large", map[string]interface{}{"n": 1<<53 + 1}, "n: integer 9007199254740993 is too large to store exactly"},
+		{"int too small", map[string]interface{}{"n": int64(-(1<<53 + 1))}, "n: integer -9007199254740993 is too large to store exactly"},
+		{"uint too large", map[string]interface{}{"n": uint64(math.MaxUint64)}, "n: integer 18446744073709551615 is too large to store exactly"},
+		{"nested", map[string]interface{}{"a": []interface{}{map[string]interface{}{"b": 1<<53 + 1}}}, "a: [0]: b: integer 9007199254740993 is too large to store exactly"},
+		{"infinite", map[string]interface{}{"n": math.Inf(1)}, "n: number +Inf cannot be stored"},
+		{"NaN", map[string]interface{}{"n": math.NaN()}, "n: number NaN cannot be stored"},
+		{"unsupported", map[string]interface{}{"n": struct{}{}}, "n: unsupported value of type struct {}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DeploymentConfigToProto(tt.config)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntityToProtoError(t *testing.T) {
+	entity := gorph.Entity{ID: "API", DeploymentConfig: map[string]interface{}{"n": 1<<53 + 1}}
+	_, err := EntityToProto(entity)
+	want := "entity API: deployment_config: n: integer 9007199254740993 is too large to store exactly"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+// TestTemplatesRoundTrip converts the templates to messages and back, and
+// checks that the marshaled YAML parses to the same model.
+func TestTemplatesRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("../../templates/*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no templates found")
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := gorph.ParseInfrastructure(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := gorph.ParseInfrastructure(roundTrip(t, want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Entities, want.Entities) {
+				t.Errorf("entities = %+v, want %+v", got.Entities, want.Entities)
+			}
+			if !reflect.DeepEqual(got.Connections, want.Connections) {
+				t.Errorf("connections = %+v, want %+v", got.Connections, want.Connections)
+			}
+			if got.Name != want.Name || got.Description != want.Description {
+				t.Errorf("name = %q, %q, want %q, %q", got.Name, got.Description, want.Name, want.Description)
+			}
+		})
+	}
+}
+
+// TestRoundTripLossy pins the documented differences in the YAML written
+// back.
+func TestRoundTripLossy(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"number attribute", "attributes:\n      port: 8081", "attributes:\n      port: \"8081\"\n"},
+		{"bool attribute", "attributes:\n      enabled: true", "attributes:\n      enabled: \"true\"\n"},
+		{"quoted attribute", "attributes:\n      version: \"7.10\"", "attributes:\n      version: \"7.10\"\n"},
+		{"list attribute", "attributes:\n      functions: [a, b]", "attributes:\n      functions: a, b\n"},
+		{"timestamp", "deployment_config:\n      since: 2024-01-02", "deployment_config:\n      since: \"2024-01-02\"\n"},
+		{"whole float", "deployment_config:\n      cpu: 3.0", "deployment_config:\n      cpu: 3\n"},
+		{"integer key", "deployment_config:\n      ports: {80: http}", "deployment_config:\n      ports:\n        \"80\": http\n"},
+		{"namespace", "", "  - id: payments/API\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "namespace: payments\nentities:\n  - id: API\n    category: BACKEND\n    description: d\n    status: healthy\n"
+			if tt.input != "" {
+				input += "    " + tt.input + "\n"
+			}
+			parsed, err := gorph.ParseInfrastructure([]byte(input + "connections: []\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out := roundTrip(t, parsed); !strings.Contains(string(out), tt.want) {
+				t.Errorf("output lacks %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func roundTrip(t *testing.T, infra *gorph.Infrastructure) []byte {
+	t.Helper()
+	msg, err := InfrastructureToProto(infra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := gorph.MarshalInfrastructure(InfrastructureFromProto(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// TestRoundTripDropsNamespaceAndInclude pins the fields messages do not
+// carry: the namespace survives only in the qualified IDs, and includes
+// are neither followed nor kept.
+func TestRoundTripDropsNamespaceAndInclude(t *testing.T) {
+	parsed, err := gorph.ParseInfrastructure([]byte(`namespace: payments
+include: [teams/*.yml]
+entities:
+  - id: API
+    category: BACKEND
+    description: d
+    status: healthy
+connections: []
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(roundTrip(t, parsed))
+	for _, key := range []string{"namespace:", "include:"} {
+		if strings.Contains(out, key) {
+			t.Errorf("output keeps %s:\n%s", key, out)
+		}
+	}
+	if !strings.Contains(out, "id: payments/API") {
+		t.Errorf("output lacks the qualified ID:\n%s", out)
+	}
+}
@@ -0,0 +1,162 @@
+package protoconv
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// maxExactInt is the largest integer a Struct number, a float64, holds
+// exactly.
+const maxExactInt = 1 << 53
+
+// DeploymentConfigToProto converts a deployment config decoded from YAML to
+// a Struct. Besides JSON values it accepts what YAML decoders produce:
+// integers of any type, timestamps, which become strings in their original
+// layout, and maps with non-string keys, which are formatted as strings.
+// Integers beyond ±2^53 and non-finite numbers cannot be stored exactly and
+// are reported instead of being rounded.
+func DeploymentConfigToProto(config map[string]interface{}) (*structpb.Struct, error) {
+	fields := make(map[string]*structpb.Value, len(config))
+	for key, v := range config {
+		value, err := toValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		fields[key] = value
+	}
+	return &structpb.Struct{Fields: fields}, nil
+}
+
+func toValue(v interface{}) (*structpb.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return structpb.NewNullValue(), nil
+	case bool:
+		return structpb.NewBoolValue(v), nil
+	case string:
+		return structpb.NewStringValue(v), nil
+	case int:
+		return intValue(int64(v))
+	case int8:
+		return intValue(int64(v))
+	case int16:
+		return intValue(int64(v))
+	case int32:
+		return intValue(int64(v))
+	case int64:
+		return intValue(v)
+	case uint:
+		return uintValue(uint64(v))
+	case uint8:
+		return uintValue(uint64(v))
+	case uint16:
+		return uintValue(uint64(v))
+	case uint32:
+		return uintValue(uint64(v))
+	case uint64:
+		return uintValue(v)
+	case float32:
+		return floatValue(float64(v))
+	case float64:
+		return floatValue(v)
+	case time.Time:
+		return structpb.NewStringValue(formatTime(v)), nil
+	case map[string]interface{}:
+		s, err := DeploymentConfigToProto(v)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewStructValue(s), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		list := &structpb.ListValue{Values: make([]*structpb.Value, rv.Len())}
+		for i := range list.Values {
+			value, err := toValue(rv.Index(i).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			list.Values[i] = value
+		}
+		return structpb.NewListValue(list), nil
+	case reflect.Map:
+		s := &structpb.Struct{Fields: make(map[string]*structpb.Value, rv.Len())}
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			value, err := toValue(iter.Value().Interface())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			s.Fields[key] = value
+		}
+		return structpb.NewStructValue(s), nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %T", v)
+}
+
+func intValue(n int64) (*structpb.Value, error) {
+	if n > maxExactInt || n < -maxExactInt {
+		return nil, fmt.Errorf("integer %d is too large to store exactly", n)
+	}
+	return structpb.NewNumberValue(float64(n)), nil
+}
+
+func uintValue(n uint64) (*structpb.Value, error) {
+	if n > maxExactInt {
+		return nil, fmt.Errorf("integer %d is too large to store exactly", n)
+	}
+	return structpb.NewNumberValue(float64(n)), nil
+}
+
+func floatValue(f float64) (*structpb.Value, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("number %v cannot be stored", f)
+	}
+	return structpb.NewNumberValue(f), nil
+}
+
+// formatTime formats a timestamp as YAML wrote it: a date alone, or an
+// RFC 3339 date and time.
+func formatTime(t time.Time) string {
+	if t.Location() == time.UTC && t.Equal(t.Truncate(24*time.Hour)) {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// DeploymentConfigFromProto converts a Struct back to the YAML model.
+// Whole numbers become ints, so that "replicas: 3" stays an integer.
+func DeploymentConfigFromProto(s *structpb.Struct) map[string]interface{} {
+	config := make(map[string]interface{}, len(s.GetFields()))
+	for key, value := range s.GetFields() {
+		config[key] = fromValue(value)
+	}
+	return config
+}
+
+func fromValue(v *structpb.Value) interface{} {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		n := kind.NumberValue
+		if n == math.Trunc(n) && math.Abs(n) <= maxExactInt {
+			return int(n)
+		}
+		return n
+	case *structpb.Value_ListValue:
+		items := make([]interface{}, len(kind.ListValue.GetValues()))
+		for i, item := range kind.ListValue.GetValues() {
+			items[i] = fromValue(item)
+		}
+		return items
+	case *structpb.Value_StructValue:
+		return DeploymentConfigFromProto(kind.StructValue)
+	}
+	return v.AsInterface()
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/auth"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// With authorization enforced, every call needs an identity set by the
+// auth interceptors. Any caller may read and create infrastructures; the
+// creator becomes the admin of an infrastructure created without admins.
+// Changes are checked one entity and connection at a time:
+//
+//   - an entity may be created, changed or deleted by its owner team, and
+//     given a new owner only by a member of both teams;
+//   - a connection belongs to the owner of the entity it starts from, but
+//     is also removed along with the entity it leads to;
+//   - name, description and admins may only be changed by admins, who
+//     may also change anything else and delete the infrastructure.
+//
+// Identities with Admin set are admins of every infrastructure.
+
+// RequireAuthorization makes the server reject calls without an identity
+// and enforce the ownership rules above.
+func (s *Server) RequireAuthorization() {
+	s.authorization = true
+}
+
+// caller returns the identity of the caller, or nil if authorization is
+// not enforced.
+func (s *Server) caller(ctx context.Context) (*auth.Identity, error) {
+	if !s.authorization {
+		return nil, nil
+	}
+	id, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "credentials required")
+	}
+	return id, nil
+}
+
+func isAdmin(id *auth.Identity, infra *pb.Infrastructure) bool {
+	if id.Admin {
+		return true
+	}
+	for _, admin := range infra.Admins {
+		if id.Member(admin) {
+			return true
+		}
+	}
+	return false
+}
+
+// requireAdmin fails unless id administers infra. A nil identity is
+// allowed everything.
+func requireAdmin(id *auth.Identity, infra *pb.Infrastructure, action string) error {
+	if id == nil || isAdmin(id, infra) {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "%s may not %s: only admins of infrastructure %q may", id.Subject, action, infra.Id)
+}
+
+// authorizeChange checks that id may make every change between two
+// versions of an infrastructure. Admin rights are taken from before, so
+// callers cannot grant themselves access.
+func authorizeChange(id *auth.Identity, before, after *pb.Infrastructure) error {
+	if id == nil || isAdmin(id, before) {
+		return nil
+	}
+	for _, event := range changeEvents(before, after) {
+		var err error
+		switch event.Type {
+		case pb.ChangeType_CHANGE_TYPE_INFRASTRUCTURE_UPDATED:
+			err = requireAdmin(id, before, "change the name, description or admins")
+		case pb.ChangeType_CHANGE_TYPE_ENTITY_CREATED, pb.ChangeType_CHANGE_TYPE_ENTITY_UPDATED, pb.ChangeType_CHANGE_TYPE_ENTITY_DELETED:
+			err = authorizeEntity(id, event.OldEntity)
+			if err == nil {
+				err = authorizeEntity(id, event.NewEntity)
+			}
+		case pb.ChangeType_CHANGE_TYPE_CONNECTION_DELETED:
+			// Removed with the entity it leads to, whose deletion is
+			// checked on its own
+			if findEntity(after, event.OldConnection.To) < 0 {
+				continue
+			}
+			err = authorizeConnection(id, before, event.OldConnection)
+		default:
+			err = authorizeConnection(id, before, event.OldConnection)
+			if err == nil {
+				err = authorizeConnection(id, after, event.NewConnection)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func authorizeEntity(id *auth.Identity, entity *pb.Entity) error {
+	if entity == nil || id.Member(entity.Owner) {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "%s may not modify entity %q, which %s", id.Subject, entity.Id, ownedBy(entity))
+}
+
+// authorizeConnection checks that id owns the entity conn starts from in
+// infra.
+func authorizeConnection(id *auth.Identity, infra *pb.Infrastructure, conn *pb.Connection) error {
+	if conn == nil {
+		return nil
+	}
+	if i := findEntity(infra, conn.From); i >= 0 {
+		if from := infra.Entities[i]; !id.Member(from.Owner) {
+			return status.Errorf(codes.PermissionDenied, "%s may not modify connection %s -> %s: entity %q %s", id.Subject, conn.From, conn.To, from.Id, ownedBy(from))
+		}
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "%s may not modify connection %s -> %s", id.Subject, conn.From, conn.To)
+}
+
+func ownedBy(entity *pb.Entity) string {
+	if entity.Owner == "" {
+		return "has no owner"
+	}
+	return "is owned by " + entity.Owner
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/auth"
+	"gorph/v2/pkg/gorph"
+	"gorph/v2/pkg/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthorization(t *testing.T) {
+	// newServer returns a server enforcing authorization over an
+	// infrastructure administered by ops, in which payments owns API and
+	// data owns DB
+	newServer := func(t *testing.T) *Server {
+		t.Helper()
+		s := New(gorph.DefaultStyle(), storage.NewMemory())
+		_, err := s.CreateInfrastructure(context.Background(), &pb.CreateInfrastructureRequest{Infrastructure: &pb.Infrastructure{
+			Id:     "shop",
+			Admins: []string{"ops"},
+			Entities: []*pb.Entity{
+				{Id: "API", Category: pb.Category_CATEGORY_BACKEND, Description: "d", Status: pb.Status_STATUS_HEALTHY, Owner: "payments"},
+				{Id: "DB", Category: pb.Category_CATEGORY_DATABASE, Description: "d", Status: pb.Status_STATUS_HEALTHY, Owner: "data"},
+				{Id: "Web", Category: pb.Category_CATEGORY_FRONTEND, Description: "d", Status: pb.Status_STATUS_HEALTHY},
+			},
+			Connections: []*pb.Connection{
+				{From: "API", To: "DB", Type: pb.ConnectionType_CONNECTION_TYPE_DB_CONNECTION},
+			},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.RequireAuthorization()
+		return s
+	}
+	var (
+		payments = &auth.Identity{Subject: "alice", Teams: []string{"payments"}}
+		data     = &auth.Identity{Subject: "bob", Teams: []string{"data"}}
+		both     = &auth.Identity{Subject: "carol", Teams: []string{"payments", "data"}}
+		ops      = &auth.Identity{Subject: "dave", Teams: []string{"ops"}}
+		root     = &auth.Identity{Subject: "root", Admin: true}
+	)
+	entity := func(id, owner string) *pb.Entity {
+		return &pb.Entity{Id: id, Category: pb.Category_CATEGORY_BACKEND, Description: "d", Status: pb.Status_STATUS_HEALTHY, Owner: owner}
+	}
+
+	tests := []struct {
+		name string
+		id   *auth.Identity // nil for an unauthenticated call
+		call func(s *Server, ctx context.Context) error
+		want codes.Code
+	}{
+		{"read without identity", nil, func(s *Server, ctx context.Context) error {
+			_, err := s.GetInfrastructure(ctx, &pb.GetInfrastructureRequest{InfrastructureId: "shop"})
+			return err
+		}, codes.Unauthenticated},
+		{"list without identity", nil, func(s *Server, ctx context.Context) error {
+			_, err := s.ListInfrastructures(ctx, &pb.ListInfrastructuresRequest{})
+			return err
+		}, codes.Unauthenticated},
+		{"update without identity", nil, func(s *Server, ctx context.Context) error {
+			_, err := s.DeleteEntity(ctx, &pb.DeleteEntityRequest{InfrastructureId: "shop", EntityId: "Web"})
+			return err
+		}, codes.Unauthenticated},
+		{"read", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.GetInfrastructure(ctx, &pb.GetInfrastructureRequest{InfrastructureId: "shop"})
+			return err
+		}, codes.OK},
+
+		{"rename as non-admin", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.UpdateInfrastructure(ctx, &pb.UpdateInfrastructureRequest{Infrastructure: &pb.Infrastructure{Id: "shop", Name: "Mine"}, UpdateMask: []string{"name"}})
+			return err
+		}, codes.PermissionDenied},
+		{"grant admin to self", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.UpdateInfrastructure(ctx, &pb.UpdateInfrastructureRequest{Infrastructure: &pb.Infrastructure{Id: "shop", Admins: []string{"ops", "payments"}}, UpdateMask: []string{"admins"}})
+			return err
+		}, codes.PermissionDenied},
+		{"rename as admin", ops, func(s *Server, ctx context.Context) error {
+			_, err := s.UpdateInfrastructure(ctx, &pb.UpdateInfrastructureRequest{Infrastructure: &pb.Infrastructure{Id: "shop", Name: "Shop"}, UpdateMask: []string{"name"}})
+			return err
+		}, codes.OK},
+		{"delete infrastructure as non-admin", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.DeleteInfrastructure(ctx, &pb.DeleteInfrastructureRequest{InfrastructureId: "shop"})
+			return err
+		}, codes.PermissionDenied},
+		{"delete infrastructure as global admin", root, func(s *Server, ctx context.Context) error {
+			_, err := s.DeleteInfrastructure(ctx, &pb.DeleteInfrastructureRequest{InfrastructureId: "shop"})
+			return err
+		}, codes.OK},
+
+		{"update own entity", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.UpdateEntity(ctx, &pb.UpdateEntityRequest{InfrastructureId: "shop", Entity: &pb.Entity{Id: "API", Description: "new"}, UpdateMask: []string{"description"}})
+			return err
+		}, codes.OK},
+		{"update another team's entity", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.UpdateEntity(ctx, &pb.UpdateEntityRequest{InfrastructureId: "shop", Entity: &pb.Entity{Id: "DB", Description: "new"}, UpdateMask: []string{"description"}})
+			return err
+		}, codes.PermissionDenied},
+		{"update unowned entity", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.UpdateEntity(ctx, &pb.UpdateEntityRequest{InfrastructureId: "shop", Entity: &pb.Entity{Id: "Web", Description: "new"}, UpdateMask: []string{"description"}})
+			return err
+		}, codes.PermissionDenied},
+		{"claim unowned entity", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.UpdateEntity(ctx, &pb.UpdateEntityRequest{InfrastructureId: "shop", Entity: &pb.Entity{Id: "Web", Owner: "payments"}, UpdateMask: []string{"owner"}})
+			return err
+		}, codes.PermissionDenied},
+		{"give entity to another team", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.UpdateEntity(ctx, &pb.UpdateEntityRequest{InfrastructureId: "shop", Entity: &pb.Entity{Id: "API", Owner: "data"}, UpdateMask: []string{"owner"}})
+			return err
+		}, codes.PermissionDenied},
+		{"give entity to another team of the caller", both, func(s *Server, ctx context.Context) error {
+			_, err := s.UpdateEntity(ctx, &pb.UpdateEntityRequest{InfrastructureId: "shop", Entity: &pb.Entity{Id: "API", Owner: "data"}, UpdateMask: []string{"owner"}})
+			return err
+		}, codes.OK},
+		{"create entity for another team", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.CreateEntity(ctx, &pb.CreateEntityRequest{InfrastructureId: "shop", Entity: entity("Cache", "data")})
+			return err
+		}, codes.PermissionDenied},
+		{"create own entity", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.CreateEntity(ctx, &pb.CreateEntityRequest{InfrastructureId: "shop", Entity: entity("Cache", "payments")})
+			return err
+		}, codes.OK},
+		{"delete another team's entity", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.DeleteEntity(ctx, &pb.DeleteEntityRequest{InfrastructureId: "shop", EntityId: "DB"})
+			return err
+		}, codes.PermissionDenied},
+		{"delete own entity with connections to it", data, func(s *Server, ctx context.Context) error {
+			_, err := s.DeleteEntity(ctx, &pb.DeleteEntityRequest{InfrastructureId: "shop", EntityId: "DB"})
+			return err
+		}, codes.OK},
+
+		{"connect from own entity", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.CreateConnection(ctx, &pb.CreateConnectionRequest{InfrastructureId: "shop", Connection: &pb.Connection{From: "API", To: "Web", Type: pb.ConnectionType_CONNECTION_TYPE_HTTP_REQUEST}})
+			return err
+		}, codes.OK},
+		{"connect from another team's entity", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.CreateConnection(ctx, &pb.CreateConnectionRequest{InfrastructureId: "shop", Connection: &pb.Connection{From: "DB", To: "API", Type: pb.ConnectionType_CONNECTION_TYPE_HTTP_REQUEST}})
+			return err
+		}, codes.PermissionDenied},
+		{"connect from unowned entity", payments, func(s *Server, ctx context.Context) error {
+			_, err := s.CreateConnection(ctx, &pb.CreateConnectionRequest{InfrastructureId: "shop", Connection: &pb.Connection{From: "Web", To: "API", Type: pb.ConnectionType_CONNECTION_TYPE_HTTP_REQUEST}})
+			return err
+		}, codes.PermissionDenied},
+		{"delete connection from another team's entity", data, func(s *Server, ctx context.Context) error {
+			_, err := s.DeleteConnection(ctx, &pb.DeleteConnectionRequest{InfrastructureId: "shop", From: "API", To: "DB"})
+			return err
+		}, codes.PermissionDenied},
+		{"retype connection from another team's entity", data, func(s *Server, ctx context.Context) error {
+			_, err := s.UpdateConnection(ctx, &pb.UpdateConnectionRequest{InfrastructureId: "shop", Connection: &pb.Connection{From: "API", To: "DB", Type: pb.ConnectionType_CONNECTION_TYPE_API_CALL}})
+			return err
+		}, codes.PermissionDenied},
+		{"change anything as admin", ops, func(s *Server, ctx context.Context) error {
+			_, err := s.DeleteConnection(ctx, &pb.DeleteConnectionRequest{InfrastructureId: "shop", From: "API", To: "DB"})
+			return err
+		}, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(t)
+			ctx := context.Background()
+			if tt.id != nil {
+				ctx = auth.NewContext(ctx, tt.id)
+			}
+			if err := tt.call(s, ctx); status.Code(err) != tt.want {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizationCreate(t *testing.T) {
+	s := New(gorph.DefaultStyle(), storage.NewMemory())
+	s.RequireAuthorization()
+	infra := &pb.Infrastructure{Id: "shop"}
+	if _, err := s.CreateInfrastructure(context.Background(), &pb.CreateInfrastructureRequest{Infrastructure: infra}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("create without identity: err = %v, want Unauthenticated", err)
+	}
+
+	ctx := auth.NewContext(context.Background(), &auth.Identity{Subject: "alice", Teams: []string{"payments"}})
+	resp, err := s.CreateInfrastructure(ctx, &pb.CreateInfrastructureRequest{Infrastructure: infra})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if admins := resp.GetInfrastructure().GetAdmins(); len(admins) != 1 || admins[0] != "user:alice" {
+		t.Errorf("admins = %v, want the creator", admins)
+	}
+}
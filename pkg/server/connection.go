@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/protoconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Connections are identified by their from and to entity IDs and their
+// type, so several connections of different types can join the same
+// entities. Requests may leave the type out when only one connection joins
+// the entities.
+
+// connectionID identifies a connection by its endpoints and the YAML
+// spelling of its type, ignoring case.
+func connectionID(conn *pb.Connection) string {
+	return conn.From + "\x00" + conn.To + "\x00" + strings.ToLower(protoconv.ConnectionFromProto(conn).Type)
+}
+
+// matchType reports whether a connection has the given type. An unset enum
+// value or an empty name matches any type.
+func matchType(conn *pb.Connection, connType pb.ConnectionType, typeName string) bool {
+	return (connType == pb.ConnectionType_CONNECTION_TYPE_UNSPECIFIED || conn.Type == connType) &&
+		(typeName == "" || strings.EqualFold(protoconv.ConnectionFromProto(conn).Type, typeName))
+}
+
+// describeConnection names a connection in error messages.
+func describeConnection(from, to string, connType pb.ConnectionType, typeName string) string {
+	name := from + " -> " + to
+	if typeName == "" {
+		typeName = protoconv.ConnectionTypeFromProto(connType)
+	}
+	if typeName != "" {
+		name += " (" + typeName + ")"
+	}
+	return name
+}
+
+func (s *Server) CreateConnection(ctx context.Context, req *pb.CreateConnectionRequest) (*pb.CreateConnectionResponse, error) {
+	conn := req.GetConnection()
+	if conn == nil {
+		return nil, status.Error(codes.InvalidArgument, "connection is required")
+	}
+
+	infra, err := s.update(ctx, req.GetInfrastructureId(), req.GetExpectedVersion(), func(infra *pb.Infrastructure) error {
+		if findConnectionID(infra, connectionID(conn)) >= 0 {
+			return status.Errorf(codes.AlreadyExists, "connection %s already exists", describeConnection(conn.From, conn.To, conn.Type, conn.TypeName))
+		}
+		infra.Connections = append(infra.Connections, conn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateConnectionResponse{Connection: conn, Version: infra.Version}, nil
+}
+
+func (s *Server) GetConnection(ctx context.Context, req *pb.GetConnectionRequest) (*pb.GetConnectionResponse, error) {
+	infra, err := s.get(ctx, req.GetInfrastructureId())
+	if err != nil {
+		return nil, err
+	}
+	i, err := findConnection(infra, req.GetFrom(), req.GetTo(), req.GetType(), req.GetTypeName())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetConnectionResponse{Connection: infra.Connections[i]}, nil
+}
+
+// UpdateConnection updates the connection with the same endpoints and type,
+// or the only connection between the endpoints, whose type it may change.
+// Only the fields named in the update mask are changed; without a mask the
+// connection is replaced.
+func (s *Server) UpdateConnection(ctx context.Context, req *pb.UpdateConnectionRequest) (*pb.UpdateConnectionResponse, error) {
+	conn := req.GetConnection()
+	if conn == nil {
+		return nil, status.Error(codes.InvalidArgument, "connection is required")
+	}
+
+	var updated *pb.Connection
+	infra, err := s.update(ctx, req.GetInfrastructureId(), req.GetExpectedVersion(), func(infra *pb.Infrastructure) error {
+		i, err := findConnection(infra, conn.From, conn.To, conn.Type, conn.TypeName)
+		if status.Code(err) == codes.NotFound {
+			i, err = findConnection(infra, conn.From, conn.To, pb.ConnectionType_CONNECTION_TYPE_UNSPECIFIED, "")
+		}
+		if err != nil {
+			return err
+		}
+		updated = infra.Connections[i]
+		if err := applyMask(updated, conn, req.GetUpdateMask(), "from", "to"); err != nil {
+			return err
+		}
+		// A type_name changed on its own replaces the type, which would
+		// otherwise take precedence over a name it does not spell
+		if updated.TypeName != "" && masked(conn, req.GetUpdateMask(), "type_name") && !masked(conn, req.GetUpdateMask(), "type") {
+			updated.Type, _ = protoconv.ConnectionTypeToProto(updated.TypeName)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateConnectionResponse{Connection: updated, Version: infra.Version}, nil
+}
+
+func (s *Server) DeleteConnection(ctx context.Context, req *pb.DeleteConnectionRequest) (*pb.DeleteConnectionResponse, error) {
+	infra, err := s.update(ctx, req.GetInfrastructureId(), req.GetExpectedVersion(), func(infra *pb.Infrastructure) error {
+		i, err := findConnection(infra, req.GetFrom(), req.GetTo(), req.GetType(), req.GetTypeName())
+		if err != nil {
+			return err
+		}
+		infra.Connections = append(infra.Connections[:i], infra.Connections[i+1:]...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteConnectionResponse{Version: infra.Version}, nil
+}
+
+// ListConnections returns the connections matching the request filters,
+// ordered by source and target entity ID and then type, one page at a
+// time. The type_name filter compares the YAML spelling of each
+// connection's type, so it also selects types without an enum value.
+func (s *Server) ListConnections(ctx context.Context, req *pb.ListConnectionsRequest) (*pb.ListConnectionsResponse, error) {
+	infra, err := s.get(ctx, req.GetInfrastructureId())
+	if err != nil {
+		return nil, err
+	}
+
+	var conns []*pb.Connection
+	var keys []string
+	for i, conn := range infra.Connections {
+		if (req.GetFrom() == "" || conn.From == req.GetFrom()) &&
+			(req.GetTo() == "" || conn.To == req.GetTo()) &&
+			matchType(conn, req.GetType(), req.GetTypeName()) {
+			conns = append(conns, conn)
+			keys = append(keys, connectionKey(conn, i))
+		}
+	}
+	sort.Sort(byKey{conns, keys})
+
+	start, end, next, err := s.page(req, req.GetPageSize(), req.GetPageToken(), keys)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListConnectionsResponse{Connections: conns[start:end], NextPageToken: next, TotalCount: int32(len(conns))}, nil
+}
+
+// connectionKey orders connections by connectionID. The connection's
+// position in the infrastructure makes the key unique, so pages do not
+// skip connections that a stored definition repeats.
+func connectionKey(conn *pb.Connection, i int) string {
+	return fmt.Sprintf("%s\x00%010d", connectionID(conn), i)
+}
+
+// byKey sorts connections along with their keys.
+type byKey struct {
+	conns []*pb.Connection
+	keys  []string
+}
+
+func (b byKey) Len() int           { return len(b.conns) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.conns[i], b.conns[j] = b.conns[j], b.conns[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/gorph"
+	"gorph/v2/pkg/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListConnectionsByType(t *testing.T) {
+	ctx := context.Background()
+	s := New(gorph.DefaultStyle(), storage.NewMemory())
+	_, err := s.CreateInfrastructure(ctx, &pb.CreateInfrastructureRequest{Infrastructure: &pb.Infrastructure{
+		Id: "shop",
+		Entities: []*pb.Entity{
+			{Id: "API", Category: pb.Category_CATEGORY_BACKEND, Description: "d", Status: pb.Status_STATUS_HEALTHY},
+			{Id: "DB", Category: pb.Category_CATEGORY_DATABASE, Description: "d", Status: pb.Status_STATUS_HEALTHY},
+			{Id: "Bus", Category: pb.Category_CATEGORY_BACKEND, Description: "d", Status: pb.Status_STATUS_HEALTHY},
+		},
+		Connections: []*pb.Connection{
+			{From: "API", To: "DB", Type: pb.ConnectionType_CONNECTION_TYPE_DB_CONNECTION},
+			{From: "API", To: "Bus", TypeName: "Publishes"},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		req  *pb.ListConnectionsRequest
+		want []string
+	}{
+		{"all", &pb.ListConnectionsRequest{}, []string{"Bus", "DB"}},
+		{"enum", &pb.ListConnectionsRequest{Type: pb.ConnectionType_CONNECTION_TYPE_DB_CONNECTION}, []string{"DB"}},
+		{"custom name", &pb.ListConnectionsRequest{TypeName: "publishes"}, []string{"Bus"}},
+		{"enum name", &pb.ListConnectionsRequest{TypeName: "DB_Connection"}, []string{"DB"}},
+		{"both", &pb.ListConnectionsRequest{Type: pb.ConnectionType_CONNECTION_TYPE_DB_CONNECTION, TypeName: "Publishes"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.InfrastructureId = "shop"
+			resp, err := s.ListConnections(ctx, tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, conn := range resp.GetConnections() {
+				got = append(got, conn.GetTo())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("targets = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("targets = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParallelConnections(t *testing.T) {
+	ctx := context.Background()
+	s := New(gorph.DefaultStyle(), storage.NewMemory())
+	_, err := s.CreateInfrastructure(ctx, &pb.CreateInfrastructureRequest{Infrastructure: &pb.Infrastructure{
+		Id: "shop",
+		Entities: []*pb.Entity{
+			{Id: "API", Category: pb.Category_CATEGORY_BACKEND, Description: "d", Status: pb.Status_STATUS_HEALTHY},
+			{Id: "Bus", Category: pb.Category_CATEGORY_BACKEND, Description: "d", Status: pb.Status_STATUS_HEALTHY},
+		},
+		Connections: []*pb.Connection{
+			{From: "API", To: "Bus", Type: pb.ConnectionType_CONNECTION_TYPE_API_CALL},
+			{From: "API", To: "Bus", TypeName: "Publishes"},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.GetConnection(ctx, &pb.GetConnectionRequest{InfrastructureId: "shop", From: "API", To: "Bus"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("get without type: err = %v, want InvalidArgument", err)
+	}
+	got, err := s.GetConnection(ctx, &pb.GetConnectionRequest{InfrastructureId: "shop", From: "API", To: "Bus", TypeName: "publishes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetConnection().GetTypeName() != "Publishes" {
+		t.Errorf("get by type_name = %v, want the Publishes connection", got.GetConnection())
+	}
+
+	_, err = s.UpdateConnection(ctx, &pb.UpdateConnectionRequest{
+		InfrastructureId: "shop",
+		Connection:       &pb.Connection{From: "API", To: "Bus", TypeName: "Publishes", Attributes: map[string]string{"topic": "orders"}},
+		UpdateMask:       []string{"attributes"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := s.GetConnection(ctx, &pb.GetConnectionRequest{InfrastructureId: "shop", From: "API", To: "Bus", Type: pb.ConnectionType_CONNECTION_TYPE_API_CALL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(call.GetConnection().GetAttributes()) != 0 {
+		t.Errorf("update of Publishes changed API_Call: %v", call.GetConnection())
+	}
+
+	_, err = s.CreateConnection(ctx, &pb.CreateConnectionRequest{InfrastructureId: "shop", Connection: &pb.Connection{From: "API", To: "Bus", TypeName: "PUBLISHES"}})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("create duplicate: err = %v, want AlreadyExists", err)
+	}
+	_, err = s.CreateInfrastructure(ctx, &pb.CreateInfrastructureRequest{Infrastructure: &pb.Infrastructure{
+		Id: "copy",
+		Entities: []*pb.Entity{
+			{Id: "API", Category: pb.Category_CATEGORY_BACKEND, Description: "d", Status: pb.Status_STATUS_HEALTHY},
+			{Id: "Bus", Category: pb.Category_CATEGORY_BACKEND, Description: "d", Status: pb.Status_STATUS_HEALTHY},
+		},
+		Connections: []*pb.Connection{
+			{From: "API", To: "Bus", TypeName: "Publishes"},
+			{From: "API", To: "Bus", TypeName: "publishes"},
+		},
+	}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("create with duplicate connections: err = %v, want InvalidArgument", err)
+	}
+
+	if _, err := s.DeleteConnection(ctx, &pb.DeleteConnectionRequest{InfrastructureId: "shop", From: "API", To: "Bus", Type: pb.ConnectionType_CONNECTION_TYPE_API_CALL}); err != nil {
+		t.Fatal(err)
+	}
+	left, err := s.GetConnection(ctx, &pb.GetConnectionRequest{InfrastructureId: "shop", From: "API", To: "Bus"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if left.GetConnection().GetTypeName() != "Publishes" {
+		t.Errorf("remaining connection = %v, want Publishes", left.GetConnection())
+	}
+}
+
+func TestListConnectionsPagesRepeatedConnections(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemory()
+	// Stores such as Dir can hold connections that the API would reject
+	// as duplicates
+	err := store.Create(ctx, &pb.Infrastructure{
+		Id:      "shop",
+		Version: 1,
+		Connections: []*pb.Connection{
+			{From: "A", To: "B", Type: pb.ConnectionType_CONNECTION_TYPE_API_CALL, Attributes: map[string]string{"n": "1"}},
+			{From: "A", To: "C", Type: pb.ConnectionType_CONNECTION_TYPE_API_CALL, Attributes: map[string]string{"n": "2"}},
+			{From: "A", To: "B", Type: pb.ConnectionType_CONNECTION_TYPE_API_CALL, Attributes: map[string]string{"n": "3"}},
+			{From: "A", To: "B", Type: pb.ConnectionType_CONNECTION_TYPE_API_CALL, Attributes: map[string]string{"n": "4"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(gorph.DefaultStyle(), store)
+
+	var got []string
+	req := &pb.ListConnectionsRequest{InfrastructureId: "shop", PageSize: 2}
+	for pages := 0; ; pages++ {
+		if pages > 4 {
+			t.Fatal("too many pages")
+		}
+		resp, err := s.ListConnections(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, conn := range resp.GetConnections() {
+			got = append(got, conn.GetAttributes()["n"])
+		}
+		if resp.GetNextPageToken() == "" {
+			break
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+	want := []string{"1", "3", "4", "2"}
+	if len(got) != len(want) {
+		t.Fatalf("connections = %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("connections = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestUpdateTypeName(t *testing.T) {
+	ctx := context.Background()
+	s := New(gorph.DefaultStyle(), storage.NewMemory())
+	_, err := s.CreateInfrastructure(ctx, &pb.CreateInfrastructureRequest{Infrastructure: &pb.Infrastructure{
+		Id: "shop",
+		Entities: []*pb.Entity{
+			{Id: "API", Category: pb.Category_CATEGORY_BACKEND, Description: "d", Status: pb.Status_STATUS_HEALTHY},
+			{Id: "Bus", Category: pb.Category_CATEGORY_BACKEND, Description: "d", Status: pb.Status_STATUS_HEALTHY},
+		},
+		Connections: []*pb.Connection{{From: "API", To: "Bus", Type: pb.ConnectionType_CONNECTION_TYPE_API_CALL}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		mask []string
+		want string
+	}{
+		{"custom type", []string{"type_name"}, "Publishes"},
+		{"json name", []string{"typeName"}, "Streams"},
+		{"enum type", []string{"type_name"}, "DB_Connection"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.UpdateConnection(ctx, &pb.UpdateConnectionRequest{
+				InfrastructureId: "shop",
+				Connection:       &pb.Connection{From: "API", To: "Bus", TypeName: tt.want},
+				UpdateMask:       tt.mask,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			exported, err := s.ExportYAML(ctx, &pb.ExportYAMLRequest{InfrastructureId: "shop"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "type: " + tt.want + "\n"; !strings.Contains(exported.GetYamlContent(), want) {
+				t.Errorf("export lacks %q:\n%s", want, exported.GetYamlContent())
+			}
+		})
+	}
+
+	_, err = s.UpdateEntity(ctx, &pb.UpdateEntityRequest{
+		InfrastructureId: "shop",
+		Entity:           &pb.Entity{Id: "Bus", CategoryName: "Queue"},
+		UpdateMask:       []string{"category_name"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported, err := s.ExportYAML(ctx, &pb.ExportYAMLRequest{InfrastructureId: "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(exported.GetYamlContent(), "category: Queue\n") {
+		t.Errorf("export lacks the Queue category:\n%s", exported.GetYamlContent())
+	}
+}
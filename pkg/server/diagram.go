@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/gorph"
+	"gorph/v2/pkg/protoconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// diagramFormats maps API output formats to gorph.Render formats.
+var diagramFormats = map[pb.OutputFormat]string{
+	pb.OutputFormat_OUTPUT_FORMAT_UNSPECIFIED: gorph.FormatDOT,
+	pb.OutputFormat_OUTPUT_FORMAT_DOT:         gorph.FormatDOT,
+	pb.OutputFormat_OUTPUT_FORMAT_PNG:         gorph.FormatPNG,
+	pb.OutputFormat_OUTPUT_FORMAT_SVG:         gorph.FormatSVG,
+	pb.OutputFormat_OUTPUT_FORMAT_PDF:         gorph.FormatPDF,
+}
+
+// GenerateDiagram renders an infrastructure as DOT, or as PNG, SVG or PDF
+// with Graphviz. Without Graphviz, SVG is rendered by the native layout
+// engine instead. The format defaults to DOT. A style_config in the request
+// is applied on top of the server's style.
+func (s *Server) GenerateDiagram(ctx context.Context, req *pb.GenerateDiagramRequest) (*pb.GenerateDiagramResponse, error) {
+	format, ok := diagramFormats[req.GetFormat()]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported format %v", req.GetFormat())
+	}
+	style, err := s.requestStyle(req.GetStyleConfig())
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.get(ctx, req.GetInfrastructureId())
+	if err != nil {
+		return nil, err
+	}
+	infra := protoconv.InfrastructureFromProto(stored)
+
+	content, contentType, err := gorph.Render(ctx, infra, style, format, gorph.RenderOptions{Graphviz: s.graphviz})
+	if errors.Is(err, gorph.ErrGraphvizNotFound) && format == gorph.FormatSVG {
+		content, contentType, err = gorph.Render(ctx, infra, style, format, gorph.RenderOptions{Engine: gorph.EngineNative})
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, gorph.ErrGraphvizNotFound):
+		return nil, status.Errorf(codes.FailedPrecondition, "rendering %v: %v", req.GetFormat(), err)
+	case errors.Is(err, gorph.ErrOutputTooLarge):
+		return nil, status.Errorf(codes.ResourceExhausted, "rendering %v: %v", req.GetFormat(), err)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return nil, status.Errorf(status.FromContextError(err).Code(), "rendering %v: %v", req.GetFormat(), err)
+	default:
+		return nil, status.Errorf(codes.Internal, "rendering %v: %v", req.GetFormat(), err)
+	}
+
+	return &pb.GenerateDiagramResponse{
+		Content:     content,
+		ContentType: contentType,
+		SizeBytes:   int64(len(content)),
+	}, nil
+}
+
+// requestStyle applies a request's style override to the server's style.
+// The icon directory cannot be overridden, so requests cannot make Graphviz
+// read files elsewhere on the server.
+func (s *Server) requestStyle(override string) (*gorph.StyleConfig, error) {
+	if strings.TrimSpace(override) == "" {
+		return s.style, nil
+	}
+	style, err := gorph.OverrideStyleConfig(s.style, []byte(override))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "style_config: %v", err)
+	}
+	style.Node.IconDir = s.style.Node.IconDir
+	return style, nil
+}
+
+// ImportYAML parses a YAML definition and stores it as a new
+// infrastructure, or replaces the entities and connections of the given
+// one.
+func (s *Server) ImportYAML(ctx context.Context, req *pb.ImportYAMLRequest) (*pb.ImportYAMLResponse, error) {
+	parsed, err := gorph.ParseInfrastructure([]byte(req.GetYamlContent()))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errs := gorph.Validate(parsed); len(errs) > 0 {
+		return nil, invalidInfrastructure(validationErrors(parsed, errs))
+	}
+	imported, err := protoconv.InfrastructureToProto(parsed)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Import into an existing infrastructure, or create it unless a
+	// version of an existing one was expected
+	id := req.GetInfrastructureId()
+	expected, err := expectedVersion(ctx, req.GetExpectedVersion())
+	if err != nil {
+		return nil, err
+	}
+	if id != "" || expected != 0 {
+		infra, err := s.update(ctx, id, expected, func(infra *pb.Infrastructure) error {
+			if imported.Name != "" {
+				infra.Name = imported.Name
+			}
+			if imported.Description != "" {
+				infra.Description = imported.Description
+			}
+			if len(imported.Admins) > 0 {
+				infra.Admins = imported.Admins
+			}
+			infra.Entities = imported.Entities
+			infra.Connections = imported.Connections
+			return nil
+		})
+		if status.Code(err) != codes.NotFound || expected != 0 {
+			if err != nil {
+				return nil, err
+			}
+			return &pb.ImportYAMLResponse{Infrastructure: infra}, nil
+		}
+	}
+
+	imported.Id = id
+	if err := s.create(ctx, imported); err != nil {
+		return nil, err
+	}
+	return &pb.ImportYAMLResponse{Infrastructure: imported}, nil
+}
+
+// ExportYAML returns an infrastructure in the YAML format read by the CLI.
+// A name that only repeats the ID, as given to definitions imported
+// without one, is left out.
+func (s *Server) ExportYAML(ctx context.Context, req *pb.ExportYAMLRequest) (*pb.ExportYAMLResponse, error) {
+	stored, err := s.get(ctx, req.GetInfrastructureId())
+	if err != nil {
+		return nil, err
+	}
+	infra := protoconv.InfrastructureFromProto(stored)
+	if infra.Name == stored.GetId() {
+		infra.Name = ""
+	}
+
+	data, err := gorph.MarshalInfrastructure(infra)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.ExportYAMLResponse{YamlContent: string(data)}, nil
+}
+
+// ValidateInfrastructure checks a definition without storing it. Problems
+// are reported in the response rather than as an error status.
+func (s *Server) ValidateInfrastructure(ctx context.Context, req *pb.ValidateInfrastructureRequest) (*pb.ValidateInfrastructureResponse, error) {
+	if req.GetInfrastructure() == nil {
+		return nil, status.Error(codes.InvalidArgument, "infrastructure is required")
+	}
+	errs := validate(req.GetInfrastructure())
+	return &pb.ValidateInfrastructureResponse{Valid: len(errs) == 0, Errors: errs}, nil
+}
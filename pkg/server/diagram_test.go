@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/gorph"
+	"gorph/v2/pkg/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestImportExportYAML(t *testing.T) {
+	ctx := context.Background()
+	s := New(gorph.DefaultStyle(), storage.NewMemory())
+	data, err := os.ReadFile("../../templates/gorph-app.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := s.ImportYAML(ctx, &pb.ImportYAMLRequest{YamlContent: string(data)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported, err := s.ExportYAML(ctx, &pb.ExportYAMLRequest{InfrastructureId: imported.GetInfrastructure().GetId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := gorph.ParseInfrastructure(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := gorph.ParseInfrastructure([]byte(exported.GetYamlContent()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "" {
+		t.Errorf("name = %q, want none", got.Name)
+	}
+	if !reflect.DeepEqual(got.Entities, want.Entities) {
+		t.Errorf("entities = %+v, want %+v", got.Entities, want.Entities)
+	}
+	if !reflect.DeepEqual(got.Connections, want.Connections) {
+		t.Errorf("connections = %+v, want %+v", got.Connections, want.Connections)
+	}
+}
+
+func TestGenerateDiagramWithoutGraphviz(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	ctx := context.Background()
+	s := New(gorph.DefaultStyle(), storage.NewMemory())
+	data, err := os.ReadFile("../../templates/simple.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := s.ImportYAML(ctx, &pb.ImportYAMLRequest{YamlContent: string(data)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := imported.GetInfrastructure().GetId()
+
+	svg, err := s.GenerateDiagram(ctx, &pb.GenerateDiagramRequest{InfrastructureId: id, Format: pb.OutputFormat_OUTPUT_FORMAT_SVG})
+	if err != nil {
+		t.Fatalf("svg: %v", err)
+	}
+	if svg.GetContentType() != "image/svg+xml" || !strings.Contains(string(svg.GetContent()), "<svg") {
+		t.Errorf("svg = %s %q, want a native SVG", svg.GetContentType(), svg.GetContent())
+	}
+
+	for _, format := range []pb.OutputFormat{pb.OutputFormat_OUTPUT_FORMAT_PNG, pb.OutputFormat_OUTPUT_FORMAT_PDF} {
+		_, err := s.GenerateDiagram(ctx, &pb.GenerateDiagramRequest{InfrastructureId: id, Format: format})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("%v: err = %v, want FailedPrecondition", format, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/protoconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) CreateEntity(ctx context.Context, req *pb.CreateEntityRequest) (*pb.CreateEntityResponse, error) {
+	entity := req.GetEntity()
+	if entity == nil {
+		return nil, status.Error(codes.InvalidArgument, "entity is required")
+	}
+
+	infra, err := s.update(ctx, req.GetInfrastructureId(), req.GetExpectedVersion(), func(infra *pb.Infrastructure) error {
+		if findEntity(infra, entity.Id) >= 0 {
+			return status.Errorf(codes.AlreadyExists, "entity %q already exists", entity.Id)
+		}
+		infra.Entities = append(infra.Entities, entity)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateEntityResponse{Entity: entity, Version: infra.Version}, nil
+}
+
+func (s *Server) GetEntity(ctx context.Context, req *pb.GetEntityRequest) (*pb.GetEntityResponse, error) {
+	infra, err := s.get(ctx, req.GetInfrastructureId())
+	if err != nil {
+		return nil, err
+	}
+	i := findEntity(infra, req.GetEntityId())
+	if i < 0 {
+		return nil, status.Errorf(codes.NotFound, "entity %q not found", req.GetEntityId())
+	}
+	return &pb.GetEntityResponse{Entity: infra.Entities[i]}, nil
+}
+
+// UpdateEntity updates the entity with the same ID. Only the fields named
+// in the update mask are changed; without a mask the entity is replaced.
+func (s *Server) UpdateEntity(ctx context.Context, req *pb.UpdateEntityRequest) (*pb.UpdateEntityResponse, error) {
+	entity := req.GetEntity()
+	if entity == nil {
+		return nil, status.Error(codes.InvalidArgument, "entity is required")
+	}
+
+	var updated *pb.Entity
+	infra, err := s.update(ctx, req.GetInfrastructureId(), req.GetExpectedVersion(), func(infra *pb.Infrastructure) error {
+		i := findEntity(infra, entity.Id)
+		if i < 0 {
+			return status.Errorf(codes.NotFound, "entity %q not found", entity.Id)
+		}
+		updated = infra.Entities[i]
+		if err := applyMask(updated, entity, req.GetUpdateMask(), "id"); err != nil {
+			return err
+		}
+		// Names changed on their own replace their enum value, as in
+		// UpdateConnection
+		mask := req.GetUpdateMask()
+		if updated.CategoryName != "" && masked(entity, mask, "category_name") && !masked(entity, mask, "category") {
+			updated.Category, _ = protoconv.CategoryToProto(updated.CategoryName)
+		}
+		if updated.StatusName != "" && masked(entity, mask, "status_name") && !masked(entity, mask, "status") {
+			updated.Status, _ = protoconv.StatusToProto(updated.StatusName)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateEntityResponse{Entity: updated, Version: infra.Version}, nil
+}
+
+// DeleteEntity removes an entity together with its connections.
+func (s *Server) DeleteEntity(ctx context.Context, req *pb.DeleteEntityRequest) (*pb.DeleteEntityResponse, error) {
+	infra, err := s.update(ctx, req.GetInfrastructureId(), req.GetExpectedVersion(), func(infra *pb.Infrastructure) error {
+		i := findEntity(infra, req.GetEntityId())
+		if i < 0 {
+			return status.Errorf(codes.NotFound, "entity %q not found", req.GetEntityId())
+		}
+		infra.Entities = append(infra.Entities[:i], infra.Entities[i+1:]...)
+
+		conns := infra.Connections[:0]
+		for _, conn := range infra.Connections {
+			if conn.From != req.GetEntityId() && conn.To != req.GetEntityId() {
+				conns = append(conns, conn)
+			}
+		}
+		infra.Connections = conns
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteEntityResponse{Version: infra.Version}, nil
+}
+
+// ListEntities returns the entities matching the request filters, ordered
+// by ID, one page at a time.
+func (s *Server) ListEntities(ctx context.Context, req *pb.ListEntitiesRequest) (*pb.ListEntitiesResponse, error) {
+	infra, err := s.get(ctx, req.GetInfrastructureId())
+	if err != nil {
+		return nil, err
+	}
+
+	var entities []*pb.Entity
+	for _, entity := range infra.Entities {
+		if matchEntity(entity, req) {
+			entities = append(entities, entity)
+		}
+	}
+	sort.SliceStable(entities, func(i, j int) bool { return entities[i].Id < entities[j].Id })
+	keys := make([]string, len(entities))
+	for i, entity := range entities {
+		keys[i] = entity.Id
+	}
+
+	start, end, next, err := s.page(req, req.GetPageSize(), req.GetPageToken(), keys)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListEntitiesResponse{Entities: entities[start:end], NextPageToken: next, TotalCount: int32(len(entities))}, nil
+}
+
+// matchEntity reports whether an entity passes the filters of a list
+// request. Unset filters match everything; tags must all be present
+// unless match_any_tag is set.
+func matchEntity(entity *pb.Entity, req *pb.ListEntitiesRequest) bool {
+	if req.GetCategory() != pb.Category_CATEGORY_UNSPECIFIED && entity.Category != req.GetCategory() {
+		return false
+	}
+	if req.GetStatus() != pb.Status_STATUS_UNSPECIFIED && entity.Status != req.GetStatus() {
+		return false
+	}
+	if req.GetCategoryName() != "" || req.GetStatusName() != "" {
+		spelled := protoconv.EntityFromProto(entity)
+		if req.GetCategoryName() != "" && !strings.EqualFold(spelled.Category, req.GetCategoryName()) {
+			return false
+		}
+		if req.GetStatusName() != "" && !strings.EqualFold(spelled.Status, req.GetStatusName()) {
+			return false
+		}
+	}
+	if req.GetOwner() != "" && entity.Owner != req.GetOwner() {
+		return false
+	}
+	if len(req.GetTags()) == 0 {
+		return true
+	}
+
+	has := make(map[string]bool, len(entity.Tags))
+	for _, tag := range entity.Tags {
+		has[tag] = true
+	}
+	matched := 0
+	for _, tag := range req.GetTags() {
+		if has[tag] {
+			matched++
+		}
+	}
+	if req.GetMatchAnyTag() {
+		return matched > 0
+	}
+	return matched == len(req.GetTags())
+}
@@ -0,0 +1,282 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/gorph"
+	"gorph/v2/pkg/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// catalog creates an infrastructure "shop" holding the given entities.
+func catalog(t *testing.T, entities ...*pb.Entity) *Server {
+	t.Helper()
+	s := New(gorph.DefaultStyle(), storage.NewMemory())
+	_, err := s.CreateInfrastructure(context.Background(), &pb.CreateInfrastructureRequest{
+		Infrastructure: &pb.Infrastructure{Id: "shop", Entities: entities},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// listIDs lists the IDs of the entities matching req, page by page.
+func listIDs(t *testing.T, s *Server, req *pb.ListEntitiesRequest) []string {
+	t.Helper()
+	req.InfrastructureId = "shop"
+	var ids []string
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("too many pages")
+		}
+		resp, err := s.ListEntities(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entity := range resp.GetEntities() {
+			ids = append(ids, entity.GetId())
+		}
+		if resp.GetNextPageToken() == "" {
+			return ids
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+}
+
+func TestListEntitiesFilters(t *testing.T) {
+	entity := func(id string, category pb.Category, status pb.Status, owner string, tags ...string) *pb.Entity {
+		return &pb.Entity{Id: id, Category: category, Status: status, Owner: owner, Description: "d", Tags: tags}
+	}
+	s := catalog(t,
+		entity("Web", pb.Category_CATEGORY_FRONTEND, pb.Status_STATUS_HEALTHY, "web", "public", "eu"),
+		entity("API", pb.Category_CATEGORY_BACKEND, pb.Status_STATUS_HEALTHY, "payments", "public", "us"),
+		entity("DB", pb.Category_CATEGORY_DATABASE, pb.Status_STATUS_DEGRADED, "data", "eu"),
+		entity("Worker", pb.Category_CATEGORY_BACKEND, pb.Status_STATUS_DOWN, "payments"),
+	)
+
+	tests := []struct {
+		name string
+		req  *pb.ListEntitiesRequest
+		want []string
+	}{
+		{"all, by ID", &pb.ListEntitiesRequest{}, []string{"API", "DB", "Web", "Worker"}},
+		{"category", &pb.ListEntitiesRequest{Category: pb.Category_CATEGORY_BACKEND}, []string{"API", "Worker"}},
+		{"status", &pb.ListEntitiesRequest{Status: pb.Status_STATUS_HEALTHY}, []string{"API", "Web"}},
+		{"owner", &pb.ListEntitiesRequest{Owner: "payments"}, []string{"API", "Worker"}},
+		{"filters combine", &pb.ListEntitiesRequest{Category: pb.Category_CATEGORY_BACKEND, Status: pb.Status_STATUS_HEALTHY}, []string{"API"}},
+		{"one tag", &pb.ListEntitiesRequest{Tags: []string{"eu"}}, []string{"DB", "Web"}},
+		{"all tags", &pb.ListEntitiesRequest{Tags: []string{"public", "eu"}}, []string{"Web"}},
+		{"any tag", &pb.ListEntitiesRequest{Tags: []string{"public", "eu"}, MatchAnyTag: true}, []string{"API", "DB", "Web"}},
+		{"unknown tag", &pb.ListEntitiesRequest{Tags: []string{"public", "asia"}}, nil},
+		{"any unknown tag", &pb.ListEntitiesRequest{Tags: []string{"asia"}, MatchAnyTag: true}, nil},
+		{"any without tags", &pb.ListEntitiesRequest{MatchAnyTag: true}, []string{"API", "DB", "Web", "Worker"}},
+		{"tags are case-sensitive", &pb.ListEntitiesRequest{Tags: []string{"EU"}}, nil},
+		{"tags and owner", &pb.ListEntitiesRequest{Tags: []string{"public"}, Owner: "web"}, []string{"Web"}},
+		{"paged", &pb.ListEntitiesRequest{Tags: []string{"public", "eu"}, MatchAnyTag: true, PageSize: 1}, []string{"API", "DB", "Web"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := listIDs(t, s, tt.req); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entities = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListEntitiesByName(t *testing.T) {
+	s := catalog(t,
+		&pb.Entity{Id: "API", Category: pb.Category_CATEGORY_BACKEND, Status: pb.Status_STATUS_HEALTHY, Description: "d"},
+		&pb.Entity{Id: "Bus", CategoryName: "Queue", StatusName: "maintenance", Description: "d"},
+		&pb.Entity{Id: "Jobs", CategoryName: "queue", Status: pb.Status_STATUS_HEALTHY, Description: "d"},
+	)
+
+	tests := []struct {
+		name string
+		req  *pb.ListEntitiesRequest
+		want []string
+	}{
+		{"custom category", &pb.ListEntitiesRequest{CategoryName: "QUEUE"}, []string{"Bus", "Jobs"}},
+		{"enum category", &pb.ListEntitiesRequest{CategoryName: "backend"}, []string{"API"}},
+		{"custom status", &pb.ListEntitiesRequest{StatusName: "Maintenance"}, []string{"Bus"}},
+		{"enum status", &pb.ListEntitiesRequest{StatusName: "healthy"}, []string{"API", "Jobs"}},
+		{"both names", &pb.ListEntitiesRequest{CategoryName: "queue", StatusName: "healthy"}, []string{"Jobs"}},
+		{"name and enum", &pb.ListEntitiesRequest{CategoryName: "queue", Category: pb.Category_CATEGORY_BACKEND}, nil},
+		{"unknown name", &pb.ListEntitiesRequest{CategoryName: "Cache"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := listIDs(t, s, tt.req); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entities = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListEntitiesPages(t *testing.T) {
+	ctx := context.Background()
+	var entities []*pb.Entity
+	for _, id := range []string{"A", "C", "E", "G", "I"} {
+		entities = append(entities, &pb.Entity{Id: id, Category: pb.Category_CATEGORY_BACKEND, Status: pb.Status_STATUS_HEALTHY, Description: "d"})
+	}
+	s := catalog(t, entities...)
+
+	first, err := s.ListEntities(ctx, &pb.ListEntitiesRequest{InfrastructureId: "shop", PageSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(first.GetEntities()); got != 2 || first.GetTotalCount() != 5 || first.GetNextPageToken() == "" {
+		t.Fatalf("first page has %d entities of %d, token %q", got, first.GetTotalCount(), first.GetNextPageToken())
+	}
+
+	// Entities inserted before and after the page boundary, and removed
+	// from the next page, do not shift the pages that follow
+	for _, id := range []string{"B", "D"} {
+		if _, err := s.CreateEntity(ctx, &pb.CreateEntityRequest{InfrastructureId: "shop", Entity: &pb.Entity{Id: id, Category: pb.Category_CATEGORY_BACKEND, Status: pb.Status_STATUS_HEALTHY, Description: "d"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.DeleteEntity(ctx, &pb.DeleteEntityRequest{InfrastructureId: "shop", EntityId: "E"}); err != nil {
+		t.Fatal(err)
+	}
+	got := listIDs(t, s, &pb.ListEntitiesRequest{PageSize: 2, PageToken: first.GetNextPageToken()})
+	if want := []string{"D", "G", "I"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("later pages = %v, want %v", got, want)
+	}
+
+	// The page size may change between pages
+	got = listIDs(t, s, &pb.ListEntitiesRequest{PageSize: 5, PageToken: first.GetNextPageToken()})
+	if want := []string{"D", "G", "I"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("later pages = %v, want %v", got, want)
+	}
+}
+
+func TestPageTokens(t *testing.T) {
+	ctx := context.Background()
+	s := catalog(t,
+		&pb.Entity{Id: "A", Category: pb.Category_CATEGORY_BACKEND, Status: pb.Status_STATUS_HEALTHY, Description: "d", Tags: []string{"x"}},
+		&pb.Entity{Id: "B", Category: pb.Category_CATEGORY_BACKEND, Status: pb.Status_STATUS_HEALTHY, Description: "d", Tags: []string{"x"}},
+		&pb.Entity{Id: "C", Category: pb.Category_CATEGORY_BACKEND, Status: pb.Status_STATUS_HEALTHY, Description: "d", Tags: []string{"x"}},
+	)
+	req := &pb.ListEntitiesRequest{InfrastructureId: "shop", Tags: []string{"x"}, PageSize: 1}
+	resp, err := s.ListEntities(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := resp.GetNextPageToken()
+
+	// Change one character of the token at a time, in its high bits as
+	// the low bits of the last one are padding
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	for i := range token {
+		tampered := token[:i] + string(alphabet[(strings.IndexByte(alphabet, token[i])+32)%64]) + token[i+1:]
+		_, err := s.ListEntities(ctx, &pb.ListEntitiesRequest{InfrastructureId: "shop", Tags: []string{"x"}, PageSize: 1, PageToken: tampered})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("token altered at %d: err = %v, want InvalidArgument", i, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		call func(token string) error
+		msg  string
+	}{
+		{"garbage", func(string) error {
+			_, err := s.ListEntities(ctx, &pb.ListEntitiesRequest{InfrastructureId: "shop", PageToken: "not a token"})
+			return err
+		}, "invalid page_token"},
+		{"truncated", func(token string) error {
+			_, err := s.ListEntities(ctx, &pb.ListEntitiesRequest{InfrastructureId: "shop", Tags: []string{"x"}, PageToken: token[:len(token)/2]})
+			return err
+		}, "invalid page_token"},
+		{"other filters", func(token string) error {
+			_, err := s.ListEntities(ctx, &pb.ListEntitiesRequest{InfrastructureId: "shop", Tags: []string{"x"}, MatchAnyTag: true, PageToken: token})
+			return err
+		}, "different request"},
+		{"other infrastructure", func(token string) error {
+			_, err := s.ListEntities(ctx, &pb.ListEntitiesRequest{InfrastructureId: "other", Tags: []string{"x"}, PageToken: token})
+			return err
+		}, ""},
+		{"other RPC", func(token string) error {
+			_, err := s.ListConnections(ctx, &pb.ListConnectionsRequest{InfrastructureId: "shop", PageToken: token})
+			return err
+		}, "different request"},
+		{"other server", func(token string) error {
+			other := catalog(t, &pb.Entity{Id: "A", Category: pb.Category_CATEGORY_BACKEND, Status: pb.Status_STATUS_HEALTHY, Description: "d", Tags: []string{"x"}})
+			_, err := other.ListEntities(ctx, &pb.ListEntitiesRequest{InfrastructureId: "shop", Tags: []string{"x"}, PageToken: token})
+			return err
+		}, "invalid page_token"},
+		{"negative page size", func(string) error {
+			_, err := s.ListEntities(ctx, &pb.ListEntitiesRequest{InfrastructureId: "shop", PageSize: -1})
+			return err
+		}, "page_size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(token)
+			if code := status.Code(err); code != codes.InvalidArgument && !(tt.msg == "" && code == codes.NotFound) {
+				t.Fatalf("err = %v, want InvalidArgument", err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("err = %v, want one mentioning %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestListInfrastructuresPages(t *testing.T) {
+	ctx := context.Background()
+	s := New(gorph.DefaultStyle(), storage.NewMemory())
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("infra-%d", 4-i)
+		if _, err := s.CreateInfrastructure(ctx, &pb.CreateInfrastructureRequest{Infrastructure: &pb.Infrastructure{Id: id}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var ids []string
+	req := &pb.ListInfrastructuresRequest{PageSize: 2}
+	for pages := 1; ; pages++ {
+		resp, err := s.ListInfrastructures(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetTotalCount() != 5 {
+			t.Errorf("total_count = %d, want 5", resp.GetTotalCount())
+		}
+		for _, infra := range resp.GetInfrastructures() {
+			ids = append(ids, infra.GetId())
+		}
+		if resp.GetNextPageToken() == "" {
+			if pages != 3 {
+				t.Errorf("got %d pages, want 3", pages)
+			}
+			break
+		}
+		req.PageToken = resp.GetNextPageToken()
+
+		if pages == 1 {
+			// Removing a listed infrastructure does not shift the next page
+			if _, err := s.DeleteInfrastructure(ctx, &pb.DeleteInfrastructureRequest{InfrastructureId: "infra-0"}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.CreateInfrastructure(ctx, &pb.CreateInfrastructureRequest{Infrastructure: &pb.Infrastructure{Id: "infra-5"}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if want := []string{"infra-0", "infra-1", "infra-2", "infra-3", "infra-4", "infra-5"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("infrastructures = %v, want %v", ids, want)
+	}
+
+	if _, err := s.ListInfrastructures(ctx, &pb.ListInfrastructuresRequest{PageToken: "bogus"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("bogus token: err = %v, want InvalidArgument", err)
+	}
+}
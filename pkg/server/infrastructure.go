@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/auth"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreateInfrastructure stores a new infrastructure, assigning an ID when
+// none is given.
+func (s *Server) CreateInfrastructure(ctx context.Context, req *pb.CreateInfrastructureRequest) (*pb.CreateInfrastructureResponse, error) {
+	infra := req.GetInfrastructure()
+	if infra == nil {
+		return nil, status.Error(codes.InvalidArgument, "infrastructure is required")
+	}
+
+	if err := s.create(ctx, infra); err != nil {
+		return nil, err
+	}
+	return &pb.CreateInfrastructureResponse{Infrastructure: infra}, nil
+}
+
+// create validates and stores a new infrastructure at version 1, filling
+// in its ID and name when they are empty.
+func (s *Server) create(ctx context.Context, infra *pb.Infrastructure) error {
+	if infra.Id == "" {
+		infra.Id = newID()
+	} else if err := checkID(infra.Id); err != nil {
+		return err
+	}
+	if infra.Name == "" {
+		infra.Name = infra.Id
+	}
+	caller, err := s.caller(ctx)
+	if err != nil {
+		return err
+	}
+	if caller != nil && len(infra.Admins) == 0 {
+		infra.Admins = []string{auth.SubjectPrefix + caller.Subject}
+	}
+	if errs := validate(infra); len(errs) > 0 {
+		return invalidInfrastructure(errs)
+	}
+
+	infra.Version = 1
+	infra.CreatedAt = timestamppb.Now()
+	infra.UpdatedAt = infra.CreatedAt
+	if err := s.store.Create(ctx, infra); err != nil {
+		return storeError(err, infra.Id)
+	}
+	setETag(ctx, infra)
+	return nil
+}
+
+func (s *Server) GetInfrastructure(ctx context.Context, req *pb.GetInfrastructureRequest) (*pb.GetInfrastructureResponse, error) {
+	infra, err := s.get(ctx, req.GetInfrastructureId())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetInfrastructureResponse{Infrastructure: infra}, nil
+}
+
+// UpdateInfrastructure updates the fields of an existing infrastructure
+// named in the update mask, or its name, description, admins, entities and
+// connections when there is no mask. A version set in the infrastructure
+// is checked like expected_version, so a message read with
+// GetInfrastructure can be modified and sent back safely.
+func (s *Server) UpdateInfrastructure(ctx context.Context, req *pb.UpdateInfrastructureRequest) (*pb.UpdateInfrastructureResponse, error) {
+	in := req.GetInfrastructure()
+	if in == nil {
+		return nil, status.Error(codes.InvalidArgument, "infrastructure is required")
+	}
+
+	expected := req.GetExpectedVersion()
+	if expected == 0 {
+		expected = in.GetVersion()
+	}
+	infra, err := s.update(ctx, in.GetId(), expected, func(infra *pb.Infrastructure) error {
+		if err := applyMask(infra, in, req.GetUpdateMask(), "id", "version", "created_at", "updated_at"); err != nil {
+			return err
+		}
+		if infra.Name == "" {
+			infra.Name = infra.Id
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateInfrastructureResponse{Infrastructure: infra}, nil
+}
+
+func (s *Server) DeleteInfrastructure(ctx context.Context, req *pb.DeleteInfrastructureRequest) (*pb.DeleteInfrastructureResponse, error) {
+	id := req.GetInfrastructureId()
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "infrastructure_id is required")
+	}
+	expected, err := expectedVersion(ctx, req.GetExpectedVersion())
+	if err != nil {
+		return nil, err
+	}
+	caller, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	var deleted *pb.Infrastructure
+	err = s.store.Delete(ctx, id, func(infra *pb.Infrastructure) error {
+		deleted = infra
+		if err := requireAdmin(caller, infra, "delete it"); err != nil {
+			return err
+		}
+		return checkVersion(infra, expected)
+	})
+	if err != nil {
+		return nil, storeError(err, id)
+	}
+	s.hub.publish(id, []*pb.ChangeEvent{{
+		Type:             pb.ChangeType_CHANGE_TYPE_INFRASTRUCTURE_DELETED,
+		InfrastructureId: id,
+		Version:          deleted.Version + 1,
+		Time:             timestamppb.Now(),
+	}})
+	return &pb.DeleteInfrastructureResponse{}, nil
+}
+
+// ListInfrastructures returns the stored infrastructures ordered by ID,
+// one page at a time.
+func (s *Server) ListInfrastructures(ctx context.Context, req *pb.ListInfrastructuresRequest) (*pb.ListInfrastructuresResponse, error) {
+	if _, err := s.caller(ctx); err != nil {
+		return nil, err
+	}
+	infras, err := s.store.List(ctx)
+	if err != nil {
+		return nil, storeError(err, "")
+	}
+	keys := make([]string, len(infras))
+	for i, infra := range infras {
+		keys[i] = infra.Id
+	}
+
+	start, end, next, err := s.page(req, req.GetPageSize(), req.GetPageToken(), keys)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListInfrastructuresResponse{Infrastructures: infras[start:end], NextPageToken: next, TotalCount: int32(len(infras))}, nil
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// applyMask copies the fields named by an update mask from src to dst.
+//
+// Paths are dot-separated field names. After a map field or a
+// google.protobuf.Struct, such as attributes or deployment_config, the next
+// segment selects a key, so "attributes.region" and
+// "deployment_config.resources.cpu" update a single entry. A field or key
+// that is unset in src is cleared in dst. An empty mask, or "*", updates
+// every field.
+//
+// Fields listed in fixed identify or describe the stored message and
+// cannot be updated; they are skipped by a full update and rejected when
+// named explicitly.
+func applyMask(dst, src proto.Message, mask []string, fixed ...string) error {
+	src = proto.Clone(src)
+	d, s := dst.ProtoReflect(), src.ProtoReflect()
+
+	if len(mask) == 0 || (len(mask) == 1 && mask[0] == "*") {
+		fields := d.Descriptor().Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			if !isFixed(string(fd.Name()), fixed) {
+				copyField(d, s, fd)
+			}
+		}
+		return nil
+	}
+
+	for _, path := range mask {
+		segments := strings.Split(path, ".")
+		if isFixed(segments[0], fixed) {
+			return status.Errorf(codes.InvalidArgument, "update_mask: field %q cannot be updated", segments[0])
+		}
+		if err := applyPath(d, s, segments, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// masked reports whether an update mask names a field of msg explicitly,
+// by its proto or JSON name.
+func masked(msg proto.Message, mask []string, name string) bool {
+	fd := msg.ProtoReflect().Descriptor().Fields().ByName(protoreflect.Name(name))
+	for _, path := range mask {
+		if path == name || (fd != nil && path == fd.JSONName()) {
+			return true
+		}
+	}
+	return false
+}
+
+func isFixed(name string, fixed []string) bool {
+	for _, f := range fixed {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
+
+func copyField(dst, src protoreflect.Message, fd protoreflect.FieldDescriptor) {
+	if src.Has(fd) {
+		dst.Set(fd, src.Get(fd))
+	} else {
+		dst.Clear(fd)
+	}
+}
+
+func applyPath(dst, src protoreflect.Message, segments []string, path string) error {
+	fields := dst.Descriptor().Fields()
+	fd := fields.ByName(protoreflect.Name(segments[0]))
+	if fd == nil {
+		fd = fields.ByJSONName(segments[0])
+	}
+	if fd == nil {
+		return status.Errorf(codes.InvalidArgument, "update_mask: unknown field %q in %q", segments[0], path)
+	}
+	rest := segments[1:]
+
+	switch {
+	case len(rest) == 0:
+		copyField(dst, src, fd)
+		return nil
+	case fd.IsMap() && len(rest) == 1 && fd.MapKey().Kind() == protoreflect.StringKind:
+		key := protoreflect.ValueOfString(rest[0]).MapKey()
+		if from := src.Get(fd).Map(); from.Has(key) {
+			dst.Mutable(fd).Map().Set(key, from.Get(key))
+		} else if dst.Has(fd) {
+			dst.Mutable(fd).Map().Clear(key)
+		}
+		return nil
+	case fd.Message() != nil && !fd.IsList() && !fd.IsMap():
+		if fd.Message().FullName() == "google.protobuf.Struct" {
+			from, _ := src.Get(fd).Message().Interface().(*structpb.Struct)
+			if from == nil && !dst.Has(fd) {
+				return nil
+			}
+			to := dst.Mutable(fd).Message().Interface().(*structpb.Struct)
+			applyStructPath(to, from, rest)
+			return nil
+		}
+		return applyPath(dst.Mutable(fd).Message(), src.Get(fd).Message(), rest, path)
+	default:
+		return status.Errorf(codes.InvalidArgument, "update_mask: %q does not name a field", path)
+	}
+}
+
+// applyStructPath updates one key of a Struct, creating the intermediate
+// Structs of a nested path as needed.
+func applyStructPath(dst, src *structpb.Struct, keys []string) {
+	key := keys[0]
+	value, ok := src.GetFields()[key]
+	if len(keys) == 1 {
+		if ok {
+			if dst.Fields == nil {
+				dst.Fields = make(map[string]*structpb.Value)
+			}
+			dst.Fields[key] = value
+		} else {
+			delete(dst.Fields, key)
+		}
+		return
+	}
+
+	from := value.GetStructValue()
+	to := dst.GetFields()[key].GetStructValue()
+	if to == nil {
+		if from == nil {
+			return
+		}
+		to = &structpb.Struct{}
+		if dst.Fields == nil {
+			dst.Fields = make(map[string]*structpb.Value)
+		}
+		dst.Fields[key] = structpb.NewStructValue(to)
+	}
+	applyStructPath(to, from, keys[1:])
+}
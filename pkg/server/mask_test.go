@@ -0,0 +1,152 @@
+package server
+
+import (
+	"testing"
+
+	pb "gorph/v2/api/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestApplyMask(t *testing.T) {
+	config := func(fields map[string]any) *structpb.Struct {
+		s, err := structpb.NewStruct(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	stored := func() *pb.Entity {
+		return &pb.Entity{
+			Id:          "API",
+			Category:    pb.Category_CATEGORY_BACKEND,
+			Description: "Shop API",
+			Status:      pb.Status_STATUS_HEALTHY,
+			Owner:       "payments",
+			Tags:        []string{"public"},
+			Attributes:  map[string]string{"region": "eu", "tier": "1"},
+			DeploymentConfig: config(map[string]any{
+				"replicas":  3,
+				"resources": map[string]any{"cpu": "1", "memory": "1Gi"},
+			}),
+		}
+	}
+	src := &pb.Entity{
+		Id:          "Other",
+		Category:    pb.Category_CATEGORY_DATABASE,
+		Description: "Orders API",
+		Attributes:  map[string]string{"region": "us", "zone": "b"},
+		DeploymentConfig: config(map[string]any{
+			"resources": map[string]any{"cpu": "2"},
+			"image":     "api:2",
+		}),
+	}
+
+	tests := []struct {
+		name string
+		mask []string
+		want func(e *pb.Entity) // changes expected in the stored entity
+		err  bool
+	}{
+		{"field", []string{"description"}, func(e *pb.Entity) {
+			e.Description = "Orders API"
+		}, false},
+		{"JSON name", []string{"deploymentConfig.image"}, func(e *pb.Entity) {
+			e.DeploymentConfig.Fields["image"] = structpb.NewStringValue("api:2")
+		}, false},
+		{"clear field", []string{"owner", "tags", "status"}, func(e *pb.Entity) {
+			e.Owner, e.Tags, e.Status = "", nil, pb.Status_STATUS_UNSPECIFIED
+		}, false},
+		{"clear message", []string{"deployment_config"}, func(e *pb.Entity) {
+			e.DeploymentConfig = src.DeploymentConfig
+		}, false},
+		{"map key", []string{"attributes.region", "attributes.zone"}, func(e *pb.Entity) {
+			e.Attributes = map[string]string{"region": "us", "tier": "1", "zone": "b"}
+		}, false},
+		{"clear map key", []string{"attributes.tier", "attributes.missing"}, func(e *pb.Entity) {
+			delete(e.Attributes, "tier")
+		}, false},
+		{"nested struct key", []string{"deployment_config.resources.cpu"}, func(e *pb.Entity) {
+			e.DeploymentConfig = config(map[string]any{
+				"replicas":  3,
+				"resources": map[string]any{"cpu": "2", "memory": "1Gi"},
+			})
+		}, false},
+		{"clear nested struct key", []string{"deployment_config.resources.memory", "deployment_config.replicas"}, func(e *pb.Entity) {
+			e.DeploymentConfig = config(map[string]any{
+				"resources": map[string]any{"cpu": "1"},
+			})
+		}, false},
+		{"new nested struct key", []string{"deployment_config.limits.cpu"}, func(e *pb.Entity) {}, false},
+		{"empty mask", nil, func(e *pb.Entity) {
+			e.Category, e.Description, e.Status, e.Owner, e.Tags = src.Category, src.Description, 0, "", nil
+			e.Attributes, e.DeploymentConfig = src.Attributes, src.DeploymentConfig
+		}, false},
+		{"wildcard", []string{"*"}, func(e *pb.Entity) {
+			e.Category, e.Description, e.Status, e.Owner, e.Tags = src.Category, src.Description, 0, "", nil
+			e.Attributes, e.DeploymentConfig = src.Attributes, src.DeploymentConfig
+		}, false},
+
+		{"unknown field", []string{"colour"}, nil, true},
+		{"unknown nested field", []string{"description.text"}, nil, true},
+		{"path into a list", []string{"tags.0"}, nil, true},
+		{"nested map key", []string{"attributes.region.code"}, nil, true},
+		{"fixed field", []string{"id"}, nil, true},
+		{"fixed field path", []string{"id.value"}, nil, true},
+		{"error after valid path", []string{"description", "colour"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stored()
+			err := applyMask(got, src, tt.mask, "id")
+			if tt.err {
+				if status.Code(err) != codes.InvalidArgument {
+					t.Errorf("err = %v, want InvalidArgument", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := stored()
+			tt.want(want)
+			if !proto.Equal(got, want) {
+				t.Errorf("entity = %v\nwant %v", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyMaskLeavesSourceAlone(t *testing.T) {
+	src := &pb.Entity{Attributes: map[string]string{"region": "us"}}
+	dst := &pb.Entity{}
+	if err := applyMask(dst, src, []string{"attributes"}); err != nil {
+		t.Fatal(err)
+	}
+	dst.Attributes["region"] = "eu"
+	if src.Attributes["region"] != "us" {
+		t.Error("changing the updated message changed the source")
+	}
+}
+
+func TestMasked(t *testing.T) {
+	conn := &pb.Connection{}
+	tests := []struct {
+		mask []string
+		want bool
+	}{
+		{[]string{"type_name"}, true},
+		{[]string{"description", "typeName"}, true},
+		{[]string{"type"}, false},
+		{[]string{"type_name.x"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := masked(conn, tt.mask, "type_name"); got != tt.want {
+			t.Errorf("masked(%q, type_name) = %v, want %v", tt.mask, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// List results are ordered by unique keys (entity ID, connection identity or
+// infrastructure ID) and a page token records the last key returned, so
+// items inserted or removed elsewhere never shift later pages.
+//
+// Tokens are signed with a key generated when the server starts. A token
+// is only accepted with the request it was issued for, apart from its page
+// size, and expires when the server restarts.
+
+// page returns the range of keys to return for a list request. Keys must
+// be sorted. The returned token is empty on the last page.
+func (s *Server) page(req proto.Message, pageSize int32, pageToken string, keys []string) (start, end int, next string, err error) {
+	switch {
+	case pageSize < 0:
+		return 0, 0, "", status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	if pageToken != "" {
+		after, err := s.readPageToken(req, pageToken)
+		if err != nil {
+			return 0, 0, "", err
+		}
+		start = sort.Search(len(keys), func(i int) bool { return keys[i] > after })
+	}
+	end = min(start+int(pageSize), len(keys))
+	if end < len(keys) {
+		next = s.pageToken(req, keys[end-1])
+	}
+	return start, end, next, nil
+}
+
+func (s *Server) pageToken(req proto.Message, after string) string {
+	payload := append(requestFingerprint(req), after...)
+	return base64.RawURLEncoding.EncodeToString(append(payload, s.sign(payload)...))
+}
+
+func (s *Server) readPageToken(req proto.Message, token string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) < 2*sha256.Size {
+		return "", status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+	payload, mac := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	if !hmac.Equal(mac, s.sign(payload)) {
+		return "", status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+	if !hmac.Equal(payload[:sha256.Size], requestFingerprint(req)) {
+		return "", status.Error(codes.InvalidArgument, "page_token was issued for a different request")
+	}
+	return string(payload[sha256.Size:]), nil
+}
+
+func (s *Server) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.tokenKey)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// requestFingerprint hashes a list request without its pagination fields.
+func requestFingerprint(req proto.Message) []byte {
+	req = proto.Clone(req)
+	fields := req.ProtoReflect().Descriptor().Fields()
+	for _, name := range []protoreflect.Name{"page_size", "page_token"} {
+		if fd := fields.ByName(name); fd != nil {
+			req.ProtoReflect().Clear(fd)
+		}
+	}
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
@@ -0,0 +1,289 @@
+// Package server implements the gorph.v1 GorphService gRPC API on top of
+// the shared model, validator and generators in pkg/gorph.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/gorph"
+	"gorph/v2/pkg/protoconv"
+	"gorph/v2/pkg/storage"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements GorphService on top of a storage.Store.
+type Server struct {
+	pb.UnimplementedGorphServiceServer
+
+	style    *gorph.StyleConfig
+	graphviz gorph.GraphvizLimits
+	store    storage.Store
+	tokenKey []byte // signs page tokens
+	hub      *hub
+
+	// authorization enforces the ownership rules in authz.go
+	authorization bool
+
+	// writeMu orders changes, so their events are published in version
+	// order
+	writeMu sync.Mutex
+}
+
+// New returns a server keeping infrastructures in store and rendering
+// diagrams with the given style.
+func New(style *gorph.StyleConfig, store storage.Store) *Server {
+	return &Server{style: style, graphviz: gorph.DefaultGraphvizLimits, store: store, tokenKey: randomBytes(32), hub: newHub()}
+}
+
+// SetGraphvizLimits changes the limits applied to Graphviz when rendering
+// PNG, SVG and PDF diagrams.
+func (s *Server) SetGraphvizLimits(limits gorph.GraphvizLimits) {
+	s.graphviz = limits
+}
+
+// Register adds the service to a gRPC server.
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	pb.RegisterGorphServiceServer(registrar, s)
+}
+
+// get returns a copy of the stored infrastructure with the given ID.
+func (s *Server) get(ctx context.Context, id string) (*pb.Infrastructure, error) {
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "infrastructure_id is required")
+	}
+	if _, err := s.caller(ctx); err != nil {
+		return nil, err
+	}
+	infra, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, storeError(err, id)
+	}
+	setETag(ctx, infra)
+	return infra, nil
+}
+
+// update applies change to the stored infrastructure and saves the result
+// under the next version if it is valid, so a rejected change leaves no
+// trace. The change is only applied to the expected version, unless
+// expected and the request's if-match metadata are both unset.
+func (s *Server) update(ctx context.Context, id string, expected int64, change func(infra *pb.Infrastructure) error) (*pb.Infrastructure, error) {
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "infrastructure_id is required")
+	}
+	expected, err := expectedVersion(ctx, expected)
+	if err != nil {
+		return nil, err
+	}
+	caller, err := s.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	var before *pb.Infrastructure
+	infra, err := s.store.Update(ctx, id, func(infra *pb.Infrastructure) error {
+		if err := checkVersion(infra, expected); err != nil {
+			return err
+		}
+		before = proto.Clone(infra).(*pb.Infrastructure)
+		if err := change(infra); err != nil {
+			return err
+		}
+		if errs := validate(infra); len(errs) > 0 {
+			return invalidInfrastructure(errs)
+		}
+		if err := authorizeChange(caller, before, infra); err != nil {
+			return err
+		}
+		infra.Version++
+		infra.UpdatedAt = timestamppb.Now()
+		return nil
+	})
+	if err != nil {
+		return nil, storeError(err, id)
+	}
+	s.hub.publish(id, changeEvents(before, infra))
+	setETag(ctx, infra)
+	return infra, nil
+}
+
+// storeError maps storage errors to gRPC status errors. Status errors
+// returned by update callbacks pass through unchanged.
+func storeError(err error, id string) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return status.Errorf(codes.NotFound, "infrastructure %q not found", id)
+	case errors.Is(err, storage.ErrAlreadyExists):
+		return status.Errorf(codes.AlreadyExists, "infrastructure %q already exists", id)
+	case errors.Is(err, storage.ErrReadOnly), errors.Is(err, storage.ErrUnsupported):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Errorf(codes.Internal, "storage: %v", err)
+	}
+}
+
+func findEntity(infra *pb.Infrastructure, id string) int {
+	for i, entity := range infra.Entities {
+		if entity.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// findConnection returns the index of the connection from one entity to
+// another. Its type, given as an enum value or by its YAML spelling as in
+// ListConnections, may be left out when only one connection joins the
+// entities.
+func findConnection(infra *pb.Infrastructure, from, to string, connType pb.ConnectionType, typeName string) (int, error) {
+	found, matches := -1, 0
+	for i, conn := range infra.Connections {
+		if conn.From == from && conn.To == to && matchType(conn, connType, typeName) {
+			found = i
+			matches++
+		}
+	}
+	name := describeConnection(from, to, connType, typeName)
+	switch matches {
+	case 0:
+		return -1, status.Errorf(codes.NotFound, "connection %s not found", name)
+	case 1:
+		return found, nil
+	default:
+		return -1, status.Errorf(codes.InvalidArgument, "%d connections match %s; set type or type_name to choose one", matches, name)
+	}
+}
+
+// findConnectionID returns the index of the connection with the given
+// connectionID, or -1.
+func findConnectionID(infra *pb.Infrastructure, id string) int {
+	for i, conn := range infra.Connections {
+		if connectionID(conn) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// validate runs the shared validator on an infrastructure message. An
+// infrastructure without entities is accepted so it can be built up one
+// entity at a time.
+func validate(infra *pb.Infrastructure) []*pb.ValidationError {
+	model := protoconv.InfrastructureFromProto(infra)
+	var errs gorph.ValidationErrors
+	for _, err := range gorph.Validate(model) {
+		if err.Field == "entities" && len(model.Entities) == 0 {
+			continue
+		}
+		errs = append(errs, err)
+	}
+	return append(validationErrors(model, errs), duplicateConnections(infra)...)
+}
+
+// duplicateConnections reports connections that repeat the endpoints and
+// type of an earlier one, since the API could not tell them apart.
+func duplicateConnections(infra *pb.Infrastructure) []*pb.ValidationError {
+	var errs []*pb.ValidationError
+	seen := make(map[string]int, len(infra.Connections))
+	for i, conn := range infra.Connections {
+		id := connectionID(conn)
+		if j, ok := seen[id]; ok {
+			errs = append(errs, &pb.ValidationError{
+				Field:   fmt.Sprintf("connections[%d]", i),
+				Message: fmt.Sprintf("connection %s duplicates connections[%d]; connections between the same entities need different types", describeConnection(conn.From, conn.To, conn.Type, conn.TypeName), j),
+			})
+			continue
+		}
+		seen[id] = i
+	}
+	return errs
+}
+
+// validationErrors converts validator results to API errors. Validator
+// paths such as "entities.3.id" become "entities[3].id".
+func validationErrors(infra *gorph.Infrastructure, errs gorph.ValidationErrors) []*pb.ValidationError {
+	var result []*pb.ValidationError
+	for _, err := range errs {
+		result = append(result, &pb.ValidationError{
+			Field:    fieldIndex.ReplaceAllString(err.Field, "[$1]"),
+			Message:  err.Error(),
+			EntityId: entityID(infra, err.Field),
+		})
+	}
+	return result
+}
+
+var fieldIndex = regexp.MustCompile(`\.(\d+)`)
+
+// entityID returns the ID of the entity a validator path points into.
+func entityID(infra *gorph.Infrastructure, path string) string {
+	parts := strings.Split(path, ".")
+	if len(parts) < 2 || parts[0] != "entities" {
+		return ""
+	}
+	i, err := strconv.Atoi(parts[1])
+	if err != nil || i < 0 || i >= len(infra.Entities) {
+		return ""
+	}
+	return infra.Entities[i].ID
+}
+
+// invalidInfrastructure reports validation errors as InvalidArgument with
+// one BadRequest field violation per error.
+func invalidInfrastructure(errs []*pb.ValidationError) error {
+	messages := make([]string, len(errs))
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Message
+		violations[i] = &errdetails.BadRequest_FieldViolation{Field: err.Field, Description: err.Message}
+	}
+
+	st := status.New(codes.InvalidArgument, "invalid infrastructure: "+strings.Join(messages, "; "))
+	if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// newID returns a random infrastructure ID.
+func newID() string {
+	return "infra-" + hex.EncodeToString(randomBytes(8))
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("reading random bytes: %v", err))
+	}
+	return b
+}
+
+// checkID rejects infrastructure IDs that are not safe to use in URLs and
+// file names.
+func checkID(id string) error {
+	if !gorph.IsValidName(id) {
+		return status.Errorf(codes.InvalidArgument, "infrastructure_id %q must start with a letter and contain only letters, numbers, underscores, and dashes", id)
+	}
+	return nil
+}
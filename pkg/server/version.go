@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	pb "gorph/v2/api/v1"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Every change to an infrastructure, including changes to its entities and
+// connections, increments its version. Mutating requests may name the
+// version they were based on, either in an expected_version field or as an
+// "if-match" metadata entry holding the ETag returned in the "etag" header,
+// and are rejected with FailedPrecondition if the infrastructure has moved
+// on since.
+
+// expectedVersion returns the version a request is conditioned on, or 0
+// for an unconditional request.
+func expectedVersion(ctx context.Context, field int64) (int64, error) {
+	if field != 0 {
+		return field, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("if-match")
+	if len(values) == 0 {
+		return 0, nil
+	}
+	version, err := parseETag(values[0])
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "if-match: %v", err)
+	}
+	return version, nil
+}
+
+// etag formats a version as an HTTP entity tag.
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parseETag reads an entity tag written by etag. "*" matches any version.
+func parseETag(tag string) (int64, error) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if tag == "*" {
+		return 0, nil
+	}
+	if unquoted, err := strconv.Unquote(tag); err == nil {
+		tag = unquoted
+	}
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid entity tag %q", tag)
+	}
+	return version, nil
+}
+
+// setETag reports the version of an infrastructure in the response header.
+// It does nothing when the server is called directly rather than over gRPC.
+func setETag(ctx context.Context, infra *pb.Infrastructure) {
+	grpc.SetHeader(ctx, metadata.Pairs("etag", etag(infra.GetVersion())))
+}
+
+// checkVersion rejects a change based on another version of infra.
+func checkVersion(infra *pb.Infrastructure, expected int64) error {
+	if expected == 0 || infra.Version == expected {
+		return nil
+	}
+	desc := fmt.Sprintf("infrastructure %q is at version %d, not %d", infra.Id, infra.Version, expected)
+	st := status.New(codes.FailedPrecondition, desc)
+	if detailed, err := st.WithDetails(&errdetails.PreconditionFailure{
+		Violations: []*errdetails.PreconditionFailure_Violation{{Type: "VERSION", Subject: infra.Id, Description: desc}},
+	}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/gorph"
+	"gorph/v2/pkg/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUpdateConflictsWithEditsByHand(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "shop.yml")
+	const shop = `entities:
+  - id: API
+    category: BACKEND
+    description: Shop API
+    status: healthy
+connections: []
+`
+	if err := os.WriteFile(file, []byte(shop), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := storage.OpenDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(gorph.DefaultStyle(), store)
+
+	edit := func(old, new string) {
+		t.Helper()
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(strings.Replace(string(data), old, new, 1)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	update := func(ctx context.Context, expected int64) error {
+		_, err := s.UpdateEntity(ctx, &pb.UpdateEntityRequest{
+			InfrastructureId: "shop",
+			Entity:           &pb.Entity{Id: "API", Owner: "team-shop"},
+			UpdateMask:       []string{"owner"},
+			ExpectedVersion:  expected,
+		})
+		return err
+	}
+
+	edit("healthy", "degraded")
+	seen, err := s.GetInfrastructure(ctx, &pb.GetInfrastructureRequest{InfrastructureId: "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	edit("degraded", "down")
+
+	// The second edit happened after the version seen was read
+	version := seen.GetInfrastructure().GetVersion()
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected int64
+	}{
+		{"expected_version", ctx, version},
+		{"if-match", metadata.NewIncomingContext(ctx, metadata.Pairs("if-match", etag(version))), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := update(tt.ctx, tt.expected); status.Code(err) != codes.FailedPrecondition {
+				t.Errorf("update based on version %d: err = %v, want FailedPrecondition", version, err)
+			}
+		})
+	}
+	if data, err := os.ReadFile(file); err != nil || strings.Contains(string(data), "team-shop") {
+		t.Errorf("shop.yml was changed by a conflicting update:\n%s", data)
+	}
+
+	current, err := s.GetInfrastructure(ctx, &pb.GetInfrastructureRequest{InfrastructureId: "shop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := update(ctx, current.GetInfrastructure().GetVersion()); err != nil {
+		t.Errorf("update based on the current version: %v", err)
+	}
+}
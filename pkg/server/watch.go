@@ -0,0 +1,286 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	pb "gorph/v2/api/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// watchHistory is how many events are kept per infrastructure for
+	// watchers resuming from an earlier version.
+	watchHistory = 4096
+	// watchBuffer is how many versions a watcher may fall behind before it
+	// is dropped and has to catch up from the history.
+	watchBuffer = 64
+)
+
+// WatchInfrastructure streams the changes made to an infrastructure. A
+// watcher starting at version 0, or at a version no longer in the history,
+// first receives a snapshot. The stream ends when the infrastructure is
+// deleted.
+//
+// Only changes made through this server are reported.
+func (s *Server) WatchInfrastructure(req *pb.WatchInfrastructureRequest, stream grpc.ServerStreamingServer[pb.ChangeEvent]) error {
+	id := req.GetInfrastructureId()
+	if id == "" {
+		return status.Error(codes.InvalidArgument, "infrastructure_id is required")
+	}
+	after := req.GetStartVersion()
+	if after < 0 {
+		return status.Error(codes.InvalidArgument, "start_version must not be negative")
+	}
+
+	ctx := stream.Context()
+	if _, err := s.caller(ctx); err != nil {
+		return err
+	}
+	for {
+		replay, sub, ok := s.hub.subscribe(id, after)
+		if !ok {
+			infra, err := s.get(ctx, id)
+			if err != nil {
+				s.hub.unsubscribe(id, sub)
+				return err
+			}
+			if after > infra.Version {
+				s.hub.unsubscribe(id, sub)
+				return status.Errorf(codes.InvalidArgument, "start_version %d is newer than version %d of infrastructure %q", after, infra.Version, id)
+			}
+			if after < infra.Version {
+				replay = [][]*pb.ChangeEvent{{{
+					Type:             pb.ChangeType_CHANGE_TYPE_SNAPSHOT,
+					InfrastructureId: id,
+					Version:          infra.Version,
+					Time:             infra.UpdatedAt,
+					Infrastructure:   infra,
+				}}}
+			}
+		}
+
+		done, err := sendEvents(stream, replay, &after)
+		if err == nil && !done {
+			done, err = follow(ctx, stream, sub, &after)
+		}
+		s.hub.unsubscribe(id, sub)
+		if err != nil || done {
+			return err
+		}
+		// The watcher fell behind; catch up from the last version sent
+	}
+}
+
+// follow sends live events until the subscription is dropped. It reports
+// whether the infrastructure was deleted.
+func follow(ctx context.Context, stream grpc.ServerStreamingServer[pb.ChangeEvent], sub *subscriber, after *int64) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return false, status.FromContextError(ctx.Err()).Err()
+		case batch, open := <-sub.events:
+			if !open {
+				return false, nil
+			}
+			if done, err := sendEvents(stream, [][]*pb.ChangeEvent{batch}, after); done || err != nil {
+				return done, err
+			}
+		}
+	}
+}
+
+// sendEvents sends the batches newer than *after, advancing it. It reports
+// whether the infrastructure was deleted.
+func sendEvents(stream grpc.ServerStreamingServer[pb.ChangeEvent], batches [][]*pb.ChangeEvent, after *int64) (bool, error) {
+	for _, batch := range batches {
+		if len(batch) == 0 || batch[0].Version <= *after {
+			continue
+		}
+		for _, event := range batch {
+			if err := stream.Send(event); err != nil {
+				return false, err
+			}
+			if event.Type == pb.ChangeType_CHANGE_TYPE_INFRASTRUCTURE_DELETED {
+				return true, nil
+			}
+		}
+		*after = batch[0].Version
+	}
+	return false, nil
+}
+
+// hub fans out change events to watchers. Publishing never blocks: a
+// watcher whose buffer is full is dropped, and catches up by resubscribing
+// from the last version it received.
+type hub struct {
+	mu     sync.Mutex
+	topics map[string]*topic
+}
+
+// topic holds the recent events and watchers of one infrastructure.
+type topic struct {
+	history [][]*pb.ChangeEvent // one batch per version, oldest first
+	size    int                 // events in history
+	since   int64               // history has every event after this version
+	subs    map[*subscriber]bool
+}
+
+type subscriber struct {
+	events chan []*pb.ChangeEvent
+}
+
+func newHub() *hub {
+	return &hub{topics: make(map[string]*topic)}
+}
+
+func (h *hub) topic(id string) *topic {
+	t, ok := h.topics[id]
+	if !ok {
+		t = &topic{since: -1, subs: make(map[*subscriber]bool)}
+		h.topics[id] = t
+	}
+	return t
+}
+
+// subscribe registers a watcher and returns the recorded events after the
+// given version. ok is false if the history does not reach back that far.
+func (h *hub) subscribe(id string, after int64) (replay [][]*pb.ChangeEvent, sub *subscriber, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topic(id)
+	sub = &subscriber{events: make(chan []*pb.ChangeEvent, watchBuffer)}
+	t.subs[sub] = true
+	if after == 0 || t.since < 0 || after < t.since || after > t.history[len(t.history)-1][0].Version {
+		return nil, sub, false
+	}
+	for _, batch := range t.history {
+		if batch[0].Version > after {
+			replay = append(replay, batch)
+		}
+	}
+	return replay, sub, true
+}
+
+func (h *hub) unsubscribe(id string, sub *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if t, ok := h.topics[id]; ok && t.subs[sub] {
+		delete(t.subs, sub)
+		close(sub.events)
+		if len(t.subs) == 0 && t.since < 0 {
+			delete(h.topics, id)
+		}
+	}
+}
+
+// publish records the events of one new version and passes them on.
+// Callers must publish the versions of an infrastructure in order.
+func (h *hub) publish(id string, events []*pb.ChangeEvent) {
+	if len(events) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topic(id)
+	if t.since < 0 {
+		t.since = events[0].Version - 1
+	}
+	t.history = append(t.history, events)
+	t.size += len(events)
+	for t.size > watchHistory && len(t.history) > 1 {
+		t.since = t.history[0][0].Version
+		t.size -= len(t.history[0])
+		t.history = t.history[1:]
+	}
+
+	for sub := range t.subs {
+		select {
+		case sub.events <- events:
+		default:
+			delete(t.subs, sub)
+			close(sub.events)
+		}
+	}
+
+	// Forget a deleted infrastructure, so one recreated with the same ID
+	// starts afresh
+	if events[len(events)-1].Type == pb.ChangeType_CHANGE_TYPE_INFRASTRUCTURE_DELETED {
+		delete(h.topics, id)
+	}
+}
+
+// changeEvents describes the difference between two versions of an
+// infrastructure.
+func changeEvents(before, after *pb.Infrastructure) []*pb.ChangeEvent {
+	var events []*pb.ChangeEvent
+	add := func(event *pb.ChangeEvent) {
+		event.InfrastructureId = after.Id
+		event.Version = after.Version
+		event.Time = after.UpdatedAt
+		events = append(events, event)
+	}
+
+	if before.Name != after.Name || before.Description != after.Description || !slices.Equal(before.Admins, after.Admins) {
+		add(&pb.ChangeEvent{
+			Type:           pb.ChangeType_CHANGE_TYPE_INFRASTRUCTURE_UPDATED,
+			Infrastructure: &pb.Infrastructure{Id: after.Id, Name: after.Name, Description: after.Description, Admins: after.Admins, Version: after.Version, CreatedAt: after.CreatedAt, UpdatedAt: after.UpdatedAt},
+		})
+	}
+
+	for _, old := range before.Entities {
+		if i := findEntity(after, old.Id); i < 0 {
+			add(&pb.ChangeEvent{Type: pb.ChangeType_CHANGE_TYPE_ENTITY_DELETED, OldEntity: old})
+		} else if !proto.Equal(old, after.Entities[i]) {
+			add(&pb.ChangeEvent{Type: pb.ChangeType_CHANGE_TYPE_ENTITY_UPDATED, OldEntity: old, NewEntity: after.Entities[i]})
+		}
+	}
+	for _, entity := range after.Entities {
+		if findEntity(before, entity.Id) < 0 {
+			add(&pb.ChangeEvent{Type: pb.ChangeType_CHANGE_TYPE_ENTITY_CREATED, NewEntity: entity})
+		}
+	}
+
+	// Connections are paired by connectionID. A connection whose type
+	// changed is then paired with a new one between the same entities.
+	paired := make([]bool, len(after.Connections))
+	pair := func(match func(conn *pb.Connection) bool) int {
+		for i, conn := range after.Connections {
+			if !paired[i] && match(conn) {
+				paired[i] = true
+				return i
+			}
+		}
+		return -1
+	}
+	var removed []*pb.Connection
+	for _, old := range before.Connections {
+		id := connectionID(old)
+		if i := pair(func(conn *pb.Connection) bool { return connectionID(conn) == id }); i < 0 {
+			removed = append(removed, old)
+		} else if !proto.Equal(old, after.Connections[i]) {
+			add(&pb.ChangeEvent{Type: pb.ChangeType_CHANGE_TYPE_CONNECTION_UPDATED, OldConnection: old, NewConnection: after.Connections[i]})
+		}
+	}
+	for _, old := range removed {
+		if i := pair(func(conn *pb.Connection) bool { return conn.From == old.From && conn.To == old.To }); i < 0 {
+			add(&pb.ChangeEvent{Type: pb.ChangeType_CHANGE_TYPE_CONNECTION_DELETED, OldConnection: old})
+		} else {
+			add(&pb.ChangeEvent{Type: pb.ChangeType_CHANGE_TYPE_CONNECTION_UPDATED, OldConnection: old, NewConnection: after.Connections[i]})
+		}
+	}
+	for i, conn := range after.Connections {
+		if !paired[i] {
+			add(&pb.ChangeEvent{Type: pb.ChangeType_CHANGE_TYPE_CONNECTION_CREATED, NewConnection: conn})
+		}
+	}
+	return events
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/gorph"
+	"gorph/v2/pkg/storage"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestChangeEventsParallelConnections(t *testing.T) {
+	call := &pb.Connection{From: "API", To: "Bus", Type: pb.ConnectionType_CONNECTION_TYPE_API_CALL}
+	publishes := &pb.Connection{From: "API", To: "Bus", TypeName: "Publishes"}
+	tagged := &pb.Connection{From: "API", To: "Bus", TypeName: "Publishes", Attributes: map[string]string{"topic": "orders"}}
+	db := &pb.Connection{From: "API", To: "DB", Type: pb.ConnectionType_CONNECTION_TYPE_API_CALL}
+	dbConn := &pb.Connection{From: "API", To: "DB", Type: pb.ConnectionType_CONNECTION_TYPE_DB_CONNECTION}
+
+	tests := []struct {
+		name   string
+		before []*pb.Connection
+		after  []*pb.Connection
+		want   []pb.ChangeType
+		old    []*pb.Connection
+	}{
+		{"update one of two", []*pb.Connection{call, publishes}, []*pb.Connection{call, tagged}, []pb.ChangeType{pb.ChangeType_CHANGE_TYPE_CONNECTION_UPDATED}, []*pb.Connection{publishes}},
+		{"reorder", []*pb.Connection{call, publishes}, []*pb.Connection{publishes, call}, nil, nil},
+		{"delete one of two", []*pb.Connection{call, publishes}, []*pb.Connection{publishes}, []pb.ChangeType{pb.ChangeType_CHANGE_TYPE_CONNECTION_DELETED}, []*pb.Connection{call}},
+		{"add a parallel one", []*pb.Connection{call}, []*pb.Connection{call, publishes}, []pb.ChangeType{pb.ChangeType_CHANGE_TYPE_CONNECTION_CREATED}, []*pb.Connection{nil}},
+		{"change type", []*pb.Connection{db}, []*pb.Connection{dbConn}, []pb.ChangeType{pb.ChangeType_CHANGE_TYPE_CONNECTION_UPDATED}, []*pb.Connection{db}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := changeEvents(&pb.Infrastructure{Id: "shop", Connections: tt.before}, &pb.Infrastructure{Id: "shop", Version: 2, Connections: tt.after})
+			if len(events) != len(tt.want) {
+				t.Fatalf("events = %v, want types %v", events, tt.want)
+			}
+			for i, event := range events {
+				if event.Type != tt.want[i] || event.OldConnection != tt.old[i] {
+					t.Errorf("event %d = %v, want %v of %v", i, event, tt.want[i], tt.old[i])
+				}
+			}
+		})
+	}
+}
+
+// batch returns the events of one version, as published by the server.
+func batch(version int64) []*pb.ChangeEvent {
+	return []*pb.ChangeEvent{{Type: pb.ChangeType_CHANGE_TYPE_ENTITY_CREATED, InfrastructureId: "shop", Version: version}}
+}
+
+func TestHubResume(t *testing.T) {
+	h := newHub()
+	for version := int64(1); version <= 3; version++ {
+		h.publish("shop", batch(version))
+	}
+
+	tests := []struct {
+		after int64
+		want  []int64
+		ok    bool
+	}{
+		{0, nil, false},
+		{1, []int64{2, 3}, true},
+		{2, []int64{3}, true},
+		{3, nil, true},
+		{4, nil, false},
+	}
+	for _, tt := range tests {
+		replay, sub, ok := h.subscribe("shop", tt.after)
+		h.unsubscribe("shop", sub)
+		if ok != tt.ok {
+			t.Errorf("subscribe after %d: ok = %v, want %v", tt.after, ok, tt.ok)
+		}
+		var got []int64
+		for _, events := range replay {
+			got = append(got, events[0].Version)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("subscribe after %d: replayed %v, want %v", tt.after, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("subscribe after %d: replayed %v, want %v", tt.after, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	h := newHub()
+	_, slow, _ := h.subscribe("shop", 0)
+	_, fast, _ := h.subscribe("shop", 0)
+
+	var received int
+	for version := int64(1); version <= watchBuffer+1; version++ {
+		h.publish("shop", batch(version))
+		<-fast.events
+		received++
+	}
+	if received != watchBuffer+1 {
+		t.Fatalf("fast subscriber received %d versions, want %d", received, watchBuffer+1)
+	}
+
+	buffered := 0
+	for range slow.events {
+		buffered++
+	}
+	if buffered != watchBuffer {
+		t.Errorf("slow subscriber received %d versions before being dropped, want %d", buffered, watchBuffer)
+	}
+	if subs := h.topics["shop"].subs; len(subs) != 1 || !subs[fast] {
+		t.Errorf("subscribers after drop = %v, want only the fast one", subs)
+	}
+	// Unsubscribing a dropped watcher must not close its channel twice
+	h.unsubscribe("shop", slow)
+	h.unsubscribe("shop", fast)
+}
+
+// watchStream collects the events sent to a watcher.
+type watchStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	events chan *pb.ChangeEvent
+}
+
+func (w *watchStream) Context() context.Context { return w.ctx }
+
+func (w *watchStream) Send(event *pb.ChangeEvent) error {
+	w.events <- event
+	return nil
+}
+
+// watch starts watching an infrastructure. Cancel the returned function to
+// stop, then read the result of WatchInfrastructure from the channel.
+func watch(s *Server, req *pb.WatchInfrastructureRequest) (*watchStream, context.CancelFunc, <-chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &watchStream{ctx: ctx, events: make(chan *pb.ChangeEvent, 16)}
+	done := make(chan error, 1)
+	go func() { done <- s.WatchInfrastructure(req, stream) }()
+	return stream, cancel, done
+}
+
+func next(t *testing.T, stream *watchStream) *pb.ChangeEvent {
+	t.Helper()
+	select {
+	case event := <-stream.events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return nil
+	}
+}
+
+// waitSubscribed waits until an infrastructure has a watcher.
+func waitSubscribed(t *testing.T, h *hub, id string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		h.mu.Lock()
+		n := 0
+		if topic, ok := h.topics[id]; ok {
+			n = len(topic.subs)
+		}
+		h.mu.Unlock()
+		if n > 0 {
+			return
+		}
+	}
+	t.Fatal("watcher did not subscribe")
+}
+
+func TestWatchInfrastructure(t *testing.T) {
+	ctx := context.Background()
+	s := New(gorph.DefaultStyle(), storage.NewMemory())
+	_, err := s.CreateInfrastructure(ctx, &pb.CreateInfrastructureRequest{Infrastructure: &pb.Infrastructure{Id: "shop"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"API", "DB"} {
+		_, err := s.CreateEntity(ctx, &pb.CreateEntityRequest{InfrastructureId: "shop", Entity: &pb.Entity{
+			Id: id, Category: pb.Category_CATEGORY_BACKEND, Description: "d", Status: pb.Status_STATUS_HEALTHY,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("resume", func(t *testing.T) {
+		stream, cancel, done := watch(s, &pb.WatchInfrastructureRequest{InfrastructureId: "shop", StartVersion: 2})
+		defer cancel()
+		event := next(t, stream)
+		if event.Type != pb.ChangeType_CHANGE_TYPE_ENTITY_CREATED || event.Version != 3 || event.NewEntity.GetId() != "DB" {
+			t.Errorf("first event = %v, want DB created at version 3", event)
+		}
+		cancel()
+		if err := <-done; status.Code(err) != codes.Canceled {
+			t.Errorf("err = %v, want Canceled", err)
+		}
+	})
+
+	t.Run("snapshot then live", func(t *testing.T) {
+		stream, cancel, done := watch(s, &pb.WatchInfrastructureRequest{InfrastructureId: "shop"})
+		defer cancel()
+		if event := next(t, stream); event.Type != pb.ChangeType_CHANGE_TYPE_SNAPSHOT || event.Version != 3 {
+			t.Errorf("first event = %v, want a snapshot of version 3", event)
+		}
+		if _, err := s.DeleteEntity(ctx, &pb.DeleteEntityRequest{InfrastructureId: "shop", EntityId: "DB"}); err != nil {
+			t.Fatal(err)
+		}
+		if event := next(t, stream); event.Type != pb.ChangeType_CHANGE_TYPE_ENTITY_DELETED || event.Version != 4 {
+			t.Errorf("live event = %v, want DB deleted at version 4", event)
+		}
+		cancel()
+		if err := <-done; status.Code(err) != codes.Canceled {
+			t.Errorf("err = %v, want Canceled", err)
+		}
+		s.hub.mu.Lock()
+		if topic, ok := s.hub.topics["shop"]; ok && len(topic.subs) != 0 {
+			t.Errorf("cancelled watcher is still subscribed")
+		}
+		s.hub.mu.Unlock()
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		stream, cancel, done := watch(s, &pb.WatchInfrastructureRequest{InfrastructureId: "shop", StartVersion: 4})
+		defer cancel()
+		waitSubscribed(t, s.hub, "shop")
+		if _, err := s.DeleteInfrastructure(ctx, &pb.DeleteInfrastructureRequest{InfrastructureId: "shop"}); err != nil {
+			t.Fatal(err)
+		}
+		if event := next(t, stream); event.Type != pb.ChangeType_CHANGE_TYPE_INFRASTRUCTURE_DELETED {
+			t.Errorf("event = %v, want infrastructure deleted", event)
+		}
+		if err := <-done; err != nil {
+			t.Errorf("err = %v, want the stream to end", err)
+		}
+	})
+}
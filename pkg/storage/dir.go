@@ -0,0 +1,456 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pb "gorph/v2/api/v1"
+	"gorph/v2/pkg/gorph"
+	"gorph/v2/pkg/protoconv"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Dir is a Store keeping each infrastructure in a YAML file named after its
+// ID, so a directory of definitions can be served and kept in Git.
+// Updates edit the existing file in place, preserving comments and
+// formatting, so API changes show up as small diffs.
+//
+// The version and timestamps of each infrastructure are kept in
+// .gorph/<id>.json next to the definitions, with a hash of the file they
+// describe. A file edited by hand, for example by a git pull, is seen as
+// one version newer than its metadata when it is next read, and the new
+// version is recorded then, so every edit gets a version of its own.
+//
+// Definitions are read with their includes merged, and a file that
+// includes others is seen as changed when they are. Such files are
+// read-only, since the store cannot tell which file a change belongs in,
+// and files included by another definition are not listed on their own.
+// Entities of a file that sets a namespace are served qualified by it, and
+// written back relative to it, so new entities must be in the namespace.
+//
+// Files that cannot be read as definitions are logged and left out of
+// List, so one bad edit does not hide the others; Get reports the error.
+//
+// Reads and writes are serialized within the process; the store does not
+// guard against other processes editing the files at the same time.
+type Dir struct {
+	mu   sync.Mutex
+	path string
+}
+
+// yamlExts are the file extensions read as definitions, preferred first.
+var yamlExts = []string{".yml", ".yaml"}
+
+// OpenDir returns a store for the YAML files in path, creating the
+// directory if needed.
+func OpenDir(path string) (*Dir, error) {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, fmt.Errorf("creating store directory: %w", err)
+	}
+	return &Dir{path: path}, nil
+}
+
+func (d *Dir) Get(ctx context.Context, id string) (*pb.Infrastructure, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	file, err := d.find(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	infra, _, err := d.decode(id, file, data)
+	return infra, err
+}
+
+func (d *Dir) List(ctx context.Context) ([]*pb.Infrastructure, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var ids []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		id := strings.TrimSuffix(entry.Name(), ext)
+		if entry.IsDir() || !isYAMLExt(ext) || seen[id] || checkFileID(id) != nil {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	infras := make(map[string]*pb.Infrastructure, len(ids))
+	files := make(map[string]string, len(ids))
+	includes := make(map[string]map[string]bool, len(ids))
+	decoded := ids[:0]
+	for _, id := range ids {
+		file, err := d.find(id)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		infra, included, err := d.decode(id, file, data)
+		if err != nil {
+			// One broken file must not hide the others; Get still
+			// reports the error for its ID
+			log.Printf("Warning: not listing infrastructure %q: %v", id, err)
+			continue
+		}
+		decoded = append(decoded, id)
+		infras[id] = infra
+		if files[id], err = filepath.Abs(file); err != nil {
+			return nil, err
+		}
+		includes[id] = make(map[string]bool, len(included))
+		for _, f := range included {
+			abs, err := filepath.Abs(f)
+			if err != nil {
+				return nil, err
+			}
+			includes[id][abs] = true
+		}
+	}
+
+	// Leave out the files other definitions are composed of, unless they
+	// include each other
+	ids = decoded
+	list := make([]*pb.Infrastructure, 0, len(ids))
+	for _, id := range ids {
+		fragment := false
+		for _, other := range ids {
+			if includes[other][files[id]] && !includes[id][files[other]] {
+				fragment = true
+				break
+			}
+		}
+		if !fragment {
+			list = append(list, infras[id])
+		}
+	}
+	return list, nil
+}
+
+func (d *Dir) Create(ctx context.Context, infra *pb.Infrastructure) error {
+	if err := checkFileID(infra.Id); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, err := d.find(infra.Id); err == nil {
+		return ErrAlreadyExists
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	data, err := gorph.MarshalInfrastructure(toModel(infra))
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(d.path, infra.Id+yamlExts[0]), data); err != nil {
+		return err
+	}
+	return d.writeMeta(infra, data)
+}
+
+func (d *Dir) Update(ctx context.Context, id string, change func(infra *pb.Infrastructure) error) (*pb.Infrastructure, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	file, err := d.find(id)
+	if err != nil {
+		return nil, err
+	}
+	original, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	infra, included, err := d.decode(id, file, original)
+	if err != nil {
+		return nil, err
+	}
+	if len(included) > 0 {
+		return nil, fmt.Errorf("%w: %s includes other files", ErrReadOnly, filepath.Base(file))
+	}
+	if err := change(infra); err != nil {
+		return nil, err
+	}
+	infra.Id = id
+
+	data, err := gorph.UpdateInfrastructureYAML(original, toModel(infra))
+	var errs gorph.ValidationErrors
+	if errors.As(err, &errs) {
+		return nil, fmt.Errorf("%w: %s: %v", ErrUnsupported, filepath.Base(file), err)
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if err := writeFile(file, data); err != nil {
+		return nil, err
+	}
+	if err := d.writeMeta(infra, data); err != nil {
+		return nil, err
+	}
+	return infra, nil
+}
+
+func (d *Dir) Delete(ctx context.Context, id string, check func(infra *pb.Infrastructure) error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	file, err := d.find(id)
+	if err != nil {
+		return err
+	}
+	if check != nil {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		infra, _, err := d.decode(id, file, data)
+		if err != nil {
+			return err
+		}
+		if err := check(infra); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(file); err != nil {
+		return err
+	}
+	if err := os.Remove(d.metaFile(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (d *Dir) Close() error {
+	return nil
+}
+
+// find returns the file holding an infrastructure.
+func (d *Dir) find(id string) (string, error) {
+	if err := checkFileID(id); err != nil {
+		return "", ErrNotFound
+	}
+	for _, ext := range yamlExts {
+		file := filepath.Join(d.path, id+ext)
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", ErrNotFound
+}
+
+// checkFileID rejects IDs that cannot be used as a plain file name.
+func checkFileID(id string) error {
+	if id == "" || strings.HasPrefix(id, ".") || strings.ContainsAny(id, `/\`) || filepath.Base(id) != id {
+		return fmt.Errorf("infrastructure ID %q cannot be used as a file name", id)
+	}
+	return nil
+}
+
+func isYAMLExt(ext string) bool {
+	for _, e := range yamlExts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// decode converts a definition read from file, merging the files it
+// includes, and adds its metadata, recording a new version if the files
+// changed since it was last written. It returns the included files too.
+// The name defaults to the ID when the file does not set one. Callers must
+// hold d.mu.
+func (d *Dir) decode(id, file string, data []byte) (*pb.Infrastructure, []string, error) {
+	model, err := gorph.LoadInfrastructure(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", file, err)
+	}
+	infra, err := protoconv.InfrastructureToProto(model)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", file, err)
+	}
+	infra.Id = id
+	if infra.Name == "" {
+		infra.Name = id
+	}
+
+	meta, err := d.readMeta(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	hash, err := hashFiles(data, model.IncludedFiles())
+	if err != nil {
+		return nil, nil, err
+	}
+	if meta.SHA256 != hash {
+		// Changed outside the store, or never written by it
+		modified, err := lastModified(append([]string{file}, model.IncludedFiles()...))
+		if err != nil {
+			return nil, nil, err
+		}
+		meta.Version++
+		meta.UpdatedAt = modified
+		if meta.CreatedAt.IsZero() {
+			meta.CreatedAt = modified
+		}
+		meta.SHA256 = hash
+		if err := d.saveMeta(id, meta); err != nil {
+			return nil, nil, err
+		}
+	}
+	infra.Version = meta.Version
+	infra.CreatedAt = timestamppb.New(meta.CreatedAt)
+	infra.UpdatedAt = timestamppb.New(meta.UpdatedAt)
+	return infra, model.IncludedFiles(), nil
+}
+
+// lastModified returns the latest modification time of files.
+func lastModified(files []string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// fileMeta is the metadata of a definition that YAML does not carry.
+type fileMeta struct {
+	Version   int64     `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	SHA256    string    `json:"sha256"`
+}
+
+func (d *Dir) metaFile(id string) string {
+	return filepath.Join(d.path, ".gorph", id+".json")
+}
+
+// readMeta returns the stored metadata, or zero values if there is none.
+func (d *Dir) readMeta(id string) (fileMeta, error) {
+	var meta fileMeta
+	data, err := os.ReadFile(d.metaFile(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return meta, nil
+	} else if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, fmt.Errorf("%s: %w", d.metaFile(id), err)
+	}
+	return meta, nil
+}
+
+// writeMeta records the metadata of an infrastructure written as data.
+func (d *Dir) writeMeta(infra *pb.Infrastructure, data []byte) error {
+	return d.saveMeta(infra.Id, fileMeta{
+		Version:   infra.Version,
+		CreatedAt: infra.CreatedAt.AsTime(),
+		UpdatedAt: infra.UpdatedAt.AsTime(),
+		SHA256:    hashFile(data),
+	})
+}
+
+func (d *Dir) saveMeta(id string, meta fileMeta) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := d.metaFile(id)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return writeFile(file, append(data, '\n'))
+}
+
+func hashFile(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashFiles hashes a definition with the files it includes. Without
+// includes it is the hash of the definition alone.
+func hashFiles(data []byte, included []string) (string, error) {
+	if len(included) == 0 {
+		return hashFile(data), nil
+	}
+	h := sha256.New()
+	h.Write(data)
+	for _, file := range included {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "\x00%d\x00", len(content))
+		h.Write(content)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// toModel converts an infrastructure for writing, leaving out a name that
+// only repeats the ID.
+func toModel(infra *pb.Infrastructure) *gorph.Infrastructure {
+	model := protoconv.InfrastructureFromProto(infra)
+	if model.Name == infra.Id {
+		model.Name = ""
+	}
+	return model
+}
+
+// writeFile replaces file atomically, so readers never see a partial
+// definition.
+func writeFile(file string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".gorph-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,319 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pb "gorph/v2/api/v1"
+)
+
+// writeFiles creates a store directory holding the given definitions.
+func writeFiles(t *testing.T, files map[string]string) (*Dir, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := OpenDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, dir
+}
+
+func entityIDs(infra *pb.Infrastructure) []string {
+	var ids []string
+	for _, entity := range infra.GetEntities() {
+		ids = append(ids, entity.GetId())
+	}
+	return ids
+}
+
+func TestDirIncludes(t *testing.T) {
+	ctx := context.Background()
+	const platform = `# Composed from team files
+include: [search.yml, teams/*.yml]
+entities:
+  - id: Gateway
+    category: FRONTEND
+    description: Edge
+    status: healthy
+connections:
+  - from: Gateway
+    to: Index
+    type: API_Call
+`
+	store, dir := writeFiles(t, map[string]string{
+		"platform.yml": platform,
+		"search.yml": `entities:
+  - id: Index
+    category: DATABASE
+    description: Search index
+    status: healthy
+connections: []
+`,
+		"teams/billing.yml": `entities:
+  - id: Billing
+    category: BACKEND
+    description: Invoices
+    status: healthy
+connections: []
+`,
+		"other.yml": `entities:
+  - id: Other
+    category: BACKEND
+    description: Unrelated
+    status: healthy
+connections: []
+`,
+	})
+
+	infra, err := store.Get(ctx, "platform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(entityIDs(infra), ","), "Gateway,Index,Billing"; got != want {
+		t.Errorf("entities = %s, want %s", got, want)
+	}
+
+	// Included files are part of their definition, not listed on their own
+	list, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, infra := range list {
+		ids = append(ids, infra.GetId())
+	}
+	if got, want := strings.Join(ids, ","), "other,platform"; got != want {
+		t.Errorf("listed = %s, want %s", got, want)
+	}
+	if _, err := store.Get(ctx, "search"); err != nil {
+		t.Errorf("Get(search) = %v, want the included file", err)
+	}
+
+	// Composed files cannot be changed, so their includes are never lost
+	_, err = store.Update(ctx, "platform", func(infra *pb.Infrastructure) error {
+		infra.Description = "changed"
+		return nil
+	})
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Update() error = %v, want %v", err, ErrReadOnly)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "platform.yml")); err != nil || string(data) != platform {
+		t.Errorf("platform.yml changed to:\n%s", data)
+	}
+
+	// Editing an included file updates the definition
+	billing := filepath.Join(dir, "teams", "billing.yml")
+	data, err := os.ReadFile(billing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(billing, []byte(strings.Replace(string(data), "healthy", "degraded", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	edited := infra.GetUpdatedAt().AsTime().Add(time.Hour)
+	if err := os.Chtimes(billing, edited, edited); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := store.Get(ctx, "platform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := changed.GetEntities()[2].GetStatus(); got != pb.Status_STATUS_DEGRADED {
+		t.Errorf("status = %v, want %v", got, pb.Status_STATUS_DEGRADED)
+	}
+	if got := changed.GetUpdatedAt().AsTime(); !got.Equal(edited) {
+		t.Errorf("updated_at = %v, want %v", got, edited)
+	}
+}
+
+func TestDirUpdateKeepsInclude(t *testing.T) {
+	ctx := context.Background()
+	// A pattern matching only the file itself merges nothing, so the file
+	// can be updated; the include must survive for files added later
+	store, dir := writeFiles(t, map[string]string{
+		"platform.yml": `include: [platform*.yml]
+entities:
+  - id: Gateway
+    category: FRONTEND
+    description: Edge
+    status: healthy
+connections: []
+`,
+	})
+	_, err := store.Update(ctx, "platform", func(infra *pb.Infrastructure) error {
+		infra.Entities[0].Status = pb.Status_STATUS_DEGRADED
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "platform.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"include: [platform*.yml]\n", "status: degraded\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("platform.yml lacks %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestDirNamespace(t *testing.T) {
+	ctx := context.Background()
+	const payments = `# payments team
+namespace: payments
+entities:
+  - id: API
+    category: BACKEND
+    description: Payments API
+    status: healthy
+  - id: Ledger
+    category: DATABASE
+    description: Ledger
+    status: healthy
+connections:
+  - from: API
+    to: Ledger
+    type: DB_Connection
+`
+	store, dir := writeFiles(t, map[string]string{"payments.yml": payments})
+	file := filepath.Join(dir, "payments.yml")
+
+	infra, err := store.Get(ctx, "payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(entityIDs(infra), ","), "payments/API,payments/Ledger"; got != want {
+		t.Errorf("entities = %s, want %s", got, want)
+	}
+
+	// Only the changed value is rewritten, relative to the namespace
+	_, err = store.Update(ctx, "payments", func(infra *pb.Infrastructure) error {
+		infra.Entities[0].Status = pb.Status_STATUS_DEGRADED
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Replace(payments, "healthy", "degraded", 1); string(data) != want {
+		t.Errorf("payments.yml =\n%s\nwant\n%s", data, want)
+	}
+
+	// Entities outside the namespace cannot be written to the file
+	_, err = store.Update(ctx, "payments", func(infra *pb.Infrastructure) error {
+		infra.Entities = append(infra.Entities, &pb.Entity{Id: "Refunds", Category: pb.Category_CATEGORY_BACKEND, Description: "d", Status: pb.Status_STATUS_HEALTHY})
+		return nil
+	})
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Update() error = %v, want %v", err, ErrUnsupported)
+	}
+	if unchanged, err := os.ReadFile(file); err != nil || string(unchanged) != string(data) {
+		t.Errorf("payments.yml changed to:\n%s", unchanged)
+	}
+}
+
+func TestDirVersionsEdits(t *testing.T) {
+	ctx := context.Background()
+	const shop = `entities:
+  - id: API
+    category: BACKEND
+    description: Shop API
+    status: healthy
+connections: []
+`
+	store, dir := writeFiles(t, map[string]string{"shop.yml": shop})
+	file := filepath.Join(dir, "shop.yml")
+
+	version := func() int64 {
+		t.Helper()
+		infra, err := store.Get(ctx, "shop")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return infra.GetVersion()
+	}
+	edit := func(old, new string) {
+		t.Helper()
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(strings.Replace(string(data), old, new, 1)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := version(); got != 1 {
+		t.Errorf("version of a new file = %d, want 1", got)
+	}
+	if got := version(); got != 1 {
+		t.Errorf("version read again = %d, want 1", got)
+	}
+	_, err := store.Update(ctx, "shop", func(infra *pb.Infrastructure) error {
+		infra.Version++
+		infra.Description = "Shop"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := version(); got != 2 {
+		t.Errorf("version after update = %d, want 2", got)
+	}
+
+	// Every edit by hand gets a version of its own
+	edit("healthy", "degraded")
+	if got := version(); got != 3 {
+		t.Errorf("version after an edit = %d, want 3", got)
+	}
+	edit("degraded", "down")
+	if got := version(); got != 4 {
+		t.Errorf("version after a second edit = %d, want 4", got)
+	}
+	if got := version(); got != 4 {
+		t.Errorf("version read again = %d, want 4", got)
+	}
+}
+
+func TestDirListSkipsBrokenFiles(t *testing.T) {
+	ctx := context.Background()
+	store, _ := writeFiles(t, map[string]string{
+		"good.yml": `entities:
+  - id: API
+    category: BACKEND
+    description: Shop API
+    status: healthy
+connections: []
+`,
+		"broken.yml": "entities: [\n",
+	})
+
+	list, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].GetId() != "good" {
+		t.Errorf("listed %v, want only good", list)
+	}
+	if _, err := store.Get(ctx, "broken"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(broken) error = %v, want the parse error", err)
+	}
+}
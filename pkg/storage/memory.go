@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	pb "gorph/v2/api/v1"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Memory is a Store keeping infrastructures in memory. It is meant for
+// tests and throwaway servers.
+type Memory struct {
+	mu     sync.RWMutex
+	infras map[string]*pb.Infrastructure
+}
+
+func NewMemory() *Memory {
+	return &Memory{infras: make(map[string]*pb.Infrastructure)}
+}
+
+func (m *Memory) Get(ctx context.Context, id string) (*pb.Infrastructure, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	infra, ok := m.infras[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return proto.Clone(infra).(*pb.Infrastructure), nil
+}
+
+func (m *Memory) List(ctx context.Context) ([]*pb.Infrastructure, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]string, 0, len(m.infras))
+	for id := range m.infras {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	infras := make([]*pb.Infrastructure, len(ids))
+	for i, id := range ids {
+		infras[i] = proto.Clone(m.infras[id]).(*pb.Infrastructure)
+	}
+	return infras, nil
+}
+
+func (m *Memory) Create(ctx context.Context, infra *pb.Infrastructure) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.infras[infra.Id]; ok {
+		return ErrAlreadyExists
+	}
+	m.infras[infra.Id] = proto.Clone(infra).(*pb.Infrastructure)
+	return nil
+}
+
+func (m *Memory) Update(ctx context.Context, id string, change func(infra *pb.Infrastructure) error) (*pb.Infrastructure, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.infras[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	infra := proto.Clone(stored).(*pb.Infrastructure)
+	if err := change(infra); err != nil {
+		return nil, err
+	}
+	infra.Id = id
+	m.infras[id] = proto.Clone(infra).(*pb.Infrastructure)
+	return infra, nil
+}
+
+func (m *Memory) Delete(ctx context.Context, id string, check func(infra *pb.Infrastructure) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.infras[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if check != nil {
+		if err := check(proto.Clone(stored).(*pb.Infrastructure)); err != nil {
+			return err
+		}
+	}
+	delete(m.infras, id)
+	return nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
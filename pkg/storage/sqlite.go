@@ -0,0 +1,300 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	pb "gorph/v2/api/v1"
+
+	"google.golang.org/protobuf/proto"
+	_ "modernc.org/sqlite"
+)
+
+// migrations are applied in order; PRAGMA user_version records how many
+// have run. Never edit a released migration, append a new one instead.
+var migrations = []string{
+	// 1: infrastructures with their entities and connections. Messages are
+	// stored as protobuf so fields added to the API survive a round trip;
+	// the key columns are duplicated for lookups and ordering.
+	`CREATE TABLE infrastructures (
+		id   TEXT PRIMARY KEY,
+		data BLOB NOT NULL
+	);
+	CREATE TABLE entities (
+		infrastructure_id TEXT NOT NULL REFERENCES infrastructures(id) ON DELETE CASCADE,
+		id                TEXT NOT NULL,
+		position          INTEGER NOT NULL,
+		data              BLOB NOT NULL,
+		PRIMARY KEY (infrastructure_id, id)
+	);
+	CREATE TABLE connections (
+		infrastructure_id TEXT NOT NULL REFERENCES infrastructures(id) ON DELETE CASCADE,
+		position          INTEGER NOT NULL,
+		from_id           TEXT NOT NULL,
+		to_id             TEXT NOT NULL,
+		data              BLOB NOT NULL,
+		PRIMARY KEY (infrastructure_id, position)
+	);
+	CREATE INDEX connections_endpoints ON connections (infrastructure_id, from_id, to_id);`,
+}
+
+// SQLite is a Store backed by an embedded SQLite database file.
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens or creates the database at path and brings its schema
+// up to date.
+func OpenSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("opening database %s: %w", path, err)
+	}
+	// A single connection serializes writers, which SQLite requires anyway
+	db.SetMaxOpenConns(1)
+
+	s := &SQLite{db: db}
+	if err := s.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating database %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *SQLite) migrate(ctx context.Context) error {
+	var version int
+	if err := s.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than this binary supports (%d)", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		// PRAGMA does not take parameters
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (s *SQLite) Get(ctx context.Context, id string) (*pb.Infrastructure, error) {
+	return load(ctx, s.db, id)
+}
+
+func (s *SQLite) List(ctx context.Context) ([]*pb.Infrastructure, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id FROM infrastructures ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	infras := make([]*pb.Infrastructure, 0, len(ids))
+	for _, id := range ids {
+		infra, err := load(ctx, s.db, id)
+		if err != nil {
+			return nil, err
+		}
+		infras = append(infras, infra)
+	}
+	return infras, nil
+}
+
+func (s *SQLite) Create(ctx context.Context, infra *pb.Infrastructure) error {
+	return s.transact(ctx, func(tx *sql.Tx) error {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM infrastructures WHERE id = ?)", infra.Id).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return ErrAlreadyExists
+		}
+		return save(ctx, tx, infra)
+	})
+}
+
+func (s *SQLite) Update(ctx context.Context, id string, change func(infra *pb.Infrastructure) error) (*pb.Infrastructure, error) {
+	var infra *pb.Infrastructure
+	err := s.transact(ctx, func(tx *sql.Tx) error {
+		var err error
+		if infra, err = load(ctx, tx, id); err != nil {
+			return err
+		}
+		if err := change(infra); err != nil {
+			return err
+		}
+		infra.Id = id
+		return save(ctx, tx, infra)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return infra, nil
+}
+
+func (s *SQLite) Delete(ctx context.Context, id string, check func(infra *pb.Infrastructure) error) error {
+	return s.transact(ctx, func(tx *sql.Tx) error {
+		if check != nil {
+			infra, err := load(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if err := check(infra); err != nil {
+				return err
+			}
+		}
+		res, err := tx.ExecContext(ctx, "DELETE FROM infrastructures WHERE id = ?", id)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// transact runs fn in a transaction, committing if it returns nil.
+func (s *SQLite) transact(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// load reads an infrastructure with its entities and connections.
+func load(ctx context.Context, q querier, id string) (*pb.Infrastructure, error) {
+	var data []byte
+	err := q.QueryRowContext(ctx, "SELECT data FROM infrastructures WHERE id = ?", id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	infra := &pb.Infrastructure{}
+	if err := proto.Unmarshal(data, infra); err != nil {
+		return nil, fmt.Errorf("decoding infrastructure %s: %w", id, err)
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT data FROM entities WHERE infrastructure_id = ? ORDER BY position", id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		entity := &pb.Entity{}
+		if err := scanMessage(rows, entity); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("decoding entity of %s: %w", id, err)
+		}
+		infra.Entities = append(infra.Entities, entity)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.QueryContext(ctx, "SELECT data FROM connections WHERE infrastructure_id = ? ORDER BY position", id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		conn := &pb.Connection{}
+		if err := scanMessage(rows, conn); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("decoding connection of %s: %w", id, err)
+		}
+		infra.Connections = append(infra.Connections, conn)
+	}
+	rows.Close()
+	return infra, rows.Err()
+}
+
+func scanMessage(rows *sql.Rows, msg proto.Message) error {
+	var data []byte
+	if err := rows.Scan(&data); err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// save replaces the stored rows of an infrastructure.
+func save(ctx context.Context, tx *sql.Tx, infra *pb.Infrastructure) error {
+	header := proto.Clone(infra).(*pb.Infrastructure)
+	header.Entities, header.Connections = nil, nil
+	data, err := proto.Marshal(header)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO infrastructures (id, data) VALUES (?, ?) ON CONFLICT (id) DO UPDATE SET data = excluded.data", infra.Id, data); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"entities", "connections"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE infrastructure_id = ?", infra.Id); err != nil {
+			return err
+		}
+	}
+	for i, entity := range infra.Entities {
+		data, err := proto.Marshal(entity)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO entities (infrastructure_id, id, position, data) VALUES (?, ?, ?, ?)", infra.Id, entity.Id, i, data); err != nil {
+			return fmt.Errorf("saving entity %s: %w", entity.Id, err)
+		}
+	}
+	for i, conn := range infra.Connections {
+		data, err := proto.Marshal(conn)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO connections (infrastructure_id, position, from_id, to_id, data) VALUES (?, ?, ?, ?, ?)", infra.Id, i, conn.From, conn.To, data); err != nil {
+			return fmt.Errorf("saving connection %s -> %s: %w", conn.From, conn.To, err)
+		}
+	}
+	return nil
+}
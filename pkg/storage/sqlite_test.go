@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	pb "gorph/v2/api/v1"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func openSQLite(t *testing.T, path string) *SQLite {
+	t.Helper()
+	store, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func sqliteShop(t *testing.T) *pb.Infrastructure {
+	t.Helper()
+	config, err := structpb.NewStruct(map[string]any{"replicas": 3, "resources": map[string]any{"cpu": "500m"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &pb.Infrastructure{
+		Id:          "shop",
+		Name:        "Shop",
+		Description: "Online shop",
+		Admins:      []string{"ops"},
+		Version:     1,
+		CreatedAt:   timestamppb.Now(),
+		Entities: []*pb.Entity{
+			// Not in ID order, which must be kept
+			{Id: "Web", Category: pb.Category_CATEGORY_FRONTEND, Description: "Storefront", Status: pb.Status_STATUS_HEALTHY},
+			{
+				Id: "API", Category: pb.Category_CATEGORY_BACKEND, Description: "Shop API", Status: pb.Status_STATUS_DEGRADED,
+				Owner: "payments", Environment: "prod", Tags: []string{"public"},
+				Attributes: map[string]string{"region": "eu"}, DeploymentConfig: config,
+			},
+			{Id: "DB", Category: pb.Category_CATEGORY_DATABASE, Description: "Orders", Status: pb.Status_STATUS_HEALTHY},
+		},
+		Connections: []*pb.Connection{
+			{From: "Web", To: "API", Type: pb.ConnectionType_CONNECTION_TYPE_HTTP_REQUEST},
+			{From: "API", To: "DB", Type: pb.ConnectionType_CONNECTION_TYPE_DB_CONNECTION},
+			{From: "API", To: "DB", TypeName: "Replicates"},
+		},
+	}
+}
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "gorph.db")
+	store := openSQLite(t, path)
+	shop := sqliteShop(t)
+	if err := store.Create(ctx, shop); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Create(ctx, &pb.Infrastructure{Id: "blog", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Get(ctx, "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(got, shop) {
+		t.Errorf("Get() = %v\nwant %v", got, shop)
+	}
+
+	// Returned messages are copies
+	got.Entities[0].Description = "changed"
+	if again, _ := store.Get(ctx, "shop"); again.Entities[0].Description != "Storefront" {
+		t.Error("changing a returned message changed the store")
+	}
+
+	store.Close()
+	reopened := openSQLite(t, path)
+	infras, err := reopened.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infras) != 2 || infras[0].Id != "blog" || !proto.Equal(infras[1], shop) {
+		t.Errorf("List() after reopening = %v", infras)
+	}
+}
+
+func TestSQLiteNotFound(t *testing.T) {
+	ctx := context.Background()
+	store := openSQLite(t, filepath.Join(t.TempDir(), "gorph.db"))
+
+	if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() err = %v, want %v", err, ErrNotFound)
+	}
+	called := false
+	_, err := store.Update(ctx, "missing", func(*pb.Infrastructure) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrNotFound) || called {
+		t.Errorf("Update() err = %v, change called %v; want %v, false", err, called, ErrNotFound)
+	}
+	if err := store.Delete(ctx, "missing", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() err = %v, want %v", err, ErrNotFound)
+	}
+	if err := store.Delete(ctx, "missing", func(*pb.Infrastructure) error { return nil }); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() with check err = %v, want %v", err, ErrNotFound)
+	}
+	if infras, err := store.List(ctx); err != nil || len(infras) != 0 {
+		t.Errorf("List() = %v, %v; want none", infras, err)
+	}
+
+	if err := store.Create(ctx, sqliteShop(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Create(ctx, &pb.Infrastructure{Id: "shop"}); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("Create() of a taken ID err = %v, want %v", err, ErrAlreadyExists)
+	}
+	if err := store.Delete(ctx, "shop", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "shop"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() err = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestSQLiteUpdate(t *testing.T) {
+	ctx := context.Background()
+	store := openSQLite(t, filepath.Join(t.TempDir(), "gorph.db"))
+	shop := sqliteShop(t)
+	if err := store.Create(ctx, shop); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := store.Update(ctx, "shop", func(infra *pb.Infrastructure) error {
+		infra.Version++
+		infra.Id = "renamed" // IDs cannot change
+		infra.Entities = infra.Entities[1:]
+		infra.Connections = infra.Connections[1:]
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ctx, "shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(got, updated) || got.Version != 2 || len(got.Entities) != 2 || len(got.Connections) != 2 {
+		t.Errorf("Get() after Update() = %v\nwant %v", got, updated)
+	}
+	if _, err := store.Get(ctx, "renamed"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(renamed) err = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestSQLiteVersionConflict(t *testing.T) {
+	ctx := context.Background()
+	store := openSQLite(t, filepath.Join(t.TempDir(), "gorph.db"))
+	shop := sqliteShop(t)
+	if err := store.Create(ctx, shop); err != nil {
+		t.Fatal(err)
+	}
+
+	// A change rejected by its callback, as the server does on a version
+	// mismatch, leaves no trace
+	errConflict := errors.New("version mismatch")
+	_, err := store.Update(ctx, "shop", func(infra *pb.Infrastructure) error {
+		infra.Version = 7
+		infra.Entities = nil
+		return errConflict
+	})
+	if !errors.Is(err, errConflict) {
+		t.Errorf("Update() err = %v, want %v", err, errConflict)
+	}
+	err = store.Delete(ctx, "shop", func(*pb.Infrastructure) error { return errConflict })
+	if !errors.Is(err, errConflict) {
+		t.Errorf("Delete() err = %v, want %v", err, errConflict)
+	}
+	if got, err := store.Get(ctx, "shop"); err != nil || !proto.Equal(got, shop) {
+		t.Errorf("Get() after rejected changes = %v, %v", got, err)
+	}
+
+	// Concurrent updates each see the version stored by the one before
+	const updates = 20
+	var wg sync.WaitGroup
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.Update(ctx, "shop", func(infra *pb.Infrastructure) error {
+				infra.Version++
+				return nil
+			}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got, err := store.Get(ctx, "shop"); err != nil || got.Version != 1+updates {
+		t.Errorf("version after %d concurrent updates = %d, %v; want %d", updates, got.GetVersion(), err, 1+updates)
+	}
+}
+
+func TestSQLiteNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gorph.db")
+	store := openSQLite(t, path)
+	if _, err := store.db.Exec("PRAGMA user_version = 99"); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	if _, err := OpenSQLite(path); err == nil || !strings.Contains(err.Error(), "schema version 99 is newer") {
+		t.Errorf("OpenSQLite() err = %v, want a schema version error", err)
+	}
+}
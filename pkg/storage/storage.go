@@ -0,0 +1,76 @@
+// Package storage persists the infrastructures served by the GorphService
+// API. Stores hand out copies, so callers may modify returned messages.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	pb "gorph/v2/api/v1"
+)
+
+var (
+	// ErrNotFound is returned when no infrastructure has the requested ID.
+	ErrNotFound = errors.New("infrastructure not found")
+	// ErrAlreadyExists is returned when creating an infrastructure whose ID
+	// is taken.
+	ErrAlreadyExists = errors.New("infrastructure already exists")
+	// ErrReadOnly is returned when changing an infrastructure the store
+	// can only read, such as a Dir definition composed of several files.
+	ErrReadOnly = errors.New("infrastructure is read-only")
+	// ErrUnsupported is returned when a change cannot be stored, such as
+	// an entity outside the namespace of the Dir file it belongs to.
+	ErrUnsupported = errors.New("change cannot be stored")
+)
+
+// Store holds infrastructures, with their entities and connections, by ID.
+type Store interface {
+	// Get returns the infrastructure with the given ID.
+	Get(ctx context.Context, id string) (*pb.Infrastructure, error)
+
+	// List returns every infrastructure ordered by ID.
+	List(ctx context.Context) ([]*pb.Infrastructure, error)
+
+	// Create stores a new infrastructure.
+	Create(ctx context.Context, infra *pb.Infrastructure) error
+
+	// Update calls change with the current infrastructure and stores the
+	// modified message unless change returns an error. The read and the
+	// write are atomic with respect to other updates.
+	Update(ctx context.Context, id string, change func(infra *pb.Infrastructure) error) (*pb.Infrastructure, error)
+
+	// Delete removes an infrastructure. If check is not nil it is called
+	// with the stored infrastructure first, and nothing is removed if it
+	// returns an error.
+	Delete(ctx context.Context, id string, check func(infra *pb.Infrastructure) error) error
+
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// Open returns the store described by spec:
+//
+//	memory            in-memory, lost on exit
+//	sqlite:PATH       embedded SQLite database file
+//	dir:PATH          one YAML file per infrastructure in a directory
+func Open(spec string) (Store, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "memory":
+		return NewMemory(), nil
+	case "sqlite":
+		if arg == "" {
+			return nil, fmt.Errorf("store %q: missing database path", spec)
+		}
+		return OpenSQLite(arg)
+	case "dir":
+		if arg == "" {
+			return nil, fmt.Errorf("store %q: missing directory path", spec)
+		}
+		return OpenDir(arg)
+	default:
+		return nil, fmt.Errorf("unknown store %q: use memory, sqlite:PATH or dir:PATH", spec)
+	}
+}
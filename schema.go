@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"gorph/v2/pkg/gorph"
+)
+
+// runSchema implements the "schema" command, which writes the JSON Schema
+// of infrastructure or style files and returns the process exit code.
+func runSchema(args []string) int {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	outputFile := fs.String("output", "", "Output file (default: stdout)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s schema [options] [infrastructure|style]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Writes the JSON Schema of infrastructure files (the default) or style files,\n")
+		fmt.Fprintf(os.Stderr, "for editors with YAML or JSON language servers.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	kind := fs.Arg(0)
+	if fs.NArg() > 0 {
+		// Allow options after the schema name
+		fs.Parse(fs.Args()[1:])
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var schema []byte
+	switch kind {
+	case "", "infrastructure":
+		schema = gorph.InfrastructureSchema()
+	case "style":
+		schema = gorph.StyleSchema()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown schema %q: use infrastructure or style\n", kind)
+		return 2
+	}
+
+	if *outputFile == "" {
+		os.Stdout.Write(schema)
+		return 0
+	}
+	if err := os.WriteFile(*outputFile, schema, 0644); err != nil {
+		log.Printf("Error writing schema: %v", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"gorph/v2/pkg/auth"
+	"gorph/v2/pkg/gateway"
+	"gorph/v2/pkg/gorph"
+	"gorph/v2/pkg/server"
+	"gorph/v2/pkg/storage"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
+)
+
+// runServe implements the "serve" command, which runs the GorphService gRPC
+// API, and optionally its HTTP/JSON gateway, until interrupted and returns
+// the process exit code.
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":9090", "Address to listen on")
+	styleFile := fs.String("style", "style.yml", "Style configuration file used for diagrams")
+	storeSpec := fs.String("store", "memory", "Storage backend: memory, sqlite:PATH or dir:PATH")
+	httpAddr := fs.String("http", "", "Address for the HTTP/JSON gateway (disabled if empty)")
+	renderTimeout := fs.Duration("render-timeout", gorph.DefaultGraphvizLimits.Timeout, "Time limit for rendering a PNG, SVG or PDF diagram with Graphviz")
+	corsOrigins := fs.String("cors", "", "Comma-separated origins allowed to call the HTTP gateway from a browser, or *")
+	var sec security
+	fs.StringVar(&sec.certFile, "tls-cert", "", "TLS certificate file; serves both APIs over TLS")
+	fs.StringVar(&sec.keyFile, "tls-key", "", "TLS private key file")
+	fs.StringVar(&sec.clientCAFile, "client-ca", "", "CA certificates for verifying client certificates (enables mTLS authentication)")
+	fs.StringVar(&sec.tokenFile, "auth-tokens", "", "YAML file of static bearer tokens (enables token authentication)")
+	fs.StringVar(&sec.jwksFile, "jwks", "", "JSON Web Key Set file for verifying JWT bearer tokens (enables JWT authentication)")
+	fs.StringVar(&sec.jwt.Issuer, "jwt-issuer", "", "Required iss claim of JWTs")
+	fs.StringVar(&sec.jwt.Audience, "jwt-audience", "", "Required aud claim of JWTs")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s serve [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Runs the GorphService gRPC API and, with -http, its HTTP/JSON gateway.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	styleConfig, err := gorph.LoadStyleConfig(*styleFile)
+	if err != nil {
+		log.Printf("Error loading style config: %v", err)
+		return 1
+	}
+	for _, w := range styleConfig.Warnings() {
+		log.Printf("Warning: %v", w)
+	}
+
+	store, err := storage.Open(*storeSpec)
+	if err != nil {
+		log.Printf("Error opening store: %v", err)
+		return 1
+	}
+	defer store.Close()
+
+	tlsConfig, authn, err := sec.load()
+	if err != nil {
+		log.Printf("Error setting up security: %v", err)
+		return 1
+	}
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Printf("Error listening on %s: %v", *addr, err)
+		return 1
+	}
+
+	srv := server.New(styleConfig, store)
+	srv.SetGraphvizLimits(gorph.GraphvizLimits{Timeout: *renderTimeout})
+	var grpcOpts []grpc.ServerOption
+	var gatewayOpts []gateway.Option
+	if tlsConfig != nil {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if authn != nil {
+		srv.RequireAuthorization()
+		grpcOpts = append(grpcOpts, grpc.UnaryInterceptor(auth.UnaryInterceptor(authn)), grpc.StreamInterceptor(auth.StreamInterceptor(authn)))
+		gatewayOpts = append(gatewayOpts, gateway.UnaryInterceptor(auth.UnaryInterceptor(authn)), gateway.StreamInterceptor(auth.StreamInterceptor(authn)))
+		if tlsConfig == nil {
+			log.Printf("Warning: authentication is enabled without TLS, so credentials are sent in the clear")
+		}
+	}
+	grpcServer := grpc.NewServer(grpcOpts...)
+	srv.Register(grpcServer)
+	reflection.Register(grpcServer)
+
+	var httpServer *http.Server
+	if *httpAddr != "" {
+		httpLis, err := net.Listen("tcp", *httpAddr)
+		if err != nil {
+			lis.Close()
+			log.Printf("Error listening on %s: %v", *httpAddr, err)
+			return 1
+		}
+		var handler http.Handler = gateway.New(srv, gatewayOpts...)
+		if *corsOrigins != "" {
+			handler = gateway.CORS(handler, strings.Split(*corsOrigins, ","))
+		}
+		httpServer = &http.Server{Handler: handler}
+		serve := httpServer.Serve
+		if tlsConfig != nil {
+			httpServer.TLSConfig = tlsConfig.Clone()
+			serve = func(l net.Listener) error { return httpServer.ServeTLS(l, "", "") }
+		}
+		go func() {
+			fmt.Fprintf(os.Stderr, "HTTP gateway listening on %s\n", httpLis.Addr())
+			if err := serve(httpLis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Error serving HTTP: %v", err)
+				grpcServer.Stop()
+			}
+		}()
+	}
+
+	// Finish in-flight requests on Ctrl-C or SIGTERM
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		fmt.Fprintf(os.Stderr, "Shutting down gRPC server\n")
+		if httpServer != nil {
+			// Watch streams only end when their clients disconnect, so
+			// give up on them after a while
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := httpServer.Shutdown(ctx); err != nil {
+				httpServer.Close()
+			}
+			cancel()
+		}
+		grpcServer.GracefulStop()
+	}()
+
+	fmt.Fprintf(os.Stderr, "gRPC server listening on %s\n", lis.Addr())
+	if err := grpcServer.Serve(lis); err != nil {
+		log.Printf("Error serving gRPC: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// security holds the TLS and authentication options of the serve command.
+type security struct {
+	certFile, keyFile string
+	clientCAFile      string
+	tokenFile         string
+	jwksFile          string
+	jwt               auth.JWTOptions
+}
+
+// load returns the TLS configuration, or nil to serve in plaintext, and the
+// authenticator, or nil if authentication is disabled.
+func (s security) load() (*tls.Config, auth.Authenticator, error) {
+	var tlsConfig *tls.Config
+	if s.certFile != "" || s.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading TLS certificate: %w", err)
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	}
+
+	var authenticators []auth.Authenticator
+	if s.clientCAFile != "" {
+		if tlsConfig == nil {
+			return nil, nil, fmt.Errorf("-client-ca requires -tls-cert and -tls-key")
+		}
+		data, err := os.ReadFile(s.clientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, nil, fmt.Errorf("no certificates in client CA file %s", s.clientCAFile)
+		}
+		// Clients may authenticate with a token instead
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		authenticators = append(authenticators, auth.ClientCertificates{})
+	}
+	if s.tokenFile != "" {
+		tokens, err := auth.LoadTokens(s.tokenFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		authenticators = append(authenticators, tokens)
+	}
+	if s.jwksFile != "" {
+		jwt, err := auth.LoadJWKS(s.jwksFile, s.jwt)
+		if err != nil {
+			return nil, nil, err
+		}
+		authenticators = append(authenticators, jwt)
+	}
+
+	if len(authenticators) == 0 {
+		return tlsConfig, nil, nil
+	}
+	return tlsConfig, auth.Chain(authenticators...), nil
+}
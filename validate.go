@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"gorph/v2/pkg/gorph"
+)
+
+// runValidate implements the "validate" command. It checks every given
+// infrastructure file, reports all problems with their source positions and
+// returns the process exit code.
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	inputFile := fs.String("input", "infra.yml", "Infrastructure file in YAML, JSON or TOML (- for stdin)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s validate [options] [file ...]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Validates infrastructure files and exits non-zero if any are invalid.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{*inputFile}
+	}
+
+	exitCode := 0
+	for _, file := range files {
+		if err := validateFile(file); err != nil {
+			printError(err)
+			exitCode = 1
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s: valid\n", displayName(file))
+	}
+	return exitCode
+}
+
+// validateFile loads and validates a single infrastructure file.
+func validateFile(path string) error {
+	infra, err := loadInfrastructure(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", displayName(path), err)
+	}
+	printWarnings(infra.Warnings())
+	if errs := gorph.Validate(infra); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// printWarnings writes problems that do not fail the command to stderr,
+// one line each.
+func printWarnings(warnings gorph.ValidationErrors) {
+	for _, w := range warnings {
+		fmt.Fprintln(os.Stderr, "warning: "+w.Error())
+	}
+}
+
+// printError writes err to stderr, one line per validation error.
+func printError(err error) {
+	var errs gorph.ValidationErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e.Error())
+		}
+		return
+	}
+	fmt.Fprintln(os.Stderr, err)
+}
@@ -9,8 +9,13 @@ export GOARCH=wasm
 # Build the WASM module
 go build -o ../frontend/gorph-app/public/gorph.wasm main.go
 
-# Copy the WASM exec helper
-cp "$(go env GOROOT)/misc/wasm/wasm_exec.js" ../frontend/gorph-app/public/
+# Copy the WASM exec helper, which Go 1.24 moved from misc/wasm to lib/wasm
+GOROOT="$(go env GOROOT)"
+if [ -f "$GOROOT/lib/wasm/wasm_exec.js" ]; then
+    cp "$GOROOT/lib/wasm/wasm_exec.js" ../frontend/gorph-app/public/
+else
+    cp "$GOROOT/misc/wasm/wasm_exec.js" ../frontend/gorph-app/public/
+fi
 
 echo "WASM build complete!"
 echo "Output files:"
@@ -2,4 +2,12 @@ module gorph-wasm
 
 go 1.23.0
 
-require gopkg.in/yaml.v3 v3.0.1
+require gorph/v2 v2.0.0-00010101000000-000000000000
+
+require (
+	github.com/BurntSushi/toml v1.4.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace gorph/v2 => ../..
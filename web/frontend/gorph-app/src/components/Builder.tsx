@@ -410,10 +410,10 @@ export default function Builder({ yamlContent, onYamlChange, svgOutput, dotOutpu
       return;
     }
 
-    if (!/^[a-zA-Z][a-zA-Z0-9_-]*$/.test(entityForm.id.trim())) {
+    if (!/^[a-zA-Z][a-zA-Z0-9_-]*(\/[a-zA-Z][a-zA-Z0-9_-]*)*$/.test(entityForm.id.trim())) {
       Alert.alert(
         'Validation Error', 
-        'Entity ID must start with a letter and contain only letters, numbers, underscores, and dashes. Dashes will be automatically converted to underscores for GraphViz compatibility.',
+        'Entity ID must start with a letter and contain only letters, numbers, underscores, and dashes, optionally after namespaces such as payments/.',
         [{ text: 'OK' }]
       );
       return;
@@ -929,8 +929,8 @@ const EntityForm = ({ entityForm, setEntityForm, onSave, onCancel }: any) => {
     
     if (!entityForm.id.trim()) {
       newErrors.id = 'Entity ID is required';
-    } else if (!/^[a-zA-Z][a-zA-Z0-9_-]*$/.test(entityForm.id.trim())) {
-      newErrors.id = 'ID must start with a letter and contain only letters, numbers, underscores, and dashes. Dashes will be automatically converted to underscores for GraphViz compatibility.';
+    } else if (!/^[a-zA-Z][a-zA-Z0-9_-]*(\/[a-zA-Z][a-zA-Z0-9_-]*)*$/.test(entityForm.id.trim())) {
+      newErrors.id = 'ID must start with a letter and contain only letters, numbers, underscores, and dashes, optionally after namespaces such as payments/.';
     }
     
     if (!entityForm.description.trim()) {